@@ -22,7 +22,6 @@ import (
 	"database/sql"
 	"fmt"
 	"masm/v3/corpus"
-	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
@@ -53,6 +52,7 @@ type Actions struct {
 	conf  *corpus.DatabaseSetup
 	cConf *corpus.CorporaSetup
 	db    DataHandler
+	pool  *corpus.CorpusPool
 }
 
 // NewActions is the default factory
@@ -60,37 +60,45 @@ func NewActions(
 	conf *corpus.DatabaseSetup,
 	cConf *corpus.CorporaSetup,
 	db DataHandler,
+	pool *corpus.CorpusPool,
 ) *Actions {
 	return &Actions{
 		conf:  conf,
 		cConf: cConf,
 		db:    db,
+		pool:  pool,
 	}
 }
 
 func (a *Actions) UpdateCorpusInfo(ctx *gin.Context) {
-	corpusID := ctx.Param("corpusId")
+	corpusID, err := corpus.CorpusIDFromRequest(ctx)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
 	baseErrTpl := "failed to update info for corpus %s: %w"
-	corpusInfo, err := corpus.GetCorpusInfo(corpusID, a.cConf, false)
+	corpusInfo, err := corpus.GetCorpusInfo(corpusID, a.cConf, a.pool, false)
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf(baseErrTpl, corpusID, err))
 		return
 	}
 	if !corpusInfo.IndexedData.Primary.Path.FileExists {
-		err := fmt.Errorf("data not found for corpus %s", corpusID)
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
+		err := fmt.Errorf("data %w", corpus.ErrNotFound)
+		corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf(baseErrTpl, corpusID, err))
 		return
 	}
 	transact, err := a.db.StartTx()
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf(baseErrTpl, corpusID, err))
+		return
+	}
 	err = a.db.UpdateSize(transact, corpusID, corpusInfo.IndexedData.Primary.Size)
 	if err != nil {
 		err2 := a.db.RollbackTx(transact)
 		if err2 != nil {
 			log.Error().Err(err2).Msg("failed to rollback transaction")
 		}
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf(baseErrTpl, corpusID, err))
 		return
 	}
 
@@ -101,23 +109,29 @@ func (a *Actions) UpdateCorpusInfo(ctx *gin.Context) {
 		if err2 != nil {
 			log.Error().Err(err2).Msg("failed to rollback transaction")
 		}
+		corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf(baseErrTpl, corpusID, err))
+		return
 	}
 
 	err = a.db.CommitTx(transact)
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
+		corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf(baseErrTpl, corpusID, err))
+		return
 	}
 	uniresp.WriteJSONResponse(ctx.Writer, updateSizeResp{OK: true})
 }
 
 func (a *Actions) InferKontextDefaults(ctx *gin.Context) {
-	corpusID := ctx.Param("corpusId")
+	corpusID, err := corpus.CorpusIDFromRequest(ctx)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
 
 	defaultViewAttrs, err := a.db.GetSimpleQueryDefaultAttrs(corpusID)
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError("Failed to get simple query default attrs: %w", err), http.StatusInternalServerError)
+		corpus.WriteErrorResponse(
+			ctx.Writer, fmt.Errorf("Failed to get simple query default attrs: %w", err))
 		return
 	}
 	defaultViewOpts := DefaultViewOpts{
@@ -125,10 +139,10 @@ func (a *Actions) InferKontextDefaults(ctx *gin.Context) {
 	}
 
 	if len(defaultViewOpts.Attrs) == 0 {
-		corpusAttrs, err := corpus.GetCorpusAttrs(corpusID, a.cConf)
+		corpusAttrs, err := corpus.GetCorpusAttrs(corpusID, a.pool)
 		if err != nil {
-			uniresp.WriteJSONErrorResponse(
-				ctx.Writer, uniresp.NewActionError("Failed to get corpus attrs: %w", err), http.StatusInternalServerError)
+			corpus.WriteErrorResponse(
+				ctx.Writer, fmt.Errorf("Failed to get corpus attrs: %w", err))
 			return
 		}
 
@@ -143,23 +157,23 @@ func (a *Actions) InferKontextDefaults(ctx *gin.Context) {
 
 	tagsetAttrs, err := a.db.GetCorpusTagsetAttrs(corpusID)
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError("Failed to get corpus tagset attrs: %w", err), http.StatusInternalServerError)
+		corpus.WriteErrorResponse(
+			ctx.Writer, fmt.Errorf("Failed to get corpus tagset attrs: %w", err))
 		return
 	}
 	defaultViewOpts.Attrs = append(defaultViewOpts.Attrs, tagsetAttrs...)
 
 	tx, err := a.db.StartTx()
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError("Failed to start database transaction: %w", err), http.StatusInternalServerError)
+		corpus.WriteErrorResponse(
+			ctx.Writer, fmt.Errorf("Failed to start database transaction: %w", err))
 		return
 	}
 	err = a.db.UpdateDefaultViewOpts(tx, corpusID, defaultViewOpts)
 	if err != nil {
 		tx.Rollback()
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError("Failed to update `default_view_opts`: %w", err), http.StatusInternalServerError)
+		corpus.WriteErrorResponse(
+			ctx.Writer, fmt.Errorf("Failed to update `default_view_opts`: %w", err))
 		return
 	}
 	tx.Commit()
@@ -19,14 +19,18 @@
 package corpus
 
 import (
-	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 
 	"github.com/czcorpus/cnc-gokit/uniresp"
+
+	"masm/v3/jobs"
 )
 
 type CorpusInfoProvider interface {
@@ -38,50 +42,172 @@ type Actions struct {
 	conf         *CorporaSetup
 	osSignal     chan os.Signal
 	infoProvider CorpusInfoProvider
+	lister       *CorporaLister
+	jobs         *jobs.Manager
+	pool         *CorpusPool
+}
+
+// ListCorpora provides a list of all the corpora found in the registry
+// directories. Supported arguments: `variant`, `offset` and `limit`.
+func (a *Actions) ListCorpora(ctx *gin.Context) {
+	args := ListArgs{Variant: CorpusVariant(ctx.Query("variant"))}
+	if args.Variant != "" && !a.conf.IsValidVariant(args.Variant) {
+		WriteErrorResponse(
+			ctx.Writer, fmt.Errorf("%w: invalid corpus variant %s", ErrInvalidArgs, args.Variant))
+		return
+	}
+	for _, p := range []struct {
+		name   string
+		target *int
+	}{{"offset", &args.Offset}, {"limit", &args.Limit}} {
+		if !ctx.Request.URL.Query().Has(p.name) {
+			continue
+		}
+		v, err := strconv.Atoi(ctx.Query(p.name))
+		if err != nil || v < 0 {
+			WriteErrorResponse(
+				ctx.Writer, fmt.Errorf("%w: invalid %s value", ErrInvalidArgs, p.name))
+			return
+		}
+		*p.target = v
+	}
+	ans, err := a.lister.List(args)
+	if err != nil {
+		WriteErrorResponse(ctx.Writer, fmt.Errorf("failed to list corpora: %w", err))
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
 }
 
 // GetCorpusInfo provides some basic information about stored data
 func (a *Actions) GetCorpusInfo(ctx *gin.Context) {
-	var err error
-	corpusID := ctx.Param("corpusId")
+	corpusID, err := CorpusIDFromRequest(ctx)
+	if err != nil {
+		WriteErrorResponse(ctx.Writer, err)
+		return
+	}
 	baseErrTpl := "failed to get corpus info for %s: %w"
 	dbInfo, err := a.infoProvider.LoadInfo(corpusID)
+	if err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to load corpus info")
+		WriteErrorResponse(ctx.Writer, fmt.Errorf(baseErrTpl, corpusID, err))
+		return
+	}
+	ans, err := GetCorpusInfo(corpusID, a.conf, a.pool, dbInfo.HasLimitedVariant)
+	if err != nil {
+		log.Error().Err(err).Str("corpusId", corpusID).Msg("failed to get corpus info")
+		WriteErrorResponse(ctx.Writer, fmt.Errorf(baseErrTpl, corpusID, err))
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
 
-	if err == sql.ErrNoRows {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
-		log.Error().Err(err)
+// SyncData starts a background job synchronizing corpus data
+// between the distributed FS and the local storage. The response
+// contains information about the job which can be further
+// watched via the jobs API. The `direction` argument can be used
+// to specify the source location explicitly (see DataSyncDirection).
+func (a *Actions) SyncData(ctx *gin.Context) {
+	corpusID, err := CorpusIDFromRequest(ctx)
+	if err != nil {
+		WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	if !a.conf.IsSyncAllowed(corpusID) {
+		WriteErrorResponse(
+			ctx.Writer,
+			fmt.Errorf("%w: data synchronization not allowed for %s", ErrForbidden, corpusID),
+		)
+		return
+	}
+	direction := DataSyncDirection(ctx.DefaultQuery("direction", string(DataSyncDirectionAuto)))
+	dataSync, err := NewDataSync(corpusID, direction, a.conf)
+	if err != nil {
+		WriteErrorResponse(
+			ctx.Writer,
+			fmt.Errorf("failed to prepare data synchronization for %s: %w", corpusID, err),
+		)
+		return
+	}
+	// verification must not read data being rewritten
+	jobInfo, err := a.jobs.Start(DataSyncJobType, corpusID, dataSync.Run, DataVerifyJobType)
+	if err != nil {
+		WriteErrorResponse(ctx.Writer, err)
 		return
+	}
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, jobInfo)
+}
 
-	} else if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
-		log.Error().Err(err)
+// VerifyData starts a background job checking consistency
+// of corpus data. Once finished, the job result contains
+// a structured report (see VerifyReport).
+func (a *Actions) VerifyData(ctx *gin.Context) {
+	corpusID, err := CorpusIDFromRequest(ctx)
+	if err != nil {
+		WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	verification, err := NewDataVerification(corpusID, a.conf)
+	if err != nil {
+		WriteErrorResponse(
+			ctx.Writer,
+			fmt.Errorf("failed to prepare data verification for %s: %w", corpusID, err),
+		)
 		return
 	}
-	ans, err := GetCorpusInfo(corpusID, a.conf, dbInfo.HasLimitedVariant)
-	if err == CorpusNotFound {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusNotFound)
-		log.Error().Err(err)
+	jobInfo, err := a.jobs.Start(DataVerifyJobType, corpusID, verification.Run, DataSyncJobType)
+	if err != nil {
+		verification.Close()
+		WriteErrorResponse(ctx.Writer, err)
 		return
+	}
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, jobInfo)
+}
 
-	} else if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer, uniresp.NewActionError(baseErrTpl, corpusID, err), http.StatusInternalServerError)
-		log.Error().Err(err)
+// VerticalStats starts a background job inspecting corpus vertical
+// file. In case the inspection is already running, information
+// about the running job is returned. Supported arguments:
+// `sentenceStruct` and `docStruct`.
+func (a *Actions) VerticalStats(ctx *gin.Context) {
+	corpusID, err := CorpusIDFromRequest(ctx)
+	if err != nil {
+		WriteErrorResponse(ctx.Writer, err)
 		return
 	}
-	uniresp.WriteJSONResponse(ctx.Writer, ans)
+	inspection, err := NewVerticalInspection(
+		corpusID, a.conf, ctx.Query("sentenceStruct"), ctx.Query("docStruct"))
+	if err != nil {
+		WriteErrorResponse(
+			ctx.Writer,
+			fmt.Errorf("failed to prepare vertical inspection for %s: %w", corpusID, err),
+		)
+		return
+	}
+	jobInfo, err := a.jobs.Start(VerticalStatsJobType, corpusID, inspection.Run)
+	if err != nil && !errors.Is(err, jobs.ErrJobRunning) {
+		WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, jobInfo)
+}
+
+// CorpusPoolStats provides information about opened corpora
+func (a *Actions) CorpusPoolStats(ctx *gin.Context) {
+	uniresp.WriteJSONResponse(ctx.Writer, a.pool.Stats())
 }
 
 // NewActions is the default factory
 func NewActions(
 	conf *CorporaSetup,
 	infoProvider CorpusInfoProvider,
+	jobsManager *jobs.Manager,
+	pool *CorpusPool,
 ) *Actions {
 	return &Actions{
 		conf:         conf,
 		infoProvider: infoProvider,
+		lister:       NewCorporaLister(conf),
+		jobs:         jobsManager,
+		pool:         pool,
 	}
 }
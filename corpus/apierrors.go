@@ -0,0 +1,123 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"masm/v3/jobs"
+	"masm/v3/mango"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+)
+
+const (
+	ErrorCodeQuerySyntax     ErrorCode = "QUERY_SYNTAX"
+	ErrorCodeCorpusNotFound  ErrorCode = "CORPUS_NOT_FOUND"
+	ErrorCodeAttrNotFound    ErrorCode = "ATTR_NOT_FOUND"
+	ErrorCodeInvalidCorpusID ErrorCode = "INVALID_CORPUS_ID"
+	ErrorCodeInvalidArgs     ErrorCode = "INVALID_ARGS"
+	ErrorCodeInvalidRequest  ErrorCode = "INVALID_REQUEST"
+	ErrorCodeNotFound        ErrorCode = "NOT_FOUND"
+	ErrorCodeAlreadyExists   ErrorCode = "ALREADY_EXISTS"
+	ErrorCodeJobRunning      ErrorCode = "JOB_RUNNING"
+	ErrorCodeForbidden       ErrorCode = "FORBIDDEN"
+	ErrorCodeIO              ErrorCode = "IO_ERROR"
+	ErrorCodeManatee         ErrorCode = "MANATEE_ERROR"
+	ErrorCodeInternal        ErrorCode = "INTERNAL_ERROR"
+)
+
+var (
+	// ErrInvalidArgs should be wrapped by errors caused
+	// by invalid request arguments
+	ErrInvalidArgs = errors.New("invalid arguments")
+
+	// ErrInvalidRequest should be wrapped by errors caused
+	// by a malformed request (e.g. undecodable body)
+	ErrInvalidRequest = errors.New("invalid request")
+
+	// ErrNotFound should be wrapped by errors caused by a missing
+	// resource other than a corpus (see CorpusNotFound)
+	ErrNotFound = errors.New("not found")
+
+	// ErrAlreadyExists should be wrapped by errors caused
+	// by an attempt to create an existing resource
+	ErrAlreadyExists = errors.New("already exists")
+
+	// ErrForbidden should be wrapped by errors caused
+	// by a disallowed operation
+	ErrForbidden = errors.New("forbidden")
+)
+
+// ErrorCode is a machine-readable identifier of an error type
+type ErrorCode string
+
+// ErrorResponse is a variant of uniresp.ErrorResponse
+// with an additional error code
+type ErrorResponse struct {
+	Error     string    `json:"error"`
+	ErrorCode ErrorCode `json:"errorCode"`
+	Code      int       `json:"code"`
+	Details   []string  `json:"details,omitempty"`
+}
+
+// ClassifyError maps an error to an HTTP status and an error code
+func ClassifyError(err error) (int, ErrorCode) {
+	switch {
+	case errors.Is(err, mango.ErrQuerySyntax):
+		return http.StatusBadRequest, ErrorCodeQuerySyntax
+	case errors.Is(err, ErrInvalidCorpusID):
+		return http.StatusBadRequest, ErrorCodeInvalidCorpusID
+	case errors.Is(err, CorpusNotFound), errors.Is(err, mango.ErrCorpusNotFound):
+		return http.StatusNotFound, ErrorCodeCorpusNotFound
+	case errors.Is(err, mango.ErrAttrNotFound):
+		return http.StatusUnprocessableEntity, ErrorCodeAttrNotFound
+	case errors.Is(err, ErrInvalidArgs):
+		return http.StatusUnprocessableEntity, ErrorCodeInvalidArgs
+	case errors.Is(err, ErrInvalidRequest):
+		return http.StatusBadRequest, ErrorCodeInvalidRequest
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrVerticalNotAvailable), errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound, ErrorCodeNotFound
+	case errors.Is(err, ErrAlreadyExists):
+		return http.StatusConflict, ErrorCodeAlreadyExists
+	case errors.Is(err, jobs.ErrJobRunning):
+		return http.StatusConflict, ErrorCodeJobRunning
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden, ErrorCodeForbidden
+	case errors.Is(err, mango.ErrIO):
+		return http.StatusInternalServerError, ErrorCodeIO
+	case errors.Is(err, mango.ErrManatee):
+		return http.StatusInternalServerError, ErrorCodeManatee
+	}
+	return http.StatusInternalServerError, ErrorCodeInternal
+}
+
+// WriteErrorResponse writes an error response with HTTP status
+// and error code based on the error type (see ClassifyError).
+// Optional details can further describe the problem.
+func WriteErrorResponse(w http.ResponseWriter, err error, details ...string) {
+	status, code := ClassifyError(err)
+	uniresp.WriteCustomJSONErrorResponse(
+		w,
+		ErrorResponse{Error: err.Error(), ErrorCode: code, Code: status, Details: details},
+		status,
+	)
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"masm/v3/jobs"
+	"masm/v3/mango"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   ErrorCode
+	}{
+		{fmt.Errorf("%w: foo", mango.ErrQuerySyntax), http.StatusBadRequest, ErrorCodeQuerySyntax},
+		{fmt.Errorf("%w: ..", ErrInvalidCorpusID), http.StatusBadRequest, ErrorCodeInvalidCorpusID},
+		{fmt.Errorf("failed: %w", CorpusNotFound), http.StatusNotFound, ErrorCodeCorpusNotFound},
+		{fmt.Errorf("%w: limit", ErrInvalidArgs), http.StatusUnprocessableEntity, ErrorCodeInvalidArgs},
+		{fmt.Errorf("%w: bad JSON", ErrInvalidRequest), http.StatusBadRequest, ErrorCodeInvalidRequest},
+		{fmt.Errorf("registry %w", ErrNotFound), http.StatusNotFound, ErrorCodeNotFound},
+		{fmt.Errorf("%w: foo.vert", ErrVerticalNotAvailable), http.StatusNotFound, ErrorCodeNotFound},
+		{fmt.Errorf("failed to load info: %w", sql.ErrNoRows), http.StatusNotFound, ErrorCodeNotFound},
+		{fmt.Errorf("tagset %w", ErrAlreadyExists), http.StatusConflict, ErrorCodeAlreadyExists},
+		{jobs.ErrJobRunning, http.StatusConflict, ErrorCodeJobRunning},
+		{fmt.Errorf("%w: sync", ErrForbidden), http.StatusForbidden, ErrorCodeForbidden},
+		{ErrDataSyncNotConfigured, http.StatusInternalServerError, ErrorCodeInternal},
+		{errors.New("foo"), http.StatusInternalServerError, ErrorCodeInternal},
+	}
+	for _, tst := range tests {
+		status, code := ClassifyError(tst.err)
+		assert.Equal(t, tst.status, status, tst.err.Error())
+		assert.Equal(t, tst.code, code, tst.err.Error())
+	}
+}
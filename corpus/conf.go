@@ -48,9 +48,17 @@ type CorporaSetup struct {
 	AltAccessMapping     map[string]string `json:"altAccessMapping"` // registry => data mapping
 	WordSketchDefDirPath string            `json:"wordSketchDefDirPath"`
 	ManateeDynlibPath    string            `json:"manateeDynlibPath"`
+	TagsetsDirPath       string            `json:"tagsetsDirPath"`
+	SyncAllowedCorpora   []string          `json:"syncAllowedCorpora"`
+	DataSync             DataSyncSetup     `json:"dataSync"`
+	CorpusPoolSize       int               `json:"corpusPoolSize"`
+	ConcCache            ConcCacheSetup    `json:"concCache"`
 }
 
 func (cs *CorporaSetup) GetFirstValidRegistry(corpusID, subDir string) string {
+	if ValidateCorpusID(corpusID) != nil || (subDir != "" && ValidateCorpusID(subDir) != nil) {
+		return ""
+	}
 	for _, dir := range cs.RegistryDirPaths {
 		d := filepath.Join(dir, subDir, corpusID)
 		pe := fs.PathExists(d)
@@ -67,6 +75,47 @@ func (cs *CorporaSetup) SubdirIsInAltAccessMapping(subdir string) bool {
 	return ok
 }
 
+// IsSyncAllowed tests whether data of a corpus can be synchronized
+// between the distributed FS and the local storage
+func (cs *CorporaSetup) IsSyncAllowed(corpusID string) bool {
+	for _, c := range cs.SyncAllowedCorpora {
+		if c == corpusID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidVariant tests whether the variant is either one of
+// the predefined ones or it is configured in AltAccessMapping
+func (cs *CorporaSetup) IsValidVariant(variant CorpusVariant) bool {
+	return variant == CorpusVariantPrimary || variant == CorpusVariantLimited ||
+		cs.SubdirIsInAltAccessMapping(string(variant))
+}
+
+// DataSyncSetup configures synchronization of corpora data
+// between a distributed FS and a local storage (typically an SSD).
+type DataSyncSetup struct {
+	DistribFSDataDir string `json:"distribFsDataDir"`
+	LocalDataDir     string `json:"localDataDir"`
+
+	// RsyncPath is a path to the rsync executable. If empty,
+	// a native copier is used instead.
+	RsyncPath string `json:"rsyncPath"`
+}
+
+// ConcCacheSetup configures limits of the concordance cache.
+// Zero values mean default limits.
+type ConcCacheSetup struct {
+	MaxEntriesPerCorp int   `json:"maxEntriesPerCorp"`
+	MaxCorpSizeBytes  int64 `json:"maxCorpSizeBytes"`
+	MaxSizeBytes      int64 `json:"maxSizeBytes"`
+
+	// NegativeEntryTTLSecs specifies how long failed
+	// concordance calculations are remembered
+	NegativeEntryTTLSecs int `json:"negativeEntryTtlSecs"`
+}
+
 type DatabaseSetup struct {
 	Host                     string `json:"host"`
 	User                     string `json:"user"`
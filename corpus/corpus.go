@@ -73,10 +73,12 @@ type IndexedData struct {
 
 // Info wraps information about a corpus installation
 type Info struct {
-	ID             string       `json:"id"`
-	IndexedData    IndexedData  `json:"indexedData"`
-	IndexedStructs []string     `json:"indexedStructs"`
-	RegistryConf   RegistryConf `json:"registry"`
+	ID             string        `json:"id"`
+	IndexedData    IndexedData   `json:"indexedData"`
+	IndexedStructs []string      `json:"indexedStructs"`
+	Attrs          []AttrStats   `json:"attrs"`
+	Structs        []StructStats `json:"structs"`
+	RegistryConf   RegistryConf  `json:"registry"`
 }
 
 // InfoError is a general corpus data information error.
@@ -84,10 +86,18 @@ type InfoError struct {
 	error
 }
 
+func (err InfoError) Unwrap() error {
+	return err.error
+}
+
 type CorpusError struct {
 	error
 }
 
+func (err CorpusError) Unwrap() error {
+	return err.error
+}
+
 // bindValueToPath creates a new FileMappedValue instance
 // using 'value' argument. Then it tests whether the
 // 'path' exists and if so then it sets related properties
@@ -156,54 +166,55 @@ func getCorpusInfo(corpus *mango.GoCorpus) (*Data, error) {
 	return ans, nil
 }
 
-func openCorpus(regPath string) (*mango.GoCorpus, error) {
-	corp, err := mango.OpenCorpus(regPath)
-	if err != nil {
-		if strings.Contains(err.Error(), "CorpInfoNotFound") {
-			return nil, CorpusNotFound
-
-		}
-		return nil, fmt.Errorf("Manatee failed to open %s: %w", regPath, err)
-	}
-	return corp, err
-}
-
 // GetCorpusInfo provides miscellaneous corpus installation information mostly
 // related to different data files.
 // It should return an error only in case Manatee or filesystem produces some
 // error (i.e. not in case something is just not found).
-func GetCorpusInfo(corpusID string, setup *CorporaSetup, tryLimited bool) (*Info, error) {
+func GetCorpusInfo(corpusID string, setup *CorporaSetup, pool *CorpusPool, tryLimited bool) (*Info, error) {
 	ans := &Info{ID: corpusID}
 	ans.IndexedData = IndexedData{}
 	ans.RegistryConf = RegistryConf{Paths: make([]FileMappedValue, 0, 10)}
 	ans.RegistryConf.SubcorpAttrs = make(map[string][]string)
 
+	if err := ValidateCorpusID(corpusID); err != nil {
+		return nil, err
+	}
 	corpReg1 := setup.GetFirstValidRegistry(corpusID, CorpusVariantPrimary.SubDir())
+	if corpReg1 == "" {
+		return nil, CorpusNotFound
+	}
 	value, err := bindValueToPath(corpReg1, corpReg1)
 	if err != nil {
 		return nil, InfoError{err}
 	}
 	ans.RegistryConf.Paths = append(ans.RegistryConf.Paths, value)
 
-	corp1, err := openCorpus(corpReg1)
+	handle1, err := pool.AcquireByPath(corpReg1)
 	if err != nil {
 		return nil, InfoError{err}
 	}
-	defer mango.CloseCorpus(corp1)
+	defer handle1.Release()
+	corp1 := handle1.Corpus()
 	corp1Info, err := getCorpusInfo(corp1)
 	if err != nil {
 		return nil, InfoError{fmt.Errorf("Failed to get info about %s: %w", corpReg1, err)}
 	}
 	ans.IndexedData.Primary = corp1Info
+	if corp1Info.ManateeError == nil && corp1Info.Path.FileExists {
+		ans.Attrs, ans.Structs, err = getAttrsStats(corp1, corp1Info.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if tryLimited {
 		corpReg2 := setup.GetFirstValidRegistry(corpusID, CorpusVariantLimited.SubDir())
-		corp2, err := openCorpus(corpReg2)
+		handle2, err := pool.AcquireByPath(corpReg2)
 		if err != nil {
 			return nil, InfoError{err}
 		}
-		defer mango.CloseCorpus(corp2)
-		corp2Info, err := getCorpusInfo(corp2)
+		defer handle2.Release()
+		corp2Info, err := getCorpusInfo(handle2.Corpus())
 		if err != nil {
 			return nil, InfoError{fmt.Errorf("Failed to get info about %s: %w", corpReg2, err)}
 		}
@@ -257,36 +268,52 @@ func GetCorpusInfo(corpusID string, setup *CorporaSetup, tryLimited bool) (*Info
 	return ans, nil
 }
 
-func OpenCorpus(corpusID string, setup *CorporaSetup) (*mango.GoCorpus, error) {
+// findRegistryPath finds a registry file of a corpus specified either by
+// [corpus ID] or by [sub dir.]/[corpus ID] (relative to registry directories)
+func findRegistryPath(corpusID string, setup *CorporaSetup) (string, error) {
+	if err := ValidateCorpusID(corpusID); err != nil {
+		return "", err
+	}
 	for _, regPathRoot := range setup.RegistryDirPaths {
 		regPath := filepath.Join(regPathRoot, corpusID)
 		isFile, err := fs.IsFile(regPath)
 		if err != nil {
-			return nil, InfoError{err}
+			return "", InfoError{err}
 		}
 		if isFile {
-			corp, err := mango.OpenCorpus(regPath)
-			if err != nil {
-				if strings.Contains(err.Error(), "CorpInfoNotFound") {
-					return nil, CorpusNotFound
+			return regPath, nil
+		}
+	}
+	return "", CorpusNotFound
+}
+
+// OpenCorpus opens a corpus specified either by [corpus ID]
+// or by [sub dir.]/[corpus ID] (relative to registry directories)
+func OpenCorpus(corpusID string, setup *CorporaSetup) (*mango.GoCorpus, error) {
+	regPath, err := findRegistryPath(corpusID, setup)
+	if err != nil {
+		return nil, err
+	}
+	corp, err := mango.OpenCorpus(regPath)
+	if err != nil {
+		if errors.Is(err, mango.ErrCorpusNotFound) {
+			return nil, CorpusNotFound
 
-				}
-				return nil, CorpusError{err}
-			}
-			return corp, nil
 		}
+		return nil, CorpusError{err}
 	}
-	return nil, CorpusNotFound
+	return corp, nil
 }
 
-func GetCorpusAttrs(corpusID string, setup *CorporaSetup) ([]string, error) {
+func GetCorpusAttrs(corpusID string, pool *CorpusPool) ([]string, error) {
 
-	corp, err := OpenCorpus(corpusID, setup)
+	handle, err := pool.Acquire(corpusID)
 	if err != nil {
 		return []string{}, err
 	}
+	defer handle.Release()
 
-	unparsedStructs, err := mango.GetCorpusConf(corp, "ATTRLIST")
+	unparsedStructs, err := mango.GetCorpusConf(handle.Corpus(), "ATTRLIST")
 	if err != nil {
 		return nil, InfoError{err}
 	}
@@ -0,0 +1,76 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// CorpusIDParam is a URL path parameter containing a corpus ID
+	// or (in case CorpusIDInSubdirParam is present) a registry sub-directory.
+	// Gin does not allow different parameter names on the same
+	// position so both forms of addressing share the parameter.
+	CorpusIDParam = "corpusId"
+
+	// CorpusIDInSubdirParam is a URL path parameter containing
+	// a corpus ID in case the corpus is addressed as [sub dir.]/[corpus ID]
+	CorpusIDInSubdirParam = "corpusIdInSubdir"
+)
+
+var (
+	ErrInvalidCorpusID = errors.New("invalid corpus ID")
+
+	corpusIDPartRegexp = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]*$`)
+)
+
+// ValidateCorpusID tests whether a corpus ID is either in the form
+// [corpus ID] or [sub dir.]/[corpus ID] and does not refer to anything
+// outside registry directories.
+func ValidateCorpusID(corpusID string) error {
+	parts := strings.Split(corpusID, "/")
+	if len(parts) > 2 {
+		return fmt.Errorf("%w: %s", ErrInvalidCorpusID, corpusID)
+	}
+	for _, p := range parts {
+		if !corpusIDPartRegexp.MatchString(p) {
+			return fmt.Errorf("%w: %s", ErrInvalidCorpusID, corpusID)
+		}
+	}
+	return nil
+}
+
+// CorpusIDFromRequest obtains a corpus ID from URL path parameters.
+// Both [corpus ID] and [sub dir.]/[corpus ID] forms are supported
+// (the latter is returned as a single ID with the slash).
+func CorpusIDFromRequest(ctx *gin.Context) (string, error) {
+	corpusID := ctx.Param(CorpusIDParam)
+	if inSubdir := ctx.Param(CorpusIDInSubdirParam); inSubdir != "" {
+		corpusID = corpusID + "/" + inSubdir
+	}
+	if err := ValidateCorpusID(corpusID); err != nil {
+		return "", err
+	}
+	return corpusID, nil
+}
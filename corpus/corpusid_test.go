@@ -0,0 +1,103 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCorpusID(t *testing.T) {
+	tests := []struct {
+		corpusID string
+		valid    bool
+	}{
+		{"syn2020", true},
+		{"syn_v11", true},
+		{"SYN2020-a.b", true},
+		{"sub/corp", true},
+		{"_sub/corp", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../syn2020", false},
+		{"sub/..", false},
+		{"a/../b", false},
+		{"/syn2020", false},
+		{"/etc/passwd", false},
+		{"a/b/c", false},
+		{"a//b", false},
+		{"a/", false},
+		{"/", false},
+		{".hidden", false},
+		{"sub/.hidden", false},
+		{".sub/corp", false},
+		{"-syn", false},
+		{"syn 2020", false},
+		{"syn\\2020", false},
+		{"syn2020\x00", false},
+	}
+	for _, tst := range tests {
+		err := ValidateCorpusID(tst.corpusID)
+		if tst.valid {
+			assert.NoError(t, err, "corpus ID %q", tst.corpusID)
+
+		} else {
+			assert.ErrorIs(t, err, ErrInvalidCorpusID, "corpus ID %q", tst.corpusID)
+		}
+	}
+}
+
+func TestCorpusIDFromRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		params   gin.Params
+		expected string
+		valid    bool
+	}{
+		{gin.Params{{Key: CorpusIDParam, Value: "syn2020"}}, "syn2020", true},
+		{
+			gin.Params{{Key: CorpusIDParam, Value: "sub"}, {Key: CorpusIDInSubdirParam, Value: "corp"}},
+			"sub/corp",
+			true,
+		},
+		{gin.Params{{Key: CorpusIDParam, Value: ".."}}, "", false},
+		{
+			gin.Params{{Key: CorpusIDParam, Value: ".."}, {Key: CorpusIDInSubdirParam, Value: "corp"}},
+			"",
+			false,
+		},
+		{gin.Params{}, "", false},
+	}
+	for _, tst := range tests {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Params = tst.params
+		corpusID, err := CorpusIDFromRequest(ctx)
+		if tst.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, tst.expected, corpusID)
+
+		} else {
+			assert.ErrorIs(t, err, ErrInvalidCorpusID)
+		}
+	}
+}
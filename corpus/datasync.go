@@ -0,0 +1,496 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"masm/v3/jobs"
+	"masm/v3/mango"
+)
+
+const (
+	DataSyncJobType = "dataSync"
+
+	fileMtimeFormat = "2006-01-02T15:04:05-0700"
+
+	DataSyncMethodRsync  = "rsync"
+	DataSyncMethodNative = "native"
+
+	DataSyncDirectionAuto        DataSyncDirection = "auto"
+	DataSyncDirectionToLocal     DataSyncDirection = "toLocal"
+	DataSyncDirectionToDistribFS DataSyncDirection = "toDistribFS"
+)
+
+var (
+	ErrDataSyncNotConfigured = errors.New("data synchronization not configured")
+	ErrDataPathNotSyncable   = errors.New("corpus data path is not within configured data directories")
+	ErrDataSyncEmptySource   = errors.New("refusing to synchronize from an empty data directory")
+
+	rsyncProgressRegexp = regexp.MustCompile(`^\s*([\d,]+)\s+(\d+)%`)
+)
+
+// DataSyncDirection specifies which location is used as the source
+// of a data synchronization. With DataSyncDirectionAuto, the location
+// containing more recently modified files is used.
+type DataSyncDirection string
+
+// Validate tests whether the direction is one of the supported values
+func (d DataSyncDirection) Validate() error {
+	switch d {
+	case DataSyncDirectionAuto, DataSyncDirectionToLocal, DataSyncDirectionToDistribFS:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown sync direction '%s'", ErrInvalidArgs, d)
+}
+
+// DataSyncResult describes a finished data synchronization
+type DataSyncResult struct {
+	Source       FileMappedValue `json:"source"`
+	Target       FileMappedValue `json:"target"`
+	Direction    string          `json:"direction"`
+	Method       string          `json:"method,omitempty"`
+	UpToDate     bool            `json:"upToDate"`
+	CopiedFiles  int             `json:"copiedFiles"`
+	DeletedFiles int             `json:"deletedFiles"`
+	CopiedBytes  int64           `json:"copiedBytes"`
+}
+
+// DataSync synchronizes data of a single corpus between
+// the distributed FS and the local storage.
+type DataSync struct {
+	conf      DataSyncSetup
+	distribFS string
+	local     string
+	direction DataSyncDirection
+}
+
+// dataDirState creates a FileMappedValue for a data directory.
+// The LastModified value is set to the mtime of the most recently
+// modified regular file within the directory (mtimes of directories
+// are ignored as they change e.g. with file removal or with creation
+// of an empty directory) and Size is the total size of all the files.
+// For a directory without any regular files, LastModified is nil.
+func dataDirState(path string) (FileMappedValue, error) {
+	ans := FileMappedValue{Value: path, Path: path}
+	st, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ans, nil
+
+	} else if err != nil {
+		return ans, err
+	}
+	if !st.IsDir() {
+		return ans, fmt.Errorf("%s is not a directory", path)
+	}
+	ans.FileExists = true
+	var latest time.Time
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		ans.Size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return ans, err
+	}
+	if !latest.IsZero() {
+		mtime := latest.Format(fileMtimeFormat)
+		ans.LastModified = &mtime
+	}
+	return ans, nil
+}
+
+// isNewer tests whether fmv1 has been modified after fmv2
+// (non-existing values are always older)
+func isNewer(fmv1, fmv2 FileMappedValue) (bool, error) {
+	if fmv1.LastModified == nil {
+		return false, nil
+	}
+	if fmv2.LastModified == nil {
+		return true, nil
+	}
+	t1, err := time.Parse(fileMtimeFormat, *fmv1.LastModified)
+	if err != nil {
+		return false, err
+	}
+	t2, err := time.Parse(fileMtimeFormat, *fmv2.LastModified)
+	if err != nil {
+		return false, err
+	}
+	return t1.After(t2), nil
+}
+
+// selectSource determines the source and the target of the synchronization.
+// In case the direction is not set explicitly and none of the locations
+// contains newer files, the returned upToDate is true.
+func (ds *DataSync) selectSource(distribState, localState FileMappedValue) (src, dst FileMappedValue, upToDate bool, err error) {
+	switch ds.direction {
+	case DataSyncDirectionToLocal:
+		return distribState, localState, false, nil
+	case DataSyncDirectionToDistribFS:
+		return localState, distribState, false, nil
+	}
+	localNewer, err := isNewer(localState, distribState)
+	if err != nil {
+		return
+	}
+	if localNewer {
+		return localState, distribState, false, nil
+	}
+	distribNewer, err := isNewer(distribState, localState)
+	if err != nil {
+		return
+	}
+	return distribState, localState, !distribNewer, nil
+}
+
+// Run performs the synchronization. Unless the direction is set
+// explicitly, the side with more recently modified files is used
+// as the source. As the target becomes a mirror of the source
+// (including removal of files), a source without any data is
+// always rejected.
+func (ds *DataSync) Run(ctx context.Context, job *jobs.Job) (any, error) {
+	job.SetProgress(0, 0, "comparing data directories")
+	distribState, err := dataDirState(ds.distribFS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to examine %s: %w", ds.distribFS, err)
+	}
+	localState, err := dataDirState(ds.local)
+	if err != nil {
+		return nil, fmt.Errorf("failed to examine %s: %w", ds.local, err)
+	}
+	direction := ds.direction
+	if direction == "" {
+		direction = DataSyncDirectionAuto
+	}
+	ans := &DataSyncResult{Direction: string(direction)}
+	ans.Source, ans.Target, ans.UpToDate, err = ds.selectSource(distribState, localState)
+	if err != nil {
+		return nil, err
+	}
+	if ans.UpToDate {
+		job.SetProgress(0, 0, "data already synchronized")
+		return ans, nil
+	}
+	if ans.Source.LastModified == nil || ans.Source.Size == 0 {
+		return ans, fmt.Errorf("%w: %s", ErrDataSyncEmptySource, ans.Source.Path)
+	}
+	if ds.conf.RsyncPath != "" {
+		ans.Method = DataSyncMethodRsync
+		err = ds.rsync(ctx, job, ans)
+
+	} else {
+		ans.Method = DataSyncMethodNative
+		err = ds.copyNative(ctx, job, ans)
+	}
+	if err != nil {
+		return ans, err
+	}
+	ans.Target, err = dataDirState(ans.Target.Path)
+	if err != nil {
+		return ans, err
+	}
+	return ans, nil
+}
+
+// scanProgressLines is a bufio.SplitFunc splitting rsync output
+// by both newlines and carriage returns
+func scanProgressLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func (ds *DataSync) rsync(ctx context.Context, job *jobs.Job, result *DataSyncResult) error {
+	if err := os.MkdirAll(result.Target.Path, 0755); err != nil {
+		return fmt.Errorf("failed to create target directory: %w", err)
+	}
+	cmd := exec.CommandContext(
+		ctx,
+		ds.conf.RsyncPath,
+		"-a", "--delete", "--info=progress2",
+		result.Source.Path+string(filepath.Separator),
+		result.Target.Path+string(filepath.Separator),
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to run rsync: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run rsync: %w", err)
+	}
+	scanner := bufio.NewScanner(stdout)
+	scanner.Split(scanProgressLines)
+	for scanner.Scan() {
+		srch := rsyncProgressRegexp.FindStringSubmatch(scanner.Text())
+		if srch == nil {
+			continue
+		}
+		done, err := strconv.ParseInt(strings.ReplaceAll(srch[1], ",", ""), 10, 64)
+		if err != nil {
+			continue
+		}
+		total := result.Source.Size
+		if pct, err := strconv.Atoi(srch[2]); err == nil && pct > 0 {
+			total = done * 100 / int64(pct)
+		}
+		result.CopiedBytes = done
+		job.SetProgress(done, total, "copying data (rsync)")
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("rsync failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// sameFile tests whether dst is (most likely) a copy of src
+func sameFile(src fs.FileInfo, dstPath string) bool {
+	dst, err := os.Lstat(dstPath)
+	if err != nil {
+		return false
+	}
+	return dst.Mode() == src.Mode() && dst.Size() == src.Size() &&
+		dst.ModTime().Equal(src.ModTime())
+}
+
+func copyFile(ctx context.Context, src string, srcInfo fs.FileInfo, dst string, onWrite func(n int64)) error {
+	fr, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer fr.Close()
+	fw, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return err
+	}
+	tmpPath := fw.Name()
+	defer os.Remove(tmpPath)
+	buff := make([]byte, 1024*1024)
+	for {
+		if ctx.Err() != nil {
+			fw.Close()
+			return ctx.Err()
+		}
+		n, err := fr.Read(buff)
+		if n > 0 {
+			if _, err := fw.Write(buff[:n]); err != nil {
+				fw.Close()
+				return err
+			}
+			onWrite(int64(n))
+		}
+		if err == io.EOF {
+			break
+
+		} else if err != nil {
+			fw.Close()
+			return err
+		}
+	}
+	if err := fw.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, srcInfo.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := os.Chtimes(tmpPath, srcInfo.ModTime(), srcInfo.ModTime()); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, dst)
+}
+
+// copyNative mirrors the source directory to the target one
+// (i.e. it copies new/changed files and removes files not
+// present in the source directory)
+func (ds *DataSync) copyNative(ctx context.Context, job *jobs.Job, result *DataSyncResult) error {
+	src, dst := result.Source.Path, result.Target.Path
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return fmt.Errorf("failed to create target directory: %w", err)
+	}
+	type fileToCopy struct {
+		relPath string
+		info    fs.FileInfo
+	}
+	toCopy := make([]fileToCopy, 0, 100)
+	srcEntries := make(map[string]bool)
+	var total int64
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		srcEntries[relPath] = true
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && !sameFile(info, filepath.Join(dst, relPath)) {
+			toCopy = append(toCopy, fileToCopy{relPath: relPath, info: info})
+			if info.Mode().IsRegular() {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read source directory: %w", err)
+	}
+
+	var done int64
+	for _, item := range toCopy {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		srcPath := filepath.Join(src, item.relPath)
+		dstPath := filepath.Join(dst, item.relPath)
+		if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+			return err
+		}
+		switch {
+		case item.info.Mode().IsRegular():
+			err = copyFile(ctx, srcPath, item.info, dstPath, func(n int64) {
+				done += n
+				job.SetProgress(done, total, fmt.Sprintf("copying %s", item.relPath))
+			})
+		case item.info.Mode()&fs.ModeSymlink != 0:
+			var target string
+			target, err = os.Readlink(srcPath)
+			if err == nil {
+				os.RemoveAll(dstPath)
+				err = os.Symlink(target, dstPath)
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to copy %s: %w", item.relPath, err)
+		}
+		result.CopiedFiles++
+	}
+	result.CopiedBytes = done
+
+	toDelete := make([]string, 0, 10)
+	err = filepath.WalkDir(dst, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dst, p)
+		if err != nil {
+			return err
+		}
+		if !srcEntries[relPath] {
+			toDelete = append(toDelete, p)
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read target directory: %w", err)
+	}
+	for _, p := range toDelete {
+		job.SetProgress(done, total, fmt.Sprintf("removing %s", p))
+		if err := os.RemoveAll(p); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", p, err)
+		}
+		result.DeletedFiles++
+	}
+	job.SetProgress(done, total, "done")
+	return nil
+}
+
+// counterpartPath finds a data directory corresponding to dataPath
+// within the other storage location
+func counterpartPath(dataPath, root, otherRoot string) (string, bool) {
+	relPath, err := filepath.Rel(root, dataPath)
+	if err != nil || relPath == "." || strings.HasPrefix(relPath, "..") {
+		return "", false
+	}
+	return filepath.Join(otherRoot, relPath), true
+}
+
+// NewDataSync prepares data synchronization for a corpus. The data
+// path is obtained from the corpus registry (the PATH property) and it
+// must be located within either DistribFSDataDir or LocalDataDir.
+func NewDataSync(corpusID string, direction DataSyncDirection, setup *CorporaSetup) (*DataSync, error) {
+	if err := direction.Validate(); err != nil {
+		return nil, err
+	}
+	conf := setup.DataSync
+	if conf.DistribFSDataDir == "" || conf.LocalDataDir == "" {
+		return nil, ErrDataSyncNotConfigured
+	}
+	corp, err := OpenCorpus(corpusID, setup)
+	if err != nil {
+		return nil, err
+	}
+	defer mango.CloseCorpus(corp)
+	corpDataPath, err := mango.GetCorpusConf(corp, "PATH")
+	if err != nil {
+		return nil, CorpusError{err}
+	}
+	dataPath := filepath.Clean(corpDataPath)
+	distribFSRoot := filepath.Clean(conf.DistribFSDataDir)
+	localRoot := filepath.Clean(conf.LocalDataDir)
+	if other, ok := counterpartPath(dataPath, distribFSRoot, localRoot); ok {
+		return &DataSync{conf: conf, distribFS: dataPath, local: other, direction: direction}, nil
+	}
+	if other, ok := counterpartPath(dataPath, localRoot, distribFSRoot); ok {
+		return &DataSync{conf: conf, distribFS: other, local: dataPath, direction: direction}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrDataPathNotSyncable, dataPath)
+}
@@ -0,0 +1,173 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"masm/v3/jobs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFile(t *testing.T, path, content string, mtime time.Time) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	assert.NoError(t, os.Chtimes(path, mtime, mtime))
+}
+
+func TestCounterpartPath(t *testing.T) {
+	p, ok := counterpartPath("/cnc/data/syn2020", "/cnc/data", "/ssd/data")
+	assert.True(t, ok)
+	assert.Equal(t, "/ssd/data/syn2020", p)
+	_, ok = counterpartPath("/cnc/data", "/cnc/data", "/ssd/data")
+	assert.False(t, ok)
+	_, ok = counterpartPath("/cnc/data2/syn2020", "/cnc/data", "/ssd/data")
+	assert.False(t, ok)
+}
+
+func TestDataSyncNative(t *testing.T) {
+	root := t.TempDir()
+	distrib := filepath.Join(root, "distrib", "susanne")
+	local := filepath.Join(root, "local", "susanne")
+	old := time.Now().Add(-48 * time.Hour)
+	writeTestFile(t, filepath.Join(local, "word.lex"), "old", old)
+	writeTestFile(t, filepath.Join(local, "obsolete.rev"), "x", old)
+	writeTestFile(t, filepath.Join(distrib, "word.lex"), "new data", time.Now())
+	writeTestFile(t, filepath.Join(distrib, "sub", "doc.rng"), "rng", old)
+	assert.NoError(t, os.Chtimes(local, old, old))
+
+	ds := &DataSync{distribFS: distrib, local: local}
+	manager := jobs.NewManager(context.Background())
+	info, err := manager.Start(DataSyncJobType, "susanne", ds.Run)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		info, _ = manager.Get(info.ID)
+		return info.Finished
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, info.OK, info.Error)
+
+	result := info.Result.(*DataSyncResult)
+	assert.Equal(t, DataSyncMethodNative, result.Method)
+	assert.Equal(t, distrib, result.Source.Value)
+	assert.Equal(t, 2, result.CopiedFiles)
+	assert.Equal(t, 1, result.DeletedFiles)
+	data, err := os.ReadFile(filepath.Join(local, "word.lex"))
+	assert.NoError(t, err)
+	assert.Equal(t, "new data", string(data))
+	assert.FileExists(t, filepath.Join(local, "sub", "doc.rng"))
+	assert.NoFileExists(t, filepath.Join(local, "obsolete.rev"))
+
+	ans, err := ds.Run(context.Background(), &jobs.Job{})
+	assert.NoError(t, err)
+	assert.True(t, ans.(*DataSyncResult).UpToDate)
+}
+
+func TestDataDirStateIgnoresDirMtimes(t *testing.T) {
+	root := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	writeTestFile(t, filepath.Join(root, "word.lex"), "data", old)
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "sub"), 0755))
+	state, err := dataDirState(root)
+	assert.NoError(t, err)
+	assert.True(t, state.FileExists)
+	assert.Equal(t, old.Format(fileMtimeFormat), *state.LastModified)
+	assert.Equal(t, int64(4), state.Size)
+
+	state, err = dataDirState(filepath.Join(root, "sub"))
+	assert.NoError(t, err)
+	assert.True(t, state.FileExists)
+	assert.Nil(t, state.LastModified)
+}
+
+func TestDataSyncEmptyTarget(t *testing.T) {
+	root := t.TempDir()
+	distrib := filepath.Join(root, "distrib", "susanne")
+	local := filepath.Join(root, "local", "susanne")
+	writeTestFile(t, filepath.Join(distrib, "word.lex"), "data", time.Now().Add(-48*time.Hour))
+	// a freshly created empty directory must not be considered newer
+	assert.NoError(t, os.MkdirAll(local, 0755))
+
+	ds := &DataSync{distribFS: distrib, local: local}
+	ans, err := ds.Run(context.Background(), &jobs.Job{})
+	assert.NoError(t, err)
+	result := ans.(*DataSyncResult)
+	assert.Equal(t, distrib, result.Source.Path)
+	assert.Equal(t, 1, result.CopiedFiles)
+	assert.FileExists(t, filepath.Join(distrib, "word.lex"))
+	assert.FileExists(t, filepath.Join(local, "word.lex"))
+}
+
+func TestDataSyncRefusesEmptySource(t *testing.T) {
+	root := t.TempDir()
+	distrib := filepath.Join(root, "distrib", "susanne")
+	local := filepath.Join(root, "local", "susanne")
+	writeTestFile(t, filepath.Join(distrib, "word.lex"), "data", time.Now())
+	writeTestFile(t, filepath.Join(local, "empty.lex"), "", time.Now())
+
+	ds := &DataSync{distribFS: distrib, local: local, direction: DataSyncDirectionToDistribFS}
+	_, err := ds.Run(context.Background(), &jobs.Job{})
+	assert.ErrorIs(t, err, ErrDataSyncEmptySource)
+	assert.FileExists(t, filepath.Join(distrib, "word.lex"))
+
+	assert.NoError(t, os.Remove(filepath.Join(local, "empty.lex")))
+	_, err = ds.Run(context.Background(), &jobs.Job{})
+	assert.ErrorIs(t, err, ErrDataSyncEmptySource)
+	assert.FileExists(t, filepath.Join(distrib, "word.lex"))
+}
+
+func TestDataSyncDeletedFile(t *testing.T) {
+	root := t.TempDir()
+	distrib := filepath.Join(root, "distrib", "susanne")
+	local := filepath.Join(root, "local", "susanne")
+	old := time.Now().Add(-48 * time.Hour)
+	for _, dir := range []string{distrib, local} {
+		writeTestFile(t, filepath.Join(dir, "word.lex"), "data", old)
+		writeTestFile(t, filepath.Join(dir, "word.rev"), "rev", old)
+	}
+	// removing a file changes only the directory mtime
+	assert.NoError(t, os.Remove(filepath.Join(distrib, "word.rev")))
+
+	ds := &DataSync{distribFS: distrib, local: local}
+	ans, err := ds.Run(context.Background(), &jobs.Job{})
+	assert.NoError(t, err)
+	assert.True(t, ans.(*DataSyncResult).UpToDate)
+	assert.FileExists(t, filepath.Join(local, "word.rev"))
+
+	ds.direction = DataSyncDirectionToLocal
+	ans, err = ds.Run(context.Background(), &jobs.Job{})
+	assert.NoError(t, err)
+	result := ans.(*DataSyncResult)
+	assert.Equal(t, string(DataSyncDirectionToLocal), result.Direction)
+	assert.Equal(t, 0, result.CopiedFiles)
+	assert.Equal(t, 1, result.DeletedFiles)
+	assert.NoFileExists(t, filepath.Join(local, "word.rev"))
+	assert.FileExists(t, filepath.Join(local, "word.lex"))
+}
+
+func TestDataSyncDirectionValidate(t *testing.T) {
+	assert.NoError(t, DataSyncDirectionAuto.Validate())
+	assert.NoError(t, DataSyncDirectionToLocal.Validate())
+	assert.NoError(t, DataSyncDirectionToDistribFS.Validate())
+	assert.ErrorIs(t, DataSyncDirection("sideways").Validate(), ErrInvalidArgs)
+}
@@ -0,0 +1,260 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"masm/v3/mango"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+)
+
+// ListedCorpus is a corpus registry found in one of
+// the configured registry directories
+type ListedCorpus struct {
+	ID            string        `json:"id"`
+	Variant       CorpusVariant `json:"variant"`
+	RegistryPath  string        `json:"registryPath"`
+	RegistryMtime string        `json:"registryMtime"`
+	Opens         bool          `json:"opens"`
+	ManateeError  *string       `json:"manateeError,omitempty"`
+	Size          int64         `json:"size"`
+
+	mtime time.Time
+}
+
+// CorporaList is a page of listed corpora
+type CorporaList struct {
+	Total  int            `json:"total"`
+	Offset int            `json:"offset"`
+	Limit  int            `json:"limit"`
+	Items  []ListedCorpus `json:"items"`
+}
+
+// ListArgs specifies filtering and paging of listed corpora.
+// Empty Variant means all the variants, zero Limit means
+// no limit.
+type ListArgs struct {
+	Variant CorpusVariant
+	Offset  int
+	Limit   int
+}
+
+// CorporaLister finds registries in all the registry roots and
+// their variant subdirectories. Manatee-related information
+// (whether the corpus opens, its size) is cached for each
+// registry file until its modification time changes.
+type CorporaLister struct {
+	conf  *CorporaSetup
+	mu    sync.Mutex
+	cache map[string]ListedCorpus
+
+	// inspectFn opens a corpus by its registry path and returns
+	// whether it opens and its size
+	inspectFn func(regPath string) (bool, int64, error)
+}
+
+// variants returns all the known corpus variants including
+// the ones defined via AltAccessMapping
+func (cl *CorporaLister) variants() []CorpusVariant {
+	ans := []CorpusVariant{CorpusVariantPrimary, CorpusVariantLimited}
+	alt := make([]string, 0, len(cl.conf.AltAccessMapping))
+	for subdir := range cl.conf.AltAccessMapping {
+		if subdir != CorpusVariantLimited.SubDir() && subdir != "" {
+			alt = append(alt, subdir)
+		}
+	}
+	sort.Strings(alt)
+	for _, v := range alt {
+		ans = append(ans, CorpusVariant(v))
+	}
+	return ans
+}
+
+// listRegistryDir adds registries found in dir to ans. Registries
+// in sub-directories (one level deep) are added with IDs in the form
+// [sub dir.]/[corpus ID] unless the sub-directory is a variant one.
+func (cl *CorporaLister) listRegistryDir(
+	dir, subDir string,
+	variant CorpusVariant,
+	found map[string]bool,
+	ans *[]ListedCorpus,
+) error {
+	isDir, err := fs.IsDir(dir)
+	if err != nil {
+		return err
+	}
+	if !isDir {
+		return nil
+	}
+	files, err := fs.ListFilesInDir(dir, false)
+	if err != nil {
+		return err
+	}
+	isVariantDir := make(map[string]bool)
+	for _, v := range cl.variants() {
+		isVariantDir[v.SubDir()] = true
+	}
+	subDirs := make([]string, 0, 10)
+	files.ForEach(func(info os.FileInfo, idx int) bool {
+		if strings.HasPrefix(info.Name(), ".") {
+			return true
+		}
+		if info.IsDir() {
+			if subDir == "" && !(variant == CorpusVariantPrimary && isVariantDir[info.Name()]) {
+				subDirs = append(subDirs, info.Name())
+			}
+			return true
+		}
+		corpusID := info.Name()
+		if subDir != "" {
+			corpusID = subDir + "/" + corpusID
+		}
+		if ValidateCorpusID(corpusID) != nil {
+			return true
+		}
+		key := string(variant) + "/" + corpusID
+		if found[key] {
+			return true
+		}
+		found[key] = true
+		*ans = append(*ans, ListedCorpus{
+			ID:            corpusID,
+			Variant:       variant,
+			RegistryPath:  filepath.Join(dir, info.Name()),
+			RegistryMtime: info.ModTime().Format("2006-01-02T15:04:05-0700"),
+			mtime:         info.ModTime(),
+		})
+		return true
+	})
+	for _, sd := range subDirs {
+		if err := cl.listRegistryDir(filepath.Join(dir, sd), sd, variant, found, ans); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findRegistries walks through registry directories. In case a registry
+// of the same ID and variant is present in more roots, the first one
+// wins (the same way as in CorporaSetup.GetFirstValidRegistry).
+func (cl *CorporaLister) findRegistries(variant CorpusVariant) ([]ListedCorpus, error) {
+	ans := make([]ListedCorpus, 0, 100)
+	found := make(map[string]bool)
+	for _, v := range cl.variants() {
+		if variant != "" && variant != v {
+			continue
+		}
+		for _, root := range cl.conf.RegistryDirPaths {
+			err := cl.listRegistryDir(filepath.Join(root, v.SubDir()), "", v, found, &ans)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.SliceStable(ans, func(i, j int) bool {
+		if ans[i].ID != ans[j].ID {
+			return ans[i].ID < ans[j].ID
+		}
+		return ans[i].Variant < ans[j].Variant
+	})
+	return ans, nil
+}
+
+// resolve attaches Manatee information to the item (either
+// from cache or by opening the corpus)
+func (cl *CorporaLister) resolve(item ListedCorpus) ListedCorpus {
+	cl.mu.Lock()
+	cached, ok := cl.cache[item.RegistryPath]
+	cl.mu.Unlock()
+	if ok && cached.mtime.Equal(item.mtime) {
+		return cached
+	}
+	var err error
+	item.Opens, item.Size, err = cl.inspectFn(item.RegistryPath)
+	if err != nil {
+		errStr := err.Error()
+		item.ManateeError = &errStr
+	}
+	cl.mu.Lock()
+	cl.cache[item.RegistryPath] = item
+	cl.mu.Unlock()
+	return item
+}
+
+// List returns a page of corpora matching provided arguments.
+// Only corpora on the page are opened by Manatee (if not cached).
+func (cl *CorporaLister) List(args ListArgs) (*CorporaList, error) {
+	items, err := cl.findRegistries(args.Variant)
+	if err != nil {
+		return nil, err
+	}
+	cl.mu.Lock()
+	current := make(map[string]bool)
+	for _, item := range items {
+		current[item.RegistryPath] = true
+	}
+	for k, v := range cl.cache {
+		if !current[k] && (args.Variant == "" || args.Variant == v.Variant) {
+			delete(cl.cache, k)
+		}
+	}
+	cl.mu.Unlock()
+
+	ans := &CorporaList{
+		Total:  len(items),
+		Offset: args.Offset,
+		Limit:  args.Limit,
+		Items:  []ListedCorpus{},
+	}
+	if args.Offset >= len(items) {
+		return ans, nil
+	}
+	end := len(items)
+	if args.Limit > 0 && args.Offset+args.Limit < end {
+		end = args.Offset + args.Limit
+	}
+	for _, item := range items[args.Offset:end] {
+		ans.Items = append(ans.Items, cl.resolve(item))
+	}
+	return ans, nil
+}
+
+func inspectCorpus(regPath string) (bool, int64, error) {
+	corp, err := mango.OpenCorpus(regPath)
+	if err != nil {
+		return false, 0, err
+	}
+	defer mango.CloseCorpus(corp)
+	size, err := mango.GetCorpusSize(corp)
+	return true, size, err
+}
+
+func NewCorporaLister(conf *CorporaSetup) *CorporaLister {
+	return &CorporaLister{
+		conf:      conf,
+		cache:     make(map[string]ListedCorpus),
+		inspectFn: inspectCorpus,
+	}
+}
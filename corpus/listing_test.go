@@ -0,0 +1,181 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createRegistryFiles(t *testing.T, root string, paths ...string) {
+	for _, p := range paths {
+		fullPath := filepath.Join(root, p)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+		assert.NoError(t, os.WriteFile(fullPath, []byte("PATH /tmp\n"), 0644))
+	}
+}
+
+func newTestLister(t *testing.T) (*CorporaLister, []string, *int) {
+	roots := []string{t.TempDir(), t.TempDir()}
+	createRegistryFiles(
+		t, roots[0],
+		"syn2020", "susanne", ".hidden",
+		"omezeni/syn2020", "omezeni/sub/lim",
+		"sub/corp", "sub/deeper/ignored",
+		"alt/syn2020",
+	)
+	createRegistryFiles(t, roots[1], "syn2020", "other", "sub/corp", "omezeni/syn2020")
+	var numInspected int
+	lister := NewCorporaLister(&CorporaSetup{
+		RegistryDirPaths: roots,
+		AltAccessMapping: map[string]string{"alt": "/data/alt"},
+	})
+	lister.inspectFn = func(regPath string) (bool, int64, error) {
+		numInspected++
+		if filepath.Base(regPath) == "susanne" {
+			return false, 0, errors.New("failed to open")
+		}
+		return true, 1000, nil
+	}
+	return lister, roots, &numInspected
+}
+
+type listedKey struct {
+	id      string
+	variant CorpusVariant
+}
+
+func listedKeys(items []ListedCorpus) []listedKey {
+	ans := make([]listedKey, len(items))
+	for i, item := range items {
+		ans[i] = listedKey{item.ID, item.Variant}
+	}
+	return ans
+}
+
+func TestCorporaListerFindRegistries(t *testing.T) {
+	lister, roots, _ := newTestLister(t)
+	items, err := lister.findRegistries("")
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]listedKey{
+			{"other", CorpusVariantPrimary},
+			{"sub/corp", CorpusVariantPrimary},
+			{"sub/lim", CorpusVariantLimited},
+			{"susanne", CorpusVariantPrimary},
+			{"syn2020", CorpusVariant("alt")},
+			{"syn2020", CorpusVariantLimited},
+			{"syn2020", CorpusVariantPrimary},
+		},
+		listedKeys(items),
+	)
+	// the first registry root wins
+	for _, item := range items {
+		if item.ID == "syn2020" || item.ID == "sub/corp" {
+			assert.Equal(t, roots[0], item.RegistryPath[:len(roots[0])])
+		}
+	}
+	assert.Equal(t, filepath.Join(roots[0], "sub", "corp"), items[1].RegistryPath)
+	assert.Equal(t, filepath.Join(roots[0], "omezeni", "sub", "lim"), items[2].RegistryPath)
+}
+
+func TestCorporaListerFindRegistriesVariant(t *testing.T) {
+	lister, roots, _ := newTestLister(t)
+	items, err := lister.findRegistries(CorpusVariantLimited)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]listedKey{{"sub/lim", CorpusVariantLimited}, {"syn2020", CorpusVariantLimited}},
+		listedKeys(items),
+	)
+	assert.Equal(t, filepath.Join(roots[0], "omezeni", "syn2020"), items[1].RegistryPath)
+
+	items, err = lister.findRegistries(CorpusVariant("alt"))
+	assert.NoError(t, err)
+	assert.Equal(t, []listedKey{{"syn2020", CorpusVariant("alt")}}, listedKeys(items))
+}
+
+func TestCorporaListerFindRegistriesMissingRoot(t *testing.T) {
+	lister := NewCorporaLister(&CorporaSetup{
+		RegistryDirPaths: []string{filepath.Join(t.TempDir(), "missing")},
+	})
+	items, err := lister.findRegistries("")
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestCorporaListerListPaging(t *testing.T) {
+	lister, _, numInspected := newTestLister(t)
+	ans, err := lister.List(ListArgs{Variant: CorpusVariantPrimary, Offset: 1, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, ans.Total)
+	assert.Equal(
+		t,
+		[]listedKey{{"sub/corp", CorpusVariantPrimary}, {"susanne", CorpusVariantPrimary}},
+		listedKeys(ans.Items),
+	)
+	// only corpora on the page are inspected
+	assert.Equal(t, 2, *numInspected)
+	assert.True(t, ans.Items[0].Opens)
+	assert.Equal(t, int64(1000), ans.Items[0].Size)
+	assert.Nil(t, ans.Items[0].ManateeError)
+	assert.False(t, ans.Items[1].Opens)
+	assert.Equal(t, "failed to open", *ans.Items[1].ManateeError)
+
+	ans, err = lister.List(ListArgs{Variant: CorpusVariantPrimary, Offset: 10, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, ans.Total)
+	assert.Empty(t, ans.Items)
+
+	ans, err = lister.List(ListArgs{})
+	assert.NoError(t, err)
+	assert.Len(t, ans.Items, 7)
+}
+
+func TestCorporaListerCachesInspection(t *testing.T) {
+	lister, roots, numInspected := newTestLister(t)
+	_, err := lister.List(ListArgs{Variant: CorpusVariantPrimary})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, *numInspected)
+	_, err = lister.List(ListArgs{Variant: CorpusVariantPrimary})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, *numInspected)
+
+	// a modified registry is inspected again
+	regPath := filepath.Join(roots[0], "syn2020")
+	newMtime := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(regPath, newMtime, newMtime))
+	_, err = lister.List(ListArgs{Variant: CorpusVariantPrimary})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, *numInspected)
+
+	// removed registries are removed from the cache
+	assert.NoError(t, os.Remove(regPath))
+	_, err = lister.List(ListArgs{Variant: CorpusVariantPrimary})
+	assert.NoError(t, err)
+	assert.NotContains(t, lister.cache, regPath)
+	// ... and the registry from the other root is used instead
+	assert.Contains(t, lister.cache, filepath.Join(roots[1], "syn2020"))
+}
@@ -0,0 +1,359 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"container/list"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"masm/v3/mango"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DfltCorpusPoolSize = 50
+)
+
+// pooledCorpus is an opened corpus shared by pool users
+type pooledCorpus struct {
+	regPath string
+	corpus  *mango.GoCorpus
+	err     error
+
+	// ready is closed once the corpus is opened (or failed to open)
+	ready chan struct{}
+
+	refs      int
+	detached  bool
+	openedAt  time.Time
+	lastUsed  time.Time
+	regMtime  time.Time
+	dataPath  string
+	dataMtime time.Time
+	elem      *list.Element
+}
+
+// CorpusPoolItemStats describes a single pooled corpus
+type CorpusPoolItemStats struct {
+	RegistryPath string    `json:"registryPath"`
+	DataPath     string    `json:"dataPath"`
+	Refs         int       `json:"refs"`
+	OpenedAt     time.Time `json:"openedAt"`
+	LastUsed     time.Time `json:"lastUsed"`
+}
+
+// CorpusPoolStats provides information about pool usage
+type CorpusPoolStats struct {
+	Capacity      int                   `json:"capacity"`
+	Size          int                   `json:"size"`
+	InUse         int                   `json:"inUse"`
+	Hits          int64                 `json:"hits"`
+	Misses        int64                 `json:"misses"`
+	Evictions     int64                 `json:"evictions"`
+	Invalidations int64                 `json:"invalidations"`
+	Items         []CorpusPoolItemStats `json:"items"`
+}
+
+// CorpusHandle provides access to a pooled corpus. Each handle
+// must be released once the corpus (and all the objects derived
+// from it, e.g. concordances) is no longer needed.
+type CorpusHandle struct {
+	pool  *CorpusPool
+	entry *pooledCorpus
+	once  sync.Once
+}
+
+func (h *CorpusHandle) Corpus() *mango.GoCorpus {
+	return h.entry.corpus
+}
+
+// DataMtime returns modification time of the corpus data directory
+// at the time the corpus was opened (zero if unknown)
+func (h *CorpusHandle) DataMtime() time.Time {
+	return h.entry.dataMtime
+}
+
+// Retain creates a new handle for the same corpus. This is useful
+// in case the corpus is needed by a goroutine which may outlive
+// the original handle user.
+func (h *CorpusHandle) Retain() *CorpusHandle {
+	h.pool.mu.Lock()
+	h.entry.refs++
+	h.pool.mu.Unlock()
+	return &CorpusHandle{pool: h.pool, entry: h.entry}
+}
+
+// Release returns the corpus to the pool. Calling the
+// method more than once has no effect.
+func (h *CorpusHandle) Release() {
+	h.once.Do(func() {
+		h.pool.release(h.entry)
+	})
+}
+
+// CorpusPool is a reference-counted, LRU-bounded pool of opened
+// corpora keyed by registry paths. Unused corpora exceeding pool
+// capacity are closed. Corpora with changed registry file or data
+// directory are reopened.
+type CorpusPool struct {
+	mu            sync.Mutex
+	setup         *CorporaSetup
+	capacity      int
+	items         map[string]*pooledCorpus
+	lru           *list.List
+	hits          int64
+	misses        int64
+	evictions     int64
+	invalidations int64
+
+	openFn     func(regPath string) (*mango.GoCorpus, error)
+	closeFn    func(corpus *mango.GoCorpus)
+	dataPathFn func(corpus *mango.GoCorpus) (string, error)
+}
+
+func fileMtime(path string) (time.Time, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return st.ModTime(), nil
+}
+
+// Acquire obtains a corpus specified either by [corpus ID]
+// or by [sub dir.]/[corpus ID] from the pool
+func (p *CorpusPool) Acquire(corpusID string) (*CorpusHandle, error) {
+	regPath, err := findRegistryPath(corpusID, p.setup)
+	if err != nil {
+		return nil, err
+	}
+	return p.AcquireByPath(regPath)
+}
+
+// AcquireByPath obtains a corpus specified by its registry path
+// from the pool. If the corpus is not opened yet, it is opened.
+func (p *CorpusPool) AcquireByPath(regPath string) (*CorpusHandle, error) {
+	for {
+		regMtime, err := fileMtime(regPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, CorpusNotFound
+
+		} else if err != nil {
+			return nil, InfoError{err}
+		}
+		p.mu.Lock()
+		entry, ok := p.items[regPath]
+		if !ok {
+			entry = &pooledCorpus{
+				regPath: regPath,
+				ready:   make(chan struct{}),
+				refs:    1,
+			}
+			entry.elem = p.lru.PushFront(entry)
+			p.items[regPath] = entry
+			p.misses++
+			p.mu.Unlock()
+			p.open(entry, regMtime)
+			if entry.err != nil {
+				p.mu.Lock()
+				p.detach(entry)
+				p.mu.Unlock()
+				p.release(entry)
+				return nil, entry.err
+			}
+			p.mu.Lock()
+			p.evict()
+			p.mu.Unlock()
+			return &CorpusHandle{pool: p, entry: entry}, nil
+		}
+		entry.refs++
+		p.mu.Unlock()
+
+		<-entry.ready
+		if entry.err != nil {
+			p.release(entry)
+			return nil, entry.err
+		}
+		if p.isModified(entry, regMtime) {
+			p.mu.Lock()
+			if !entry.detached {
+				p.detach(entry)
+				p.invalidations++
+				log.Info().Str("registry", regPath).Msg("corpus files changed, reopening pooled corpus")
+			}
+			p.mu.Unlock()
+			p.release(entry)
+			continue
+		}
+		p.mu.Lock()
+		p.hits++
+		entry.lastUsed = time.Now()
+		if !entry.detached {
+			p.lru.MoveToFront(entry.elem)
+		}
+		p.mu.Unlock()
+		return &CorpusHandle{pool: p, entry: entry}, nil
+	}
+}
+
+// open opens a corpus of a new entry and closes entry's ready channel
+func (p *CorpusPool) open(entry *pooledCorpus, regMtime time.Time) {
+	defer close(entry.ready)
+	corp, err := p.openFn(entry.regPath)
+	if errors.Is(err, mango.ErrCorpusNotFound) {
+		entry.err = CorpusNotFound
+		return
+
+	} else if err != nil {
+		entry.err = CorpusError{err}
+		return
+	}
+	var dataMtime time.Time
+	dataPath, err := p.dataPathFn(corp)
+	if err == nil {
+		dataPath = filepath.Clean(dataPath)
+		dataMtime, _ = fileMtime(dataPath)
+
+	} else {
+		dataPath = ""
+	}
+	p.mu.Lock()
+	entry.corpus = corp
+	entry.regMtime = regMtime
+	entry.dataPath = dataPath
+	entry.dataMtime = dataMtime
+	entry.openedAt = time.Now()
+	entry.lastUsed = entry.openedAt
+	p.mu.Unlock()
+}
+
+// isModified tests whether registry file or data directory
+// of an entry has changed since the corpus was opened
+func (p *CorpusPool) isModified(entry *pooledCorpus, regMtime time.Time) bool {
+	if !regMtime.Equal(entry.regMtime) {
+		return true
+	}
+	if entry.dataPath == "" {
+		return false
+	}
+	dataMtime, _ := fileMtime(entry.dataPath)
+	return !dataMtime.Equal(entry.dataMtime)
+}
+
+// detach removes an entry from the pool so it cannot be acquired
+// anymore. The corpus is closed once all its users release it.
+// The method expects the pool to be locked.
+func (p *CorpusPool) detach(entry *pooledCorpus) {
+	entry.detached = true
+	if p.items[entry.regPath] == entry {
+		delete(p.items, entry.regPath)
+	}
+	p.lru.Remove(entry.elem)
+}
+
+// evict detaches least recently used unused entries exceeding pool
+// capacity. In case all the entries are in use, the pool may temporarily
+// exceed its capacity. The method expects the pool to be locked.
+func (p *CorpusPool) evict() {
+	for elem := p.lru.Back(); elem != nil && len(p.items) > p.capacity; {
+		entry := elem.Value.(*pooledCorpus)
+		elem = elem.Prev()
+		if entry.refs > 0 {
+			continue
+		}
+		p.detach(entry)
+		p.evictions++
+		p.closeFn(entry.corpus)
+	}
+}
+
+func (p *CorpusPool) release(entry *pooledCorpus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry.refs--
+	if entry.refs > 0 {
+		return
+	}
+	if entry.detached {
+		if entry.corpus != nil {
+			p.closeFn(entry.corpus)
+		}
+		return
+	}
+	p.evict()
+}
+
+// Stats provides information about the pool
+func (p *CorpusPool) Stats() CorpusPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ans := CorpusPoolStats{
+		Capacity:      p.capacity,
+		Size:          len(p.items),
+		Hits:          p.hits,
+		Misses:        p.misses,
+		Evictions:     p.evictions,
+		Invalidations: p.invalidations,
+		Items:         make([]CorpusPoolItemStats, 0, len(p.items)),
+	}
+	for _, entry := range p.items {
+		if entry.refs > 0 {
+			ans.InUse++
+		}
+		ans.Items = append(ans.Items, CorpusPoolItemStats{
+			RegistryPath: entry.regPath,
+			DataPath:     entry.dataPath,
+			Refs:         entry.refs,
+			OpenedAt:     entry.openedAt,
+			LastUsed:     entry.lastUsed,
+		})
+	}
+	sort.Slice(ans.Items, func(i, j int) bool {
+		return ans.Items[i].RegistryPath < ans.Items[j].RegistryPath
+	})
+	return ans
+}
+
+// NewCorpusPool creates a new pool with capacity
+// configured in setup (or DfltCorpusPoolSize)
+func NewCorpusPool(setup *CorporaSetup) *CorpusPool {
+	capacity := setup.CorpusPoolSize
+	if capacity <= 0 {
+		capacity = DfltCorpusPoolSize
+	}
+	return &CorpusPool{
+		setup:    setup,
+		capacity: capacity,
+		items:    make(map[string]*pooledCorpus),
+		lru:      list.New(),
+		openFn:   mango.OpenCorpus,
+		closeFn: func(corpus *mango.GoCorpus) {
+			mango.CloseCorpus(corpus)
+		},
+		dataPathFn: func(corpus *mango.GoCorpus) (string, error) {
+			return mango.GetCorpusConf(corpus, "PATH")
+		},
+	}
+}
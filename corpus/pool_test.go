@@ -0,0 +1,150 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"container/list"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"masm/v3/mango"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPoolCounters struct {
+	opened atomic.Int32
+	closed atomic.Int32
+}
+
+func newTestPool(t *testing.T, capacity int) (*CorpusPool, *testPoolCounters, string) {
+	dir := t.TempDir()
+	counters := &testPoolCounters{}
+	pool := &CorpusPool{
+		setup:    &CorporaSetup{RegistryDirPaths: []string{dir}},
+		capacity: capacity,
+		items:    make(map[string]*pooledCorpus),
+		lru:      list.New(),
+		openFn: func(regPath string) (*mango.GoCorpus, error) {
+			time.Sleep(5 * time.Millisecond)
+			counters.opened.Add(1)
+			return &mango.GoCorpus{}, nil
+		},
+		closeFn: func(corpus *mango.GoCorpus) {
+			counters.closed.Add(1)
+		},
+		dataPathFn: func(corpus *mango.GoCorpus) (string, error) {
+			return filepath.Join(dir, "data"), nil
+		},
+	}
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "data"), 0755))
+	for _, c := range []string{"corp1", "corp2", "corp3"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, c), []byte("PATH data"), 0644))
+	}
+	return pool, counters, dir
+}
+
+func TestCorpusPoolReuseAndEviction(t *testing.T) {
+	pool, counters, _ := newTestPool(t, 2)
+	h1, err := pool.Acquire("corp1")
+	assert.NoError(t, err)
+	h1b, err := pool.Acquire("corp1")
+	assert.NoError(t, err)
+	assert.Same(t, h1.entry, h1b.entry)
+	assert.Equal(t, int32(1), counters.opened.Load())
+
+	h2, err := pool.Acquire("corp2")
+	assert.NoError(t, err)
+	h2.Release()
+	h3, err := pool.Acquire("corp3")
+	assert.NoError(t, err)
+	// corp1 is in use so the least recently used unused corp2 is evicted
+	assert.Equal(t, int32(1), counters.closed.Load())
+	stats := pool.Stats()
+	assert.Equal(t, 2, stats.Size)
+	assert.Equal(t, 2, stats.InUse)
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, int64(1), stats.Hits)
+
+	h1.Release()
+	h1.Release()
+	// releasing the same handle twice has no effect
+	assert.Equal(t, 1, pool.Stats().Items[0].Refs)
+	h1b.Release()
+	h3.Release()
+	assert.Equal(t, int32(1), counters.closed.Load())
+	assert.Equal(t, 0, pool.Stats().InUse)
+
+	_, err = pool.Acquire("corp4")
+	assert.ErrorIs(t, err, CorpusNotFound)
+}
+
+func TestCorpusPoolInvalidation(t *testing.T) {
+	pool, counters, dir := newTestPool(t, 5)
+	h1, err := pool.Acquire("corp1")
+	assert.NoError(t, err)
+	future := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "corp1"), future, future))
+	h2, err := pool.Acquire("corp1")
+	assert.NoError(t, err)
+	assert.NotSame(t, h1.entry, h2.entry)
+	assert.Equal(t, int64(1), pool.Stats().Invalidations)
+	// the old corpus is still in use
+	assert.Equal(t, int32(0), counters.closed.Load())
+	h1.Release()
+	assert.Equal(t, int32(1), counters.closed.Load())
+
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "data"), future, future))
+	h3, err := pool.Acquire("corp1")
+	assert.NoError(t, err)
+	assert.NotSame(t, h2.entry, h3.entry)
+	h2.Release()
+	h3.Release()
+	assert.Equal(t, int32(3), counters.opened.Load())
+	assert.Equal(t, int32(2), counters.closed.Load())
+}
+
+func TestCorpusPoolConcurrentAcquire(t *testing.T) {
+	pool, counters, _ := newTestPool(t, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			corpusID := []string{"corp1", "corp2", "corp3"}[i%3]
+			h, err := pool.Acquire(corpusID)
+			if !assert.NoError(t, err) {
+				return
+			}
+			h2 := h.Retain()
+			h.Release()
+			time.Sleep(time.Millisecond)
+			h2.Release()
+		}(i)
+	}
+	wg.Wait()
+	stats := pool.Stats()
+	assert.Equal(t, 0, stats.InUse)
+	assert.LessOrEqual(t, stats.Size, 2)
+	assert.Equal(t, counters.opened.Load()-counters.closed.Load(), int32(stats.Size))
+}
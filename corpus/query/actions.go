@@ -19,15 +19,15 @@
 package query
 
 import (
+	"fmt"
 	"masm/v3/corpus"
+	"masm/v3/jobs"
 	"masm/v3/mango"
-	"net/http"
-	"os"
-	"path"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/czcorpus/cnc-gokit/fs"
 	"github.com/czcorpus/cnc-gokit/uniresp"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
@@ -48,156 +48,203 @@ var (
 )
 
 type Actions struct {
-	conf      *corpus.CorporaSetup
-	concCache *Cache
+	conf         *corpus.CorporaSetup
+	concProvider *ConcProvider
+	pool         *corpus.CorpusPool
+	jobs         *jobs.Manager
 }
 
+// FreqDistrib calculates frequency distribution of a concordance.
+// Supported arguments: `q` (CQL query), `fcrit` (can be repeated),
+// `flimit`, `sort` (freq, ipm, alpha), `maxItems`, `offset` and `limit`.
 func (a *Actions) FreqDistrib(ctx *gin.Context) {
 	q := ctx.Request.URL.Query().Get("q")
 	log.Debug().
 		Str("query", q).
 		Msg("processing Mango query")
-	flimit := 1
-	if ctx.Request.URL.Query().Has("flimit") {
-		var err error
-		flimit, err = strconv.Atoi(ctx.Request.URL.Query().Get("flimit"))
+	args := FreqArgs{FLimit: 1, Sort: FreqSortFreq}
+	for _, p := range []struct {
+		name   string
+		target *int
+	}{
+		{"flimit", &args.FLimit}, {"maxItems", &args.MaxItems},
+		{"offset", &args.Offset}, {"limit", &args.Limit},
+	} {
+		if !ctx.Request.URL.Query().Has(p.name) {
+			continue
+		}
+		v, err := strconv.Atoi(ctx.Query(p.name))
 		if err != nil {
-			uniresp.WriteJSONErrorResponse(
-				ctx.Writer,
-				uniresp.NewActionErrorFrom(err),
-				http.StatusUnprocessableEntity,
-			)
+			corpus.WriteErrorResponse(
+				ctx.Writer, fmt.Errorf("%w: invalid %s value", corpus.ErrInvalidArgs, p.name))
 			return
 		}
+		*p.target = v
 	}
-
-	var err error
-	var corp *mango.GoCorpus
-	corp, err = corpus.OpenCorpus(ctx.Param("corpusId"), a.conf)
-	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer,
-			uniresp.NewActionErrorFrom(err),
-			http.StatusUnprocessableEntity,
-		)
-		return
-	}
-
-	var conc *mango.GoConc
-	if a.concCache.Contains(ctx.Param("corpusId"), q) {
-		cacheEntry, err := a.concCache.Get(ctx.Param("corpusId"), q)
-		if cacheEntry.Err != nil {
-			err = cacheEntry.Err
-			uniresp.WriteJSONErrorResponse(
-				ctx.Writer,
-				uniresp.NewActionErrorFrom(cacheEntry.Err),
-				http.StatusInternalServerError,
-			)
+	if v := ctx.Query("sort"); v != "" {
+		args.Sort = v
+	}
+	if err := args.Validate(); err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	for _, v := range ctx.QueryArray("fcrit") {
+		crit, err := ParseFreqCrit(v)
+		if err != nil {
+			corpus.WriteErrorResponse(ctx.Writer, err)
 			return
-
-		} else {
-			conc, err = mango.OpenConcordance(corp, cacheEntry.FilePath)
-			if err != nil {
-				uniresp.WriteJSONErrorResponse(
-					ctx.Writer,
-					uniresp.NewActionErrorFrom(err),
-					http.StatusInternalServerError,
-				)
-				return
-			}
 		}
-
-	} else {
-		conc, err = mango.CreateConcordance(corp, q)
-		a.concCache.Promise(
-			ctx.Param("corpusId"),
-			q,
-			func(targetPath string) error {
-				targetDir := path.Dir(targetPath)
-				if !fs.PathExists(targetDir) {
-					if err := os.MkdirAll(targetDir, 0755); err != nil {
-						return err
-					}
-				}
-				return mango.SaveConcordance(conc, targetPath)
-			},
-		)
+		args.Crit = append(args.Crit, crit)
 	}
 
+	corpusID, err := corpus.CorpusIDFromRequest(ctx)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	corpHandle, err := a.pool.Acquire(corpusID)
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer,
-			uniresp.NewActionErrorFrom(err),
-			http.StatusInternalServerError, // TODO the status should be based on err type
-		)
+		corpus.WriteErrorResponse(ctx.Writer, err)
 		return
 	}
+	defer corpHandle.Release()
 
-	freqs, err := mango.CalcFreqDist(conc, "lemma/e 0~0>0", flimit)
-	ans := make([]*FreqDistribItem, len(freqs.Freqs))
-	for i, _ := range ans {
-		norm := freqs.Norms[i]
-		if norm == 0 {
-			norm = conc.CorpSize()
+	var corpConf [3][]string
+	for i, prop := range []string{"ATTRLIST", "STRUCTLIST", "STRUCTATTRLIST"} {
+		v, err := mango.GetCorpusConf(corpHandle.Corpus(), prop)
+		if err != nil {
+			corpus.WriteErrorResponse(ctx.Writer, err)
+			return
+		}
+		corpConf[i] = strings.Split(v, ",")
+	}
+	if len(args.Crit) == 0 {
+		// for backward compatibility, lemma is used by default if available
+		dfltCrit := FreqCrit{{Attr: "lemma", Flags: "e", Position: DfltFreqCritPosition}}
+		if dfltCrit.Validate(corpConf[0], nil, nil) != nil {
+			dfltCrit[0].Attr = "word"
 		}
-		ans[i] = &FreqDistribItem{
-			Freq: freqs.Freqs[i],
-			Norm: norm,
-			IPM:  float32(freqs.Freqs[i]) / float32(norm) * 1e6,
-			Word: freqs.Words[i],
+		args.Crit = append(args.Crit, dfltCrit)
+	}
+	for _, crit := range args.Crit {
+		if err := crit.Validate(corpConf[0], corpConf[1], corpConf[2]); err != nil {
+			corpus.WriteErrorResponse(ctx.Writer, err)
+			return
 		}
 	}
+
+	conc, releaseConc, err := a.concProvider.Acquire(corpHandle, corpusID, q)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	defer releaseConc()
+	blocks, err := CalcFreqs(conc, args)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
 	uniresp.WriteJSONResponse(
 		ctx.Writer,
 		map[string]any{
 			"concSize": conc.Size(),
-			"freqs":    ans,
+			"freqs":    blocks[0].Freqs,
+			"blocks":   blocks,
 		},
 	)
 }
 
+// Collocations calculates collocations of a concordance.
+// Supported arguments: `q` (CQL query), `attr`, `fn` (can be repeated
+// or comma-separated, the first one is used for sorting), `minFreq`,
+// `minBgr`, `fromW`, `toW` and `maxItems`.
 func (a *Actions) Collocations(ctx *gin.Context) {
 	q := ctx.Request.URL.Query().Get("q")
 	log.Debug().
 		Str("query", q).
 		Msg("processing Mango query")
+	args := CollArgs{
+		Attr:     "word",
+		Fns:      ParseCollFns(ctx.QueryArray("fn")),
+		MinFreq:  DfltCollMinFreq,
+		MinBgr:   DfltCollMinBgr,
+		FromW:    DfltCollFromW,
+		ToW:      DfltCollToW,
+		MaxItems: DfltCollMaxItems,
+	}
+	if len(args.Fns) == 0 {
+		args.Fns = []string{DfltCollFn}
+	}
+	if v := ctx.Query("attr"); v != "" {
+		args.Attr = v
+	}
+	for _, p := range []struct {
+		name   string
+		target *int64
+	}{{"minFreq", &args.MinFreq}, {"minBgr", &args.MinBgr}} {
+		if !ctx.Request.URL.Query().Has(p.name) {
+			continue
+		}
+		v, err := strconv.ParseInt(ctx.Query(p.name), 10, 64)
+		if err != nil {
+			corpus.WriteErrorResponse(
+				ctx.Writer, fmt.Errorf("%w: invalid %s value", corpus.ErrInvalidArgs, p.name))
+			return
+		}
+		*p.target = v
+	}
+	for _, p := range []struct {
+		name   string
+		target *int
+	}{{"fromW", &args.FromW}, {"toW", &args.ToW}, {"maxItems", &args.MaxItems}} {
+		if !ctx.Request.URL.Query().Has(p.name) {
+			continue
+		}
+		v, err := strconv.Atoi(ctx.Query(p.name))
+		if err != nil {
+			corpus.WriteErrorResponse(
+				ctx.Writer, fmt.Errorf("%w: invalid %s value", corpus.ErrInvalidArgs, p.name))
+			return
+		}
+		*p.target = v
+	}
+	if err := args.Validate(); err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
 
-	corp, err := corpus.OpenCorpus(ctx.Param("corpusId"), a.conf)
+	corpusID, err := corpus.CorpusIDFromRequest(ctx)
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer,
-			uniresp.NewActionErrorFrom(err),
-			http.StatusInternalServerError,
-		)
+		corpus.WriteErrorResponse(ctx.Writer, err)
 		return
 	}
+	corpHandle, err := a.pool.Acquire(corpusID)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	defer corpHandle.Release()
 
-	conc, err := mango.CreateConcordance(corp, q)
+	attrList, err := mango.GetCorpusConf(corpHandle.Corpus(), "ATTRLIST")
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer,
-			uniresp.NewActionErrorFrom(err),
-			http.StatusInternalServerError, // TODO the status should be based on err type
-		)
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	if !slices.Contains(strings.Split(attrList, ","), args.Attr) {
+		corpus.WriteErrorResponse(
+			ctx.Writer, fmt.Errorf("%w: attribute %s", mango.ErrAttrNotFound, args.Attr))
 		return
 	}
-	collFnArg := ctx.Request.URL.Query().Get("fn")
-	collFn, ok := collFunc[collFnArg]
-	if !ok {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer,
-			uniresp.NewActionError("unknown collocations function %s", collFnArg),
-			http.StatusUnprocessableEntity,
-		)
+
+	conc, releaseConc, err := a.concProvider.Acquire(corpHandle, corpusID, q)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
 		return
 	}
-	collocs, err := mango.GetCollcations(conc, "word", collFn, 20, 20)
+	defer releaseConc()
+	collocs, err := CalcCollocations(conc, args)
 	if err != nil {
-		uniresp.WriteJSONErrorResponse(
-			ctx.Writer,
-			uniresp.NewActionErrorFrom(err),
-			http.StatusInternalServerError, // TODO the status should be based on err type
-		)
+		corpus.WriteErrorResponse(ctx.Writer, err)
 		return
 	}
 	uniresp.WriteJSONResponse(
@@ -209,13 +256,82 @@ func (a *Actions) Collocations(ctx *gin.Context) {
 
 }
 
+// Conc provides KWIC lines of a concordance. Supported arguments:
+// `q` (CQL query), `offset`, `limit`, `attrs` and `structs`
+// (comma-separated lists) and `ctx` (number of context tokens).
+func (a *Actions) Conc(ctx *gin.Context) {
+	q := ctx.Request.URL.Query().Get("q")
+	log.Debug().
+		Str("query", q).
+		Msg("processing Mango query")
+	args := KWICArgs{
+		Limit: DfltKWICLimit,
+		Ctx:   DfltKWICCtx,
+		Attrs: []string{"word"},
+	}
+	for _, p := range []struct {
+		name   string
+		target *int
+	}{{"offset", &args.Offset}, {"limit", &args.Limit}, {"ctx", &args.Ctx}} {
+		if !ctx.Request.URL.Query().Has(p.name) {
+			continue
+		}
+		v, err := strconv.Atoi(ctx.Query(p.name))
+		if err != nil {
+			corpus.WriteErrorResponse(
+				ctx.Writer, fmt.Errorf("%w: invalid %s value", corpus.ErrInvalidArgs, p.name))
+			return
+		}
+		*p.target = v
+	}
+	if v := ctx.Query("attrs"); v != "" {
+		args.Attrs = strings.Split(v, ",")
+	}
+	if v := ctx.Query("structs"); v != "" {
+		args.Structs = strings.Split(v, ",")
+	}
+	if err := args.Validate(); err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+
+	corpusID, err := corpus.CorpusIDFromRequest(ctx)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	corpHandle, err := a.pool.Acquire(corpusID)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	defer corpHandle.Release()
+
+	conc, releaseConc, err := a.concProvider.Acquire(corpHandle, corpusID, q)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	defer releaseConc()
+	ans, err := GetKWICLines(conc, args)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
 func NewActions(
 	conf *corpus.CorporaSetup,
 	location *time.Location,
-	cache *Cache,
+	concProvider *ConcProvider,
+	pool *corpus.CorpusPool,
+	jobsManager *jobs.Manager,
 ) *Actions {
 	return &Actions{
-		conf:      conf,
-		concCache: cache,
+		conf:         conf,
+		concProvider: concProvider,
+		pool:         pool,
+		jobs:         jobsManager,
 	}
 }
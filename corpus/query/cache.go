@@ -19,46 +19,94 @@
 package query
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/cenkalti/backoff/v4"
+	"masm/v3/corpus"
+
 	"github.com/czcorpus/cnc-gokit/collections"
 	"github.com/czcorpus/cnc-gokit/fs"
 	"github.com/rs/zerolog/log"
 )
 
 const (
-	DfltConcBackoffInitialInterval = 200 * time.Millisecond
-	DfltConcBackoffMaxElapsedTime  = 2 * time.Minute
-)
+	DfltConcCacheMaxEntriesPerCorp = 1000
+	DfltConcCacheMaxCorpSize       = 5 * 1024 * 1024 * 1024
+	DfltConcCacheMaxSize           = 20 * 1024 * 1024 * 1024
+	DfltConcCacheNegativeTTL       = time.Minute
+
+	// ConcCacheStatsFlushInterval specifies how often access statistics
+	// of entries are written to their metadata files
+	ConcCacheStatsFlushInterval = 30 * time.Second
 
-var (
-	ErrEntryNotFound    = errors.New("cache entry not found")
-	ErrEntryNotReadyYet = errors.New("cache entry not ready yet")
+	// metaFileSuffix is a suffix of a sidecar file containing
+	// metadata of a cached concordance
+	metaFileSuffix = ".json"
+	tmpFileSuffix  = ".tmp"
+
+	// corpusDirSubdirSeparator replaces the slash of corpora
+	// in registry sub-directories so each corpus has a single
+	// cache directory directly within the cache root
+	corpusDirSubdirSeparator = "__"
 )
 
+// CacheEntry describes a cached concordance. Fulfilled entries
+// are stored along with the concordance file (see metaFileSuffix)
+// so they survive restarts.
 type CacheEntry struct {
-	PromisedAt  time.Time
-	FulfilledAt time.Time
-	FilePath    string
-	Err         error
+	CorpusID    string    `json:"corpusId"`
+	Query       string    `json:"query"`
+	PromisedAt  time.Time `json:"promisedAt"`
+	FulfilledAt time.Time `json:"fulfilledAt"`
+	FilePath    string    `json:"-"`
+	Size        int64     `json:"size"`
+	ConcSize    int64     `json:"concSize"`
+	Hits        int64     `json:"hits"`
+	LastAccess  time.Time `json:"lastAccess"`
+
+	// DataMtime is a modification time of the corpus data directory
+	// the concordance was calculated from. Entries with a different
+	// data modification time are invalid.
+	DataMtime time.Time `json:"dataMtime"`
+
+	// Err is set for negative entries (i.e. failed concordance
+	// calculations). Negative entries are kept only in memory
+	// and they are valid until ExpiresAt.
+	Err       error     `json:"-"`
+	ExpiresAt time.Time `json:"-"`
 }
 
+// Cache is a file-based concordance cache. Fulfilled entries are evicted
+// (least recently used first) once the configured number of entries per
+// corpus, the size per corpus or the total size is exceeded.
 type Cache struct {
+	// mu guards updates of entries (hits, eviction)
+	mu                sync.Mutex
 	data              *collections.ConcurrentMap[string, CacheEntry]
 	loc               *time.Location
 	rootPath          string
 	maxEntriesPerCorp int
-	nextListenerId    int
-	waitLimit         time.Duration
-	waitCheckInterval time.Duration
+	maxCorpSize       int64
+	maxSize           int64
+	negativeTTL       time.Duration
+
+	// dirty contains keys of entries with access statistics
+	// not written to their metadata files yet (guarded by mu)
+	dirty map[string]struct{}
+
+	// flushMu makes sure there is only one FlushAccessStats running
+	flushMu sync.Mutex
 }
 
 func (cache *Cache) mkKey(corpusID, query string) string {
@@ -68,14 +116,125 @@ func (cache *Cache) mkKey(corpusID, query string) string {
 	return hex.EncodeToString(enc.Sum(nil))
 }
 
+// corpusDir returns a name of a directory containing cached
+// concordances of a corpus
+func corpusDir(corpusID string) string {
+	return strings.ReplaceAll(corpusID, "/", corpusDirSubdirSeparator)
+}
+
 func (cache *Cache) mkPath(corpusID, query string) string {
-	return filepath.Join(cache.rootPath, corpusID, cache.mkKey(corpusID, query))
+	return filepath.Join(cache.rootPath, corpusDir(corpusID), cache.mkKey(corpusID, query))
+}
+
+// saveMeta writes entry's metadata to its sidecar file
+func (cache *Cache) saveMeta(entry CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to save cache metadata: %w", err)
+	}
+	metaPath := entry.FilePath + metaFileSuffix
+	if err := os.WriteFile(metaPath+tmpFileSuffix, data, 0644); err != nil {
+		return fmt.Errorf("failed to save cache metadata: %w", err)
+	}
+	if err := os.Rename(metaPath+tmpFileSuffix, metaPath); err != nil {
+		return fmt.Errorf("failed to save cache metadata: %w", err)
+	}
+	return nil
+}
+
+func (cache *Cache) loadMeta(filePath string) (CacheEntry, error) {
+	var entry CacheEntry
+	data, err := os.ReadFile(filePath + metaFileSuffix)
+	if err != nil {
+		return entry, fmt.Errorf("failed to load cache metadata: %w", err)
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, fmt.Errorf("failed to load cache metadata: %w", err)
+	}
+	entry.FilePath = filePath
+	return entry, nil
+}
+
+// removeFiles removes a concordance file along with its metadata
+func (cache *Cache) removeFiles(filePath string) {
+	for _, p := range []string{filePath, filePath + metaFileSuffix} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Error().Err(err).Str("path", p).Msg("failed to remove cache file")
+		}
+	}
+}
+
+// remove removes an entry including its files.
+// The method expects the cache to be locked.
+func (cache *Cache) remove(key string) {
+	entry, ok := cache.data.GetWithTest(key)
+	if !ok {
+		return
+	}
+	cache.data.Delete(key)
+	delete(cache.dirty, key)
+	cache.removeFiles(entry.FilePath)
 }
 
-func (cache *Cache) Contains(corpusID, query string) bool {
-	return cache.data.HasKey(cache.mkKey(corpusID, query))
+// enforceLimits evicts least recently used fulfilled entries exceeding
+// limits of the corpus and the global size limit. Expired negative
+// entries are removed too. The entry identified
+// by keepKey is never evicted (so a freshly calculated concordance is
+// always available at least once). The method expects the cache
+// to be locked.
+func (cache *Cache) enforceLimits(corpusID, keepKey string) {
+	type item struct {
+		key   string
+		entry CacheEntry
+	}
+	items := make([]item, 0, cache.data.Len())
+	var totalSize, corpSize int64
+	var corpEntries int
+	now := time.Now()
+	for k, v := range cache.data.AsMap() {
+		if v.Err != nil && now.After(v.ExpiresAt) {
+			cache.data.Delete(k)
+			continue
+		}
+		if v.FulfilledAt.IsZero() || v.Err != nil {
+			continue
+		}
+		items = append(items, item{k, v})
+		totalSize += v.Size
+		if v.CorpusID == corpusID {
+			corpSize += v.Size
+			corpEntries++
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].entry.LastAccess.Before(items[j].entry.LastAccess)
+	})
+	for _, itm := range items {
+		corpOverLimit := corpEntries > cache.maxEntriesPerCorp || corpSize > cache.maxCorpSize
+		if !corpOverLimit && totalSize <= cache.maxSize {
+			break
+		}
+		if itm.key == keepKey || (itm.entry.CorpusID != corpusID && totalSize <= cache.maxSize) {
+			continue
+		}
+		cache.remove(itm.key)
+		totalSize -= itm.entry.Size
+		if itm.entry.CorpusID == corpusID {
+			corpSize -= itm.entry.Size
+			corpEntries--
+		}
+		log.Debug().
+			Str("corpusId", itm.entry.CorpusID).
+			Str("query", itm.entry.Query).
+			Int64("size", itm.entry.Size).
+			Msg("evicted concordance cache entry")
+	}
 }
 
+// RestoreUnboundEntries loads entries stored in the cache directory.
+// Corpus IDs are taken from the metadata files (see corpusDir).
+// Concordance files without valid metadata and stale temporary
+// files are removed.
 func (cache *Cache) RestoreUnboundEntries() error {
 	log.Info().
 		Str("cachePath", cache.rootPath).
@@ -84,8 +243,8 @@ func (cache *Cache) RestoreUnboundEntries() error {
 	if err != nil {
 		return fmt.Errorf("failed to restore unbound cache records: %w", err)
 	}
-	now := time.Now().In(cache.loc)
 	var iterErr error
+	corpusIDs := make(map[string]bool)
 	corpDirs.ForEach(func(dirInfo os.FileInfo, _ int) bool {
 		subdir := path.Base(dirInfo.Name())
 		files, err := fs.ListFilesInDir(path.Join(cache.rootPath, subdir), false)
@@ -95,73 +254,296 @@ func (cache *Cache) RestoreUnboundEntries() error {
 		}
 		files.ForEach(func(finfo os.FileInfo, _ int) bool {
 			file := path.Base(finfo.Name())
-			entry := CacheEntry{
-				PromisedAt:  now,
-				FulfilledAt: now,
-				FilePath:    path.Join(cache.rootPath, subdir, file),
+			filePath := path.Join(cache.rootPath, subdir, file)
+			if finfo.IsDir() {
+				log.Warn().Str("path", filePath).Msg("ignoring unexpected directory in cache")
+				return true
+			}
+			if strings.HasSuffix(file, tmpFileSuffix) {
+				cache.removeFiles(filePath)
+				return true
+			}
+			if strings.HasSuffix(file, metaFileSuffix) {
+				concPath := strings.TrimSuffix(filePath, metaFileSuffix)
+				if !fs.PathExists(concPath) {
+					cache.removeFiles(concPath)
+				}
+				return true
+			}
+			entry, err := cache.loadMeta(filePath)
+			if err == nil && cache.mkKey(entry.CorpusID, entry.Query) != file {
+				err = fmt.Errorf("metadata do not match file %s", file)
+			}
+			if err != nil {
+				log.Warn().
+					Err(err).
+					Str("path", filePath).
+					Msg("removing cache file without valid metadata")
+				cache.removeFiles(filePath)
+				return true
+			}
+			if entry.Size == 0 {
+				entry.Size = finfo.Size()
 			}
 			cache.data.Set(file, entry)
+			corpusIDs[entry.CorpusID] = true
 			return true
 		})
 		return true
 	})
+	cache.mu.Lock()
+	for corpusID := range corpusIDs {
+		cache.enforceLimits(corpusID, "")
+	}
+	cache.mu.Unlock()
 	log.Info().
 		Int("numEntries", cache.data.Len()).
 		Msg("restored unbound cache entries")
 	return iterErr
 }
 
-func (cache *Cache) Promise(corpusID, query string, fn func(path string) error) <-chan CacheEntry {
+// Hit returns a valid fulfilled entry and updates its access statistics.
+// A valid negative entry is returned too (with Err set). Entries calculated
+// from data with a different modification time (i.e. the corpus has been
+// recompiled since) and expired negative entries are removed.
+// The statistics are written to metadata files later (see FlushAccessStats).
+func (cache *Cache) Hit(corpusID, query string, dataMtime time.Time) (CacheEntry, bool) {
+	entryKey := cache.mkKey(corpusID, query)
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	entry, ok := cache.data.GetWithTest(entryKey)
+	if !ok || entry.FulfilledAt.IsZero() {
+		return entry, false
+	}
+	now := time.Now().In(cache.loc)
+	if !entry.DataMtime.Equal(dataMtime) || (entry.Err != nil && now.After(entry.ExpiresAt)) {
+		log.Info().
+			Err(entry.Err).
+			Str("corpusId", corpusID).
+			Str("query", query).
+			Msg("removing invalid concordance cache entry")
+		cache.remove(entryKey)
+		return entry, false
+	}
+	entry.Hits++
+	entry.LastAccess = now
+	cache.data.Set(entryKey, entry)
+	if entry.Err == nil {
+		cache.dirty[entryKey] = struct{}{}
+	}
+	return entry, true
+}
+
+// FlushAccessStats writes access statistics of entries hit since
+// the last flush to their metadata files. The files are written
+// without the cache being locked so entries removed or replaced
+// in the meantime are skipped (a metadata file of an entry removed
+// during the flush is cleaned up by RestoreUnboundEntries).
+func (cache *Cache) FlushAccessStats() {
+	cache.flushMu.Lock()
+	defer cache.flushMu.Unlock()
+	cache.mu.Lock()
+	entries := make([]CacheEntry, 0, len(cache.dirty))
+	for key := range cache.dirty {
+		if entry, ok := cache.data.GetWithTest(key); ok {
+			entries = append(entries, entry)
+		}
+	}
+	cache.dirty = make(map[string]struct{})
+	cache.mu.Unlock()
+
+	for _, entry := range entries {
+		current, ok := cache.data.GetWithTest(cache.mkKey(entry.CorpusID, entry.Query))
+		if !ok || !current.PromisedAt.Equal(entry.PromisedAt) || current.Err != nil {
+			continue
+		}
+		if err := cache.saveMeta(current); err != nil {
+			log.Error().Err(err).Str("path", current.FilePath).Msg("failed to update cache entry")
+		}
+	}
+}
+
+// RunAccessStatsWriter periodically flushes access statistics
+// (see FlushAccessStats) until ctx is cancelled. To store
+// the remaining statistics, FlushAccessStats should be called
+// once the cache is no longer used.
+func (cache *Cache) RunAccessStatsWriter(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			cache.FlushAccessStats()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// SetFailed stores a negative entry for a query whose concordance
+// cannot be calculated so repeated requests get the same error
+// without recalculation. Possible concordance files of the query
+// are removed.
+func (cache *Cache) SetFailed(corpusID, query string, dataMtime time.Time, err error) {
+	entryKey := cache.mkKey(corpusID, query)
+	now := time.Now().In(cache.loc)
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.remove(entryKey)
+	cache.data.Set(
+		entryKey,
+		CacheEntry{
+			CorpusID:    corpusID,
+			Query:       query,
+			PromisedAt:  now,
+			FulfilledAt: now,
+			LastAccess:  now,
+			FilePath:    cache.mkPath(corpusID, query),
+			DataMtime:   dataMtime,
+			Err:         err,
+			ExpiresAt:   now.Add(cache.negativeTTL),
+		},
+	)
+}
+
+// Promise registers a new entry which is going to be written by fn.
+// The function writes to a temporary file which is moved to the final
+// location only if fn succeeds so a partially written file never becomes
+// a valid entry. In case of a failure, the entry is removed. The returned
+// channel receives the entry (with possible Err) once fn finishes.
+func (cache *Cache) Promise(
+	corpusID, query string,
+	dataMtime time.Time,
+	concSize int64,
+	fn func(path string) error,
+) <-chan CacheEntry {
 	targetPath := cache.mkPath(corpusID, query)
 	entry := CacheEntry{
+		CorpusID:   corpusID,
+		Query:      query,
 		PromisedAt: time.Now().In(cache.loc),
 		FilePath:   targetPath,
+		ConcSize:   concSize,
+		DataMtime:  dataMtime,
 	}
 	entryKey := cache.mkKey(corpusID, query)
 	cache.data.Set(entryKey, entry)
-	ans := make(chan CacheEntry)
+	// the channel is buffered so the goroutine does not leak
+	// in case nobody reads the result
+	ans := make(chan CacheEntry, 1)
 	go func(entry2 CacheEntry) {
-		err := fn(targetPath)
+		tmpPath := targetPath + tmpFileSuffix
+		err := fn(tmpPath)
+		cache.mu.Lock()
+		if err == nil {
+			err = os.Rename(tmpPath, targetPath)
+		}
+		if err == nil {
+			var finfo os.FileInfo
+			if finfo, err = os.Stat(targetPath); err == nil {
+				entry2.Size = finfo.Size()
+			}
+		}
+		entry2.FulfilledAt = time.Now().In(cache.loc)
+		entry2.LastAccess = entry2.FulfilledAt
+		if err == nil {
+			err = cache.saveMeta(entry2)
+		}
 		if err != nil {
 			entry2.Err = err
+			if err := os.Remove(tmpPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Error().Err(err).Str("path", tmpPath).Msg("failed to remove cache file")
+			}
+			// make sure we do not remove a newer entry (e.g. a negative one)
+			current, ok := cache.data.GetWithTest(entryKey)
+			if ok && current.PromisedAt.Equal(entry2.PromisedAt) {
+				cache.remove(entryKey)
+			}
+
+		} else {
+			cache.data.Set(entryKey, entry2)
+			cache.enforceLimits(corpusID, entryKey)
 		}
-		entry2.FulfilledAt = time.Now().In(cache.loc)
-		cache.data.Set(entryKey, entry2)
+		cache.mu.Unlock()
 		ans <- entry2
 		close(ans)
 	}(entry)
 	return ans
 }
 
-func (cache *Cache) Get(corpusID, query string) (CacheEntry, error) {
-	entryKey := cache.mkKey(corpusID, query)
-	operation := func() (CacheEntry, error) {
-		entry, ok := cache.data.GetWithTest(entryKey)
-		if !ok {
-			e := CacheEntry{
-				Err:         ErrEntryNotFound,
-				FulfilledAt: time.Now().In(cache.loc),
-			}
-			return e, backoff.Permanent(ErrEntryNotFound)
+// Entries returns all the entries of a corpus (or all the entries
+// in case corpusID is empty) sorted by corpus and by last access
+// (most recent first)
+func (cache *Cache) Entries(corpusID string) []CacheEntry {
+	ans := make([]CacheEntry, 0, cache.data.Len())
+	for _, v := range cache.data.Values() {
+		if corpusID == "" || v.CorpusID == corpusID {
+			ans = append(ans, v)
+		}
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		if ans[i].CorpusID != ans[j].CorpusID {
+			return ans[i].CorpusID < ans[j].CorpusID
 		}
-		if entry.FulfilledAt.IsZero() {
-			entry.Err = ErrEntryNotReadyYet
-			return entry, nil
+		return ans[i].LastAccess.After(ans[j].LastAccess)
+	})
+	return ans
+}
+
+// RemoveCorpus removes all the fulfilled entries of a corpus
+// (including files). Entries being calculated are kept.
+// The number of removed entries is returned.
+func (cache *Cache) RemoveCorpus(corpusID string) int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	var ans int
+	for k, v := range cache.data.AsMap() {
+		if v.CorpusID == corpusID && !v.FulfilledAt.IsZero() {
+			cache.remove(k)
+			ans++
 		}
-		return entry, nil
 	}
-	bkoff := backoff.NewExponentialBackOff()
-	bkoff.InitialInterval = DfltConcBackoffInitialInterval
-	bkoff.MaxElapsedTime = DfltConcBackoffMaxElapsedTime
-	return backoff.RetryWithData(operation, bkoff)
+	return ans
+}
+
+// RemoveQuery removes a fulfilled entry of a query (including files).
+// It returns false if there is no such entry.
+func (cache *Cache) RemoveQuery(corpusID, query string) bool {
+	entryKey := cache.mkKey(corpusID, query)
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	entry, ok := cache.data.GetWithTest(entryKey)
+	if !ok || entry.FulfilledAt.IsZero() {
+		return false
+	}
+	cache.remove(entryKey)
+	return true
 }
 
-func NewCache(rootPath string, location *time.Location) *Cache {
-	return &Cache{
+// NewCache creates a new cache with limits configured in setup
+// (or default limits)
+func NewCache(rootPath string, location *time.Location, setup corpus.ConcCacheSetup) *Cache {
+	ans := &Cache{
 		rootPath:          rootPath,
 		loc:               location,
 		data:              collections.NewConcurrentMap[string, CacheEntry](),
-		waitCheckInterval: time.Millisecond * 500,
-		waitLimit:         time.Second * 10,
+		maxEntriesPerCorp: setup.MaxEntriesPerCorp,
+		maxCorpSize:       setup.MaxCorpSizeBytes,
+		maxSize:           setup.MaxSizeBytes,
+		negativeTTL:       time.Duration(setup.NegativeEntryTTLSecs) * time.Second,
+		dirty:             make(map[string]struct{}),
+	}
+	if ans.maxEntriesPerCorp <= 0 {
+		ans.maxEntriesPerCorp = DfltConcCacheMaxEntriesPerCorp
+	}
+	if ans.maxCorpSize <= 0 {
+		ans.maxCorpSize = DfltConcCacheMaxCorpSize
 	}
+	if ans.maxSize <= 0 {
+		ans.maxSize = DfltConcCacheMaxSize
+	}
+	if ans.negativeTTL <= 0 {
+		ans.negativeTTL = DfltConcCacheNegativeTTL
+	}
+	return ans
 }
@@ -0,0 +1,249 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"masm/v3/corpus"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testDataMtime = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func addTestEntry(t *testing.T, cache *Cache, corpusID, query string, size int) CacheEntry {
+	entry := <-cache.Promise(corpusID, query, testDataMtime, int64(size/10), func(path string) error {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(path, make([]byte, size), 0644)
+	})
+	assert.NoError(t, entry.Err)
+	return entry
+}
+
+func hasTestEntry(cache *Cache, corpusID, query string) bool {
+	return cache.data.HasKey(cache.mkKey(corpusID, query))
+}
+
+func getTestEntry(cache *Cache, corpusID, query string) (CacheEntry, bool) {
+	return cache.data.GetWithTest(cache.mkKey(corpusID, query))
+}
+
+func TestCacheRestoresMetadata(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(dir, time.UTC, corpus.ConcCacheSetup{})
+	entry := addTestEntry(t, cache, "syn2020", "[lemma=\"pes\"]", 10)
+	_, ok := cache.Hit("syn2020", "[lemma=\"pes\"]", testDataMtime)
+	assert.True(t, ok)
+	assert.FileExists(t, entry.FilePath+metaFileSuffix)
+	cache.FlushAccessStats()
+	// a file without metadata and a stale temporary file
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "syn2020", "abc"), []byte("x"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "syn2020", "def.tmp"), []byte("x"), 0644))
+
+	cache2 := NewCache(dir, time.UTC, corpus.ConcCacheSetup{})
+	assert.NoError(t, cache2.RestoreUnboundEntries())
+	restored, ok := getTestEntry(cache2, "syn2020", "[lemma=\"pes\"]")
+	assert.True(t, ok)
+	assert.Equal(t, "syn2020", restored.CorpusID)
+	assert.Equal(t, "[lemma=\"pes\"]", restored.Query)
+	assert.Equal(t, int64(10), restored.Size)
+	assert.Equal(t, int64(1), restored.Hits)
+	assert.True(t, restored.FulfilledAt.Equal(entry.FulfilledAt))
+	assert.Equal(t, entry.FilePath, restored.FilePath)
+	assert.Equal(t, 1, cache2.data.Len())
+	assert.NoFileExists(t, filepath.Join(dir, "syn2020", "abc"))
+	assert.NoFileExists(t, filepath.Join(dir, "syn2020", "def.tmp"))
+}
+
+func TestCacheInvalidatesOnDataChange(t *testing.T) {
+	cache := NewCache(t.TempDir(), time.UTC, corpus.ConcCacheSetup{})
+	entry := addTestEntry(t, cache, "syn2020", "[word=\"x\"]", 10)
+	_, ok := cache.Hit("syn2020", "[word=\"x\"]", testDataMtime.Add(time.Minute))
+	assert.False(t, ok)
+	assert.False(t, hasTestEntry(cache, "syn2020", "[word=\"x\"]"))
+	assert.NoFileExists(t, entry.FilePath)
+	assert.NoFileExists(t, entry.FilePath+metaFileSuffix)
+}
+
+func TestCacheEvictsPerCorpus(t *testing.T) {
+	cache := NewCache(t.TempDir(), time.UTC, corpus.ConcCacheSetup{MaxEntriesPerCorp: 2})
+	first := addTestEntry(t, cache, "syn2020", "q1", 10)
+	addTestEntry(t, cache, "syn2020", "q2", 10)
+	addTestEntry(t, cache, "susanne", "q1", 10)
+	// q1 becomes the most recently used one
+	_, ok := cache.Hit("syn2020", "q1", testDataMtime)
+	assert.True(t, ok)
+	addTestEntry(t, cache, "syn2020", "q3", 10)
+	assert.True(t, hasTestEntry(cache, "syn2020", "q1"))
+	assert.False(t, hasTestEntry(cache, "syn2020", "q2"))
+	assert.True(t, hasTestEntry(cache, "syn2020", "q3"))
+	assert.True(t, hasTestEntry(cache, "susanne", "q1"))
+	assert.FileExists(t, first.FilePath)
+}
+
+func TestCacheEvictsBySize(t *testing.T) {
+	cache := NewCache(t.TempDir(), time.UTC, corpus.ConcCacheSetup{MaxSizeBytes: 25})
+	old := addTestEntry(t, cache, "susanne", "q1", 10)
+	addTestEntry(t, cache, "syn2020", "q1", 10)
+	addTestEntry(t, cache, "syn2020", "q2", 10)
+	assert.False(t, hasTestEntry(cache, "susanne", "q1"))
+	assert.NoFileExists(t, old.FilePath)
+	assert.True(t, hasTestEntry(cache, "syn2020", "q1"))
+	assert.True(t, hasTestEntry(cache, "syn2020", "q2"))
+
+	// a single entry exceeding the limit is kept
+	addTestEntry(t, cache, "syn2020", "q3", 30)
+	assert.True(t, hasTestEntry(cache, "syn2020", "q3"))
+	assert.Equal(t, 1, cache.data.Len())
+}
+
+func TestCacheRemoveEntries(t *testing.T) {
+	cache := NewCache(t.TempDir(), time.UTC, corpus.ConcCacheSetup{})
+	q1 := addTestEntry(t, cache, "syn2020", "q1", 10)
+	addTestEntry(t, cache, "syn2020", "q2", 10)
+	addTestEntry(t, cache, "susanne", "q1", 10)
+	assert.Len(t, cache.Entries(""), 3)
+	assert.Len(t, cache.Entries("syn2020"), 2)
+
+	assert.True(t, cache.RemoveQuery("syn2020", "q1"))
+	assert.False(t, cache.RemoveQuery("syn2020", "q1"))
+	assert.NoFileExists(t, q1.FilePath)
+	assert.NoFileExists(t, q1.FilePath+metaFileSuffix)
+
+	assert.Equal(t, 1, cache.RemoveCorpus("syn2020"))
+	assert.Equal(t, 0, cache.RemoveCorpus("syn2020"))
+	entries := cache.Entries("")
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "susanne", entries[0].CorpusID)
+}
+
+func TestCachePromiseFailure(t *testing.T) {
+	cache := NewCache(t.TempDir(), time.UTC, corpus.ConcCacheSetup{})
+	saveErr := errors.New("disk full")
+	var tmpPath string
+	entry := <-cache.Promise("syn2020", "q1", testDataMtime, 0, func(path string) error {
+		tmpPath = path
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte("partial"), 0644); err != nil {
+			return err
+		}
+		return saveErr
+	})
+	assert.ErrorIs(t, entry.Err, saveErr)
+	assert.NotEqual(t, entry.FilePath, tmpPath)
+	assert.NoFileExists(t, tmpPath)
+	assert.NoFileExists(t, entry.FilePath)
+	assert.False(t, hasTestEntry(cache, "syn2020", "q1"))
+}
+
+func TestCacheNegativeEntry(t *testing.T) {
+	cache := NewCache(
+		t.TempDir(), time.UTC, corpus.ConcCacheSetup{NegativeEntryTTLSecs: 60})
+	queryErr := errors.New("syntax error")
+	cache.SetFailed("syn2020", "[word=", testDataMtime, queryErr)
+	entry, ok := cache.Hit("syn2020", "[word=", testDataMtime)
+	assert.True(t, ok)
+	assert.ErrorIs(t, entry.Err, queryErr)
+	// negative entries are not persisted
+	assert.NoFileExists(t, entry.FilePath+metaFileSuffix)
+
+	// a different data version invalidates the entry
+	_, ok = cache.Hit("syn2020", "[word=", testDataMtime.Add(time.Second))
+	assert.False(t, ok)
+	assert.False(t, hasTestEntry(cache, "syn2020", "[word="))
+
+	cache.negativeTTL = time.Millisecond
+	cache.SetFailed("syn2020", "[word=", testDataMtime, queryErr)
+	time.Sleep(5 * time.Millisecond)
+	_, ok = cache.Hit("syn2020", "[word=", testDataMtime)
+	assert.False(t, ok)
+	assert.False(t, hasTestEntry(cache, "syn2020", "[word="))
+}
+
+func TestCacheRestoresSubdirCorpus(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(dir, time.UTC, corpus.ConcCacheSetup{MaxEntriesPerCorp: 1})
+	entry := addTestEntry(t, cache, "sub/corp", "q1", 10)
+	assert.Equal(t, filepath.Join(dir, "sub__corp"), filepath.Dir(entry.FilePath))
+	// an unexpected directory (e.g. from a previous nested layout) is ignored
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "sub", "corp"), 0755))
+
+	cache2 := NewCache(dir, time.UTC, corpus.ConcCacheSetup{MaxEntriesPerCorp: 1})
+	assert.NoError(t, cache2.RestoreUnboundEntries())
+	restored, ok := getTestEntry(cache2, "sub/corp", "q1")
+	assert.True(t, ok)
+	assert.Equal(t, "sub/corp", restored.CorpusID)
+	assert.Equal(t, entry.FilePath, restored.FilePath)
+	assert.Len(t, cache2.Entries("sub/corp"), 1)
+
+	// limits are applied to the restored corpus
+	addTestEntry(t, cache2, "sub/corp", "q2", 10)
+	assert.False(t, hasTestEntry(cache2, "sub/corp", "q1"))
+	assert.NoFileExists(t, entry.FilePath)
+}
+
+func TestCacheRestoreRemovesMismatchedMetadata(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(dir, time.UTC, corpus.ConcCacheSetup{})
+	entry := addTestEntry(t, cache, "syn2020", "q1", 10)
+	other := filepath.Join(filepath.Dir(entry.FilePath), "abc")
+	assert.NoError(t, os.Rename(entry.FilePath, other))
+	assert.NoError(t, os.Rename(entry.FilePath+metaFileSuffix, other+metaFileSuffix))
+
+	cache2 := NewCache(dir, time.UTC, corpus.ConcCacheSetup{})
+	assert.NoError(t, cache2.RestoreUnboundEntries())
+	assert.Equal(t, 0, cache2.data.Len())
+	assert.NoFileExists(t, other)
+	assert.NoFileExists(t, other+metaFileSuffix)
+}
+
+func TestCacheFlushAccessStats(t *testing.T) {
+	cache := NewCache(t.TempDir(), time.UTC, corpus.ConcCacheSetup{})
+	entry := addTestEntry(t, cache, "syn2020", "q1", 10)
+	for i := 0; i < 3; i++ {
+		_, ok := cache.Hit("syn2020", "q1", testDataMtime)
+		assert.True(t, ok)
+	}
+	// hits are not written immediately
+	stored, err := cache.loadMeta(entry.FilePath)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stored.Hits)
+
+	cache.FlushAccessStats()
+	stored, err = cache.loadMeta(entry.FilePath)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), stored.Hits)
+	assert.Empty(t, cache.dirty)
+
+	// removed entries are not written
+	_, ok := cache.Hit("syn2020", "q1", testDataMtime)
+	assert.True(t, ok)
+	assert.True(t, cache.RemoveQuery("syn2020", "q1"))
+	cache.FlushAccessStats()
+	assert.NoFileExists(t, entry.FilePath+metaFileSuffix)
+}
@@ -0,0 +1,181 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"masm/v3/corpus"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+)
+
+// CacheEntryInfo describes a cached concordance
+type CacheEntryInfo struct {
+	Query      string    `json:"query"`
+	Ready      bool      `json:"ready"`
+	Size       int64     `json:"size"`
+	ConcSize   int64     `json:"concSize"`
+	Hits       int64     `json:"hits"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastAccess time.Time `json:"lastAccess,omitempty"`
+	AgeSecs    int64     `json:"ageSecs"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// CorpusCacheInfo describes cached concordances of a corpus
+type CorpusCacheInfo struct {
+	CorpusID   string           `json:"corpusId"`
+	NumEntries int              `json:"numEntries"`
+	Size       int64            `json:"size"`
+	Entries    []CacheEntryInfo `json:"entries"`
+}
+
+// CacheListing is an overview of the concordance cache
+type CacheListing struct {
+	NumEntries int               `json:"numEntries"`
+	Size       int64             `json:"size"`
+	Corpora    []CorpusCacheInfo `json:"corpora"`
+}
+
+// makeCacheListing groups entries (expected to be sorted by corpus)
+// by their corpora
+func makeCacheListing(entries []CacheEntry, now time.Time) CacheListing {
+	ans := CacheListing{Corpora: []CorpusCacheInfo{}}
+	for _, entry := range entries {
+		if len(ans.Corpora) == 0 || ans.Corpora[len(ans.Corpora)-1].CorpusID != entry.CorpusID {
+			ans.Corpora = append(
+				ans.Corpora,
+				CorpusCacheInfo{CorpusID: entry.CorpusID, Entries: []CacheEntryInfo{}},
+			)
+		}
+		corp := &ans.Corpora[len(ans.Corpora)-1]
+		info := CacheEntryInfo{
+			Query:      entry.Query,
+			Ready:      !entry.FulfilledAt.IsZero() && entry.Err == nil,
+			Size:       entry.Size,
+			ConcSize:   entry.ConcSize,
+			Hits:       entry.Hits,
+			CreatedAt:  entry.PromisedAt,
+			LastAccess: entry.LastAccess,
+			AgeSecs:    int64(now.Sub(entry.PromisedAt).Seconds()),
+		}
+		if entry.Err != nil {
+			info.Error = entry.Err.Error()
+		}
+		corp.Entries = append(corp.Entries, info)
+		corp.NumEntries++
+		corp.Size += entry.Size
+		ans.NumEntries++
+		ans.Size += entry.Size
+	}
+	return ans
+}
+
+// CacheList lists all the cached concordances grouped by corpora
+func (a *Actions) CacheList(ctx *gin.Context) {
+	cache := a.concProvider.cache
+	uniresp.WriteJSONResponse(
+		ctx.Writer,
+		makeCacheListing(cache.Entries(""), time.Now().In(cache.loc)),
+	)
+}
+
+// CacheCorpusInfo lists cached concordances of a corpus
+func (a *Actions) CacheCorpusInfo(ctx *gin.Context) {
+	corpusID, err := corpus.CorpusIDFromRequest(ctx)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	cache := a.concProvider.cache
+	ans := makeCacheListing(cache.Entries(corpusID), time.Now().In(cache.loc))
+	if len(ans.Corpora) == 0 {
+		uniresp.WriteJSONResponse(
+			ctx.Writer,
+			CorpusCacheInfo{CorpusID: corpusID, Entries: []CacheEntryInfo{}},
+		)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans.Corpora[0])
+}
+
+// CacheDelete removes cached concordances of a corpus. In case
+// the `q` argument is present, only the entry of the query is removed.
+func (a *Actions) CacheDelete(ctx *gin.Context) {
+	corpusID, err := corpus.CorpusIDFromRequest(ctx)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	if ctx.Request.URL.Query().Has("q") {
+		q := ctx.Query("q")
+		if !a.concProvider.cache.RemoveQuery(corpusID, q) {
+			corpus.WriteErrorResponse(
+				ctx.Writer,
+				fmt.Errorf("cache entry of %s for query %s %w", corpusID, q, corpus.ErrNotFound),
+			)
+			return
+		}
+		uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"numRemoved": 1})
+		return
+	}
+	uniresp.WriteJSONResponse(
+		ctx.Writer,
+		map[string]any{"numRemoved": a.concProvider.cache.RemoveCorpus(corpusID)},
+	)
+}
+
+// CachePrewarm starts a background job calculating concordances
+// of queries listed in the request body (see PrewarmArgs)
+func (a *Actions) CachePrewarm(ctx *gin.Context) {
+	corpusID, err := corpus.CorpusIDFromRequest(ctx)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	var args PrewarmArgs
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&args); err != nil {
+		corpus.WriteErrorResponse(
+			ctx.Writer, fmt.Errorf("%w: failed to decode arguments: %s", corpus.ErrInvalidArgs, err))
+		return
+	}
+	if err := args.Validate(); err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	// make sure the corpus exists before the job is started
+	corpHandle, err := a.pool.Acquire(corpusID)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	corpHandle.Release()
+	prewarm := NewCachePrewarm(corpusID, args, a.pool, a.concProvider)
+	jobInfo, err := a.jobs.Start(CachePrewarmJobType, corpusID, prewarm.Run)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, jobInfo)
+}
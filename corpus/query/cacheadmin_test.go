@@ -0,0 +1,98 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"masm/v3/corpus"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeCacheListing(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	entries := []CacheEntry{
+		{CorpusID: "susanne", Query: "q1", Size: 5, Hits: 2,
+			PromisedAt: now.Add(-time.Hour), FulfilledAt: now.Add(-time.Hour)},
+		{CorpusID: "syn2020", Query: "q1", Size: 10,
+			PromisedAt: now.Add(-time.Minute), FulfilledAt: now.Add(-time.Minute)},
+		{CorpusID: "syn2020", Query: "q2", PromisedAt: now.Add(-time.Second)},
+		{CorpusID: "syn2020", Query: "q3", PromisedAt: now, FulfilledAt: now,
+			Err: errors.New("disk full")},
+	}
+	listing := makeCacheListing(entries, now)
+	assert.Equal(t, 4, listing.NumEntries)
+	assert.Equal(t, int64(15), listing.Size)
+	assert.Len(t, listing.Corpora, 2)
+	assert.Equal(t, "susanne", listing.Corpora[0].CorpusID)
+	assert.Equal(t, int64(3600), listing.Corpora[0].Entries[0].AgeSecs)
+	assert.Equal(t, int64(2), listing.Corpora[0].Entries[0].Hits)
+	assert.True(t, listing.Corpora[0].Entries[0].Ready)
+
+	syn := listing.Corpora[1]
+	assert.Equal(t, 3, syn.NumEntries)
+	assert.Equal(t, int64(10), syn.Size)
+	assert.True(t, syn.Entries[0].Ready)
+	assert.False(t, syn.Entries[1].Ready)
+	assert.False(t, syn.Entries[2].Ready)
+	assert.Equal(t, "disk full", syn.Entries[2].Error)
+
+	assert.Empty(t, makeCacheListing(nil, now).Corpora)
+}
+
+func TestPrewarmArgsValidate(t *testing.T) {
+	assert.NoError(t, PrewarmArgs{Queries: []string{"[word=\"x\"]"}}.Validate())
+	assert.ErrorIs(t, PrewarmArgs{}.Validate(), corpus.ErrInvalidArgs)
+	assert.ErrorIs(t, PrewarmArgs{Queries: []string{"q", ""}}.Validate(), corpus.ErrInvalidArgs)
+	assert.ErrorIs(
+		t,
+		PrewarmArgs{Queries: make([]string, MaxPrewarmQueries+1)}.Validate(),
+		corpus.ErrInvalidArgs,
+	)
+}
+
+func TestCacheDeleteErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := NewConcProvider(NewCache(t.TempDir(), time.UTC, corpus.ConcCacheSetup{}))
+	actions := NewActions(&corpus.CorporaSetup{}, time.UTC, provider, nil, nil)
+	engine := gin.New()
+	engine.DELETE("/cache/:corpusId", actions.CacheDelete)
+
+	resp := httptest.NewRecorder()
+	engine.ServeHTTP(resp, httptest.NewRequest(http.MethodDelete, "/cache/susanne?q=foo", nil))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	var errResp corpus.ErrorResponse
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &errResp))
+	assert.Equal(t, corpus.ErrorCodeNotFound, errResp.ErrorCode)
+
+	resp = httptest.NewRecorder()
+	engine.ServeHTTP(resp, httptest.NewRequest(http.MethodDelete, "/cache/.hidden", nil))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = httptest.NewRecorder()
+	engine.ServeHTTP(resp, httptest.NewRequest(http.MethodDelete, "/cache/susanne", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"masm/v3/corpus"
+	"masm/v3/mango"
+)
+
+const (
+	DfltCollMinFreq  = 20
+	DfltCollMinBgr   = 20
+	DfltCollFromW    = -5
+	DfltCollToW      = 5
+	DfltCollMaxItems = 20
+	DfltCollFn       = "logDice"
+
+	MaxCollWindow   = 50
+	MaxCollMaxItems = 1000
+)
+
+// CollArgs specifies collocations calculation
+type CollArgs struct {
+	Attr string
+
+	// Fns are names of functions (see collFunc) whose scores
+	// are calculated for each item. The first one is used
+	// for sorting.
+	Fns      []string
+	MinFreq  int64
+	MinBgr   int64
+	FromW    int
+	ToW      int
+	MaxItems int
+}
+
+// ParseCollFns parses values of (possibly repeated) `fn` arguments.
+// Each value can contain comma-separated function names.
+func ParseCollFns(values []string) []string {
+	ans := make([]string, 0, len(values))
+	for _, v := range values {
+		for _, fn := range strings.Split(v, ",") {
+			fn = strings.TrimSpace(fn)
+			if fn != "" {
+				ans = append(ans, fn)
+			}
+		}
+	}
+	return ans
+}
+
+func (args CollArgs) Validate() error {
+	if args.Attr == "" {
+		return fmt.Errorf("%w: missing attribute", corpus.ErrInvalidArgs)
+	}
+	if len(args.Fns) == 0 {
+		return fmt.Errorf("%w: no collocations function specified", corpus.ErrInvalidArgs)
+	}
+	used := make(map[string]bool)
+	for _, fn := range args.Fns {
+		if _, ok := collFunc[fn]; !ok {
+			return fmt.Errorf("%w: unknown collocations function %s", corpus.ErrInvalidArgs, fn)
+		}
+		if used[fn] {
+			return fmt.Errorf("%w: duplicate collocations function %s", corpus.ErrInvalidArgs, fn)
+		}
+		used[fn] = true
+	}
+	if args.MinFreq < 0 || args.MinBgr < 0 {
+		return fmt.Errorf("%w: minFreq and minBgr must be non-negative", corpus.ErrInvalidArgs)
+	}
+	if args.FromW < -MaxCollWindow || args.ToW > MaxCollWindow || args.FromW > args.ToW {
+		return fmt.Errorf(
+			"%w: window must satisfy -%d <= fromW <= toW <= %d",
+			corpus.ErrInvalidArgs, MaxCollWindow, MaxCollWindow,
+		)
+	}
+	if args.MaxItems < 1 || args.MaxItems > MaxCollMaxItems {
+		return fmt.Errorf(
+			"%w: maxItems must be between 1 and %d", corpus.ErrInvalidArgs, MaxCollMaxItems)
+	}
+	return nil
+}
+
+// mangoArgs converts the arguments to the Manatee (mango) format
+func (args CollArgs) mangoArgs() mango.CollocationsArgs {
+	fns := make([]byte, len(args.Fns))
+	for i, fn := range args.Fns {
+		fns[i] = collFunc[fn]
+	}
+	return mango.CollocationsArgs{
+		Attr:     args.Attr,
+		SortFn:   fns[0],
+		ScoreFns: fns,
+		MinFreq:  args.MinFreq,
+		MinBgr:   args.MinBgr,
+		FromW:    args.FromW,
+		ToW:      args.ToW,
+		MaxItems: args.MaxItems,
+	}
+}
+
+// makeCollItems attaches function names to calculated scores
+func makeCollItems(colls []*mango.GoColls, fns []string) []*CollItem {
+	ans := make([]*CollItem, len(colls))
+	for i, c := range colls {
+		item := &CollItem{
+			Word:   c.Word,
+			Value:  c.Value,
+			Freq:   c.Freq,
+			Scores: make(map[string]float64, len(fns)),
+		}
+		for j, fn := range fns {
+			if j < len(c.Scores) {
+				item.Scores[fn] = c.Scores[j]
+			}
+		}
+		ans[i] = item
+	}
+	return ans
+}
+
+// CalcCollocations calculates collocations of a concordance
+func CalcCollocations(conc *mango.GoConc, args CollArgs) ([]*CollItem, error) {
+	colls, err := mango.GetCollcations(conc, args.mangoArgs())
+	if err != nil {
+		return nil, err
+	}
+	return makeCollItems(colls, args.Fns), nil
+}
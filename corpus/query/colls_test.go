@@ -0,0 +1,91 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"testing"
+
+	"masm/v3/corpus"
+	"masm/v3/mango"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func dfltCollArgs() CollArgs {
+	return CollArgs{
+		Attr:     "word",
+		Fns:      []string{"logDice"},
+		MinFreq:  DfltCollMinFreq,
+		MinBgr:   DfltCollMinBgr,
+		FromW:    DfltCollFromW,
+		ToW:      DfltCollToW,
+		MaxItems: DfltCollMaxItems,
+	}
+}
+
+func TestParseCollFns(t *testing.T) {
+	assert.Equal(
+		t,
+		[]string{"logDice", "tScore", "LLH"},
+		ParseCollFns([]string{"logDice", " tScore, LLH,", ""}),
+	)
+	assert.Empty(t, ParseCollFns(nil))
+}
+
+func TestCollArgsValidate(t *testing.T) {
+	assert.NoError(t, dfltCollArgs().Validate())
+
+	for name, update := range map[string]func(*CollArgs){
+		"unknown fn":     func(a *CollArgs) { a.Fns = []string{"logDice", "foo"} },
+		"duplicate fn":   func(a *CollArgs) { a.Fns = []string{"logDice", "logDice"} },
+		"no fn":          func(a *CollArgs) { a.Fns = nil },
+		"no attr":        func(a *CollArgs) { a.Attr = "" },
+		"neg. minBgr":    func(a *CollArgs) { a.MinBgr = -1 },
+		"swapped window": func(a *CollArgs) { a.FromW, a.ToW = 3, -3 },
+		"large window":   func(a *CollArgs) { a.ToW = MaxCollWindow + 1 },
+		"zero maxItems":  func(a *CollArgs) { a.MaxItems = 0 },
+	} {
+		args := dfltCollArgs()
+		update(&args)
+		assert.ErrorIs(t, args.Validate(), corpus.ErrInvalidArgs, name)
+	}
+}
+
+func TestCollArgsMangoArgs(t *testing.T) {
+	args := dfltCollArgs()
+	args.Fns = []string{"tScore", "logDice", "mutualInf3"}
+	args.FromW, args.ToW = -2, 0
+	margs := args.mangoArgs()
+	assert.Equal(t, byte('t'), margs.SortFn)
+	assert.Equal(t, []byte{'t', 'd', '3'}, margs.ScoreFns)
+	assert.Equal(t, -2, margs.FromW)
+	assert.Equal(t, 0, margs.ToW)
+	assert.Equal(t, int64(DfltCollMinBgr), margs.MinBgr)
+}
+
+func TestMakeCollItems(t *testing.T) {
+	items := makeCollItems(
+		[]*mango.GoColls{{Word: "dog", Value: 9.5, Freq: 30, Scores: []float64{9.5, 4.2}}},
+		[]string{"logDice", "tScore"},
+	)
+	assert.Len(t, items, 1)
+	assert.Equal(t, "dog", items[0].Word)
+	assert.Equal(t, 9.5, items[0].Value)
+	assert.Equal(t, map[string]float64{"logDice": 9.5, "tScore": 4.2}, items[0].Scores)
+}
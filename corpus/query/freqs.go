@@ -0,0 +1,214 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"masm/v3/corpus"
+	"masm/v3/mango"
+)
+
+const (
+	FreqSortFreq  = "freq"
+	FreqSortIPM   = "ipm"
+	FreqSortAlpha = "alpha"
+
+	DfltFreqCritPosition = "0~0>0"
+)
+
+var (
+	freqCritAttrRegexp = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)?)(?:/([ie]*))?$`)
+	freqCritPosRegexp  = regexp.MustCompile(`^-?\d+(?:[<>]-?\d+)?(?:~-?\d+(?:[<>]-?\d+)?)?$`)
+)
+
+// FreqCritLevel is a single level of a frequency criterion,
+// e.g. `lemma/i 0~0>0` or `doc.genre 0`
+type FreqCritLevel struct {
+	Attr string
+
+	// Flags are Manatee attribute flags (`i` = ignore case)
+	Flags    string
+	Position string
+}
+
+func (lev FreqCritLevel) String() string {
+	if lev.Flags != "" {
+		return fmt.Sprintf("%s/%s %s", lev.Attr, lev.Flags, lev.Position)
+	}
+	return fmt.Sprintf("%s %s", lev.Attr, lev.Position)
+}
+
+// FreqCrit is a (possibly multi-level) frequency criterion
+type FreqCrit []FreqCritLevel
+
+// String returns the criterion in a form accepted by Manatee
+func (fc FreqCrit) String() string {
+	ans := make([]string, len(fc))
+	for i, lev := range fc {
+		ans[i] = lev.String()
+	}
+	return strings.Join(ans, " ")
+}
+
+// Validate tests whether all the attributes used in the criterion
+// are available in the corpus. Positional attributes are tested against
+// ATTRLIST, structural ones against STRUCTLIST and STRUCTATTRLIST.
+func (fc FreqCrit) Validate(attrs, structs, structAttrs []string) error {
+	contains := func(items []string, v string) bool {
+		for _, item := range items {
+			if item == v {
+				return true
+			}
+		}
+		return false
+	}
+	for _, lev := range fc {
+		if strct, _, ok := strings.Cut(lev.Attr, "."); ok {
+			if !contains(structs, strct) {
+				return fmt.Errorf("%w: structure %s", mango.ErrAttrNotFound, strct)
+			}
+			if !contains(structAttrs, lev.Attr) {
+				return fmt.Errorf("%w: structural attribute %s", mango.ErrAttrNotFound, lev.Attr)
+			}
+
+		} else if !contains(attrs, lev.Attr) {
+			return fmt.Errorf("%w: attribute %s", mango.ErrAttrNotFound, lev.Attr)
+		}
+	}
+	return nil
+}
+
+// ParseFreqCrit parses a criterion in the Manatee format
+// (`attr[/flags] position [attr[/flags] position ...]`)
+func ParseFreqCrit(s string) (FreqCrit, error) {
+	parts := strings.Fields(s)
+	if len(parts) == 0 || len(parts)%2 != 0 {
+		return nil, fmt.Errorf("%w: invalid frequency criterion %q", corpus.ErrInvalidArgs, s)
+	}
+	ans := make(FreqCrit, 0, len(parts)/2)
+	for i := 0; i < len(parts); i += 2 {
+		srch := freqCritAttrRegexp.FindStringSubmatch(parts[i])
+		if srch == nil {
+			return nil, fmt.Errorf(
+				"%w: invalid attribute %q in frequency criterion", corpus.ErrInvalidArgs, parts[i])
+		}
+		if !freqCritPosRegexp.MatchString(parts[i+1]) {
+			return nil, fmt.Errorf(
+				"%w: invalid position %q in frequency criterion", corpus.ErrInvalidArgs, parts[i+1])
+		}
+		ans = append(ans, FreqCritLevel{Attr: srch[1], Flags: srch[2], Position: parts[i+1]})
+	}
+	return ans, nil
+}
+
+// FreqArgs specifies frequency distribution calculation
+type FreqArgs struct {
+	Crit   []FreqCrit
+	FLimit int
+	Sort   string
+
+	// MaxItems limits the number of items per criterion
+	// (0 means no limit)
+	MaxItems int
+	Offset   int
+
+	// Limit is a page size (0 means all the items)
+	Limit int
+}
+
+func (args FreqArgs) Validate() error {
+	switch args.Sort {
+	case FreqSortFreq, FreqSortIPM, FreqSortAlpha:
+	default:
+		return fmt.Errorf("%w: invalid sort %q", corpus.ErrInvalidArgs, args.Sort)
+	}
+	if args.FLimit < 0 || args.MaxItems < 0 || args.Offset < 0 || args.Limit < 0 {
+		return fmt.Errorf(
+			"%w: flimit, maxItems, offset and limit must be non-negative", corpus.ErrInvalidArgs)
+	}
+	return nil
+}
+
+// FreqBlock contains frequency distribution for a single criterion
+type FreqBlock struct {
+	FCrit string             `json:"fcrit"`
+	Total int                `json:"total"`
+	Freqs []*FreqDistribItem `json:"freqs"`
+}
+
+func sortFreqItems(items []*FreqDistribItem, sortBy string) {
+	sort.SliceStable(items, func(i, j int) bool {
+		switch sortBy {
+		case FreqSortIPM:
+			return items[i].IPM > items[j].IPM
+		case FreqSortAlpha:
+			return items[i].Word < items[j].Word
+		default:
+			return items[i].Freq > items[j].Freq
+		}
+	})
+}
+
+// makeFreqBlock sorts items and applies maxItems and paging
+func makeFreqBlock(fcrit string, items []*FreqDistribItem, args FreqArgs) FreqBlock {
+	sortFreqItems(items, args.Sort)
+	if args.MaxItems > 0 && len(items) > args.MaxItems {
+		items = items[:args.MaxItems]
+	}
+	ans := FreqBlock{FCrit: fcrit, Total: len(items)}
+	from := min(args.Offset, len(items))
+	to := len(items)
+	if args.Limit > 0 {
+		to = min(from+args.Limit, len(items))
+	}
+	ans.Freqs = items[from:to]
+	return ans
+}
+
+// CalcFreqs calculates frequency distribution for all the criteria
+func CalcFreqs(conc *mango.GoConc, args FreqArgs) ([]FreqBlock, error) {
+	ans := make([]FreqBlock, len(args.Crit))
+	for i, crit := range args.Crit {
+		fcrit := crit.String()
+		freqs, err := mango.CalcFreqDist(conc, fcrit, args.FLimit)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]*FreqDistribItem, len(freqs.Freqs))
+		for j := range items {
+			norm := freqs.Norms[j]
+			if norm == 0 {
+				norm = conc.CorpSize()
+			}
+			items[j] = &FreqDistribItem{
+				Freq:   freqs.Freqs[j],
+				Norm:   norm,
+				IPM:    float32(freqs.Freqs[j]) / float32(norm) * 1e6,
+				Word:   freqs.Words[j],
+				Values: freqs.Values[j],
+			}
+		}
+		ans[i] = makeFreqBlock(fcrit, items, args)
+	}
+	return ans, nil
+}
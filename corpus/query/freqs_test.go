@@ -0,0 +1,90 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"testing"
+
+	"masm/v3/corpus"
+	"masm/v3/mango"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFreqCrit(t *testing.T) {
+	crit, err := ParseFreqCrit("lemma/e 0~0>0")
+	assert.NoError(t, err)
+	assert.Equal(t, FreqCrit{{Attr: "lemma", Flags: "e", Position: "0~0>0"}}, crit)
+	assert.Equal(t, "lemma/e 0~0>0", crit.String())
+
+	crit, err = ParseFreqCrit("word/i -1  tag 1<0")
+	assert.NoError(t, err)
+	assert.Len(t, crit, 2)
+	assert.Equal(t, "word/i -1 tag 1<0", crit.String())
+
+	crit, err = ParseFreqCrit("doc.genre 0")
+	assert.NoError(t, err)
+	assert.Equal(t, "doc.genre", crit[0].Attr)
+
+	for _, v := range []string{"", "lemma", "lemma/x 0", "lemma 0 tag", "le;ma 0", "lemma 0~"} {
+		_, err := ParseFreqCrit(v)
+		assert.ErrorIs(t, err, corpus.ErrInvalidArgs, v)
+	}
+}
+
+func TestFreqCritValidate(t *testing.T) {
+	attrs := []string{"word", "lemma"}
+	structs := []string{"doc", "s"}
+	structAttrs := []string{"doc.genre"}
+	crit, _ := ParseFreqCrit("lemma 0 doc.genre 0")
+	assert.NoError(t, crit.Validate(attrs, structs, structAttrs))
+	crit, _ = ParseFreqCrit("tag 0")
+	assert.ErrorIs(t, crit.Validate(attrs, structs, structAttrs), mango.ErrAttrNotFound)
+	crit, _ = ParseFreqCrit("doc.year 0")
+	assert.ErrorIs(t, crit.Validate(attrs, structs, structAttrs), mango.ErrAttrNotFound)
+	crit, _ = ParseFreqCrit("p.type 0")
+	assert.ErrorIs(t, crit.Validate(attrs, structs, structAttrs), mango.ErrAttrNotFound)
+}
+
+func TestMakeFreqBlock(t *testing.T) {
+	mkItems := func() []*FreqDistribItem {
+		return []*FreqDistribItem{
+			{Word: "b", Freq: 10, IPM: 1},
+			{Word: "a", Freq: 5, IPM: 3},
+			{Word: "c", Freq: 7, IPM: 2},
+		}
+	}
+	words := func(block FreqBlock) []string {
+		ans := make([]string, len(block.Freqs))
+		for i, item := range block.Freqs {
+			ans[i] = item.Word
+		}
+		return ans
+	}
+	block := makeFreqBlock("word 0", mkItems(), FreqArgs{Sort: FreqSortFreq})
+	assert.Equal(t, []string{"b", "c", "a"}, words(block))
+	block = makeFreqBlock("word 0", mkItems(), FreqArgs{Sort: FreqSortIPM})
+	assert.Equal(t, []string{"a", "c", "b"}, words(block))
+	block = makeFreqBlock("word 0", mkItems(), FreqArgs{Sort: FreqSortAlpha, Offset: 1, Limit: 1})
+	assert.Equal(t, []string{"b"}, words(block))
+	assert.Equal(t, 3, block.Total)
+	block = makeFreqBlock("word 0", mkItems(), FreqArgs{Sort: FreqSortFreq, MaxItems: 2, Offset: 5})
+	assert.Equal(t, 2, block.Total)
+	assert.Len(t, block.Freqs, 0)
+}
@@ -0,0 +1,197 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"fmt"
+
+	"masm/v3/corpus"
+	"masm/v3/mango"
+)
+
+const (
+	KWICItemToken       = "token"
+	KWICItemStructBegin = "structBegin"
+	KWICItemStructEnd   = "structEnd"
+
+	DfltKWICLimit = 20
+	MaxKWICLimit  = 1000
+	DfltKWICCtx   = 5
+	MaxKWICCtx    = 50
+)
+
+// KWICItem is either a token or a structure boundary
+type KWICItem struct {
+	Type   string            `json:"type"`
+	Pos    int64             `json:"pos"`
+	Struct string            `json:"struct,omitempty"`
+	Attrs  map[string]string `json:"attrs,omitempty"`
+}
+
+// KWICLine is a single concordance line split
+// into left context, KWIC and right context
+type KWICLine struct {
+	Pos   int64      `json:"pos"`
+	Left  []KWICItem `json:"left"`
+	KWIC  []KWICItem `json:"kwic"`
+	Right []KWICItem `json:"right"`
+}
+
+// ConcResult is a page of concordance lines
+type ConcResult struct {
+	ConcSize int64      `json:"concSize"`
+	Offset   int        `json:"offset"`
+	Limit    int        `json:"limit"`
+	Lines    []KWICLine `json:"lines"`
+}
+
+// KWICArgs specifies which concordance lines are
+// returned and what they contain
+type KWICArgs struct {
+	Offset  int
+	Limit   int
+	Attrs   []string
+	Structs []string
+
+	// Ctx is a number of tokens on both sides of KWIC
+	Ctx int
+}
+
+func (args KWICArgs) Validate() error {
+	if args.Offset < 0 {
+		return fmt.Errorf("%w: offset must be non-negative", corpus.ErrInvalidArgs)
+	}
+	if args.Limit < 1 || args.Limit > MaxKWICLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", corpus.ErrInvalidArgs, MaxKWICLimit)
+	}
+	if args.Ctx < 0 || args.Ctx > MaxKWICCtx {
+		return fmt.Errorf("%w: ctx must be between 0 and %d", corpus.ErrInvalidArgs, MaxKWICCtx)
+	}
+	if len(args.Attrs) == 0 {
+		return fmt.Errorf("%w: at least one attribute must be specified", corpus.ErrInvalidArgs)
+	}
+	return nil
+}
+
+// kwicSource provides corpus data needed to build KWIC lines
+type kwicSource interface {
+	attrValues(attr string, fromPos, toPos int64) ([]string, error)
+	structRanges(strct string, fromPos, toPos int64) ([]int64, []int64, error)
+}
+
+type corpusKWICSource struct {
+	corp *mango.GoCorpus
+}
+
+func (src corpusKWICSource) attrValues(attr string, fromPos, toPos int64) ([]string, error) {
+	return mango.GetAttrValuesAt(src.corp, attr, fromPos, toPos)
+}
+
+func (src corpusKWICSource) structRanges(strct string, fromPos, toPos int64) ([]int64, []int64, error) {
+	return mango.GetStructRanges(src.corp, strct, fromPos, toPos)
+}
+
+// buildKWICLine creates a KWIC line for a concordance line [beg, end)
+// with args.Ctx tokens of context on both sides. Structure boundaries
+// within the line are inserted before (beginnings) and after (ends)
+// respective tokens.
+func buildKWICLine(src kwicSource, beg, end, corpSize int64, args KWICArgs) (KWICLine, error) {
+	ans := KWICLine{
+		Pos:   beg,
+		Left:  make([]KWICItem, 0, args.Ctx*2),
+		KWIC:  make([]KWICItem, 0, end-beg),
+		Right: make([]KWICItem, 0, args.Ctx*2),
+	}
+	from := max(0, beg-int64(args.Ctx))
+	to := min(corpSize, end+int64(args.Ctx))
+	values := make([][]string, len(args.Attrs))
+	for i, attr := range args.Attrs {
+		v, err := src.attrValues(attr, from, to)
+		if err != nil {
+			return ans, err
+		}
+		if int64(len(v)) != to-from {
+			return ans, fmt.Errorf("unexpected number of values for %s", attr)
+		}
+		values[i] = v
+	}
+	begins := make(map[int64][]string)
+	ends := make(map[int64][]string)
+	for _, strct := range args.Structs {
+		begs, endsAt, err := src.structRanges(strct, from, to)
+		if err != nil {
+			return ans, err
+		}
+		for i := range begs {
+			if begs[i] >= from {
+				begins[begs[i]] = append(begins[begs[i]], strct)
+			}
+			if endsAt[i] <= to {
+				// structures listed first are considered to be
+				// the outer ones so their ends go last
+				ends[endsAt[i]-1] = append([]string{strct}, ends[endsAt[i]-1]...)
+			}
+		}
+	}
+	for pos := from; pos < to; pos++ {
+		target := &ans.Right
+		if pos < beg {
+			target = &ans.Left
+
+		} else if pos < end {
+			target = &ans.KWIC
+		}
+		for _, strct := range begins[pos] {
+			*target = append(*target, KWICItem{Type: KWICItemStructBegin, Pos: pos, Struct: strct})
+		}
+		token := KWICItem{Type: KWICItemToken, Pos: pos, Attrs: make(map[string]string)}
+		for i, attr := range args.Attrs {
+			token.Attrs[attr] = values[i][pos-from]
+		}
+		*target = append(*target, token)
+		for _, strct := range ends[pos] {
+			*target = append(*target, KWICItem{Type: KWICItemStructEnd, Pos: pos, Struct: strct})
+		}
+	}
+	return ans, nil
+}
+
+// GetKWICLines creates a page of KWIC lines from a concordance
+func GetKWICLines(conc *mango.GoConc, args KWICArgs) (*ConcResult, error) {
+	ans := &ConcResult{
+		ConcSize: conc.Size(),
+		Offset:   args.Offset,
+		Limit:    args.Limit,
+		Lines:    make([]KWICLine, 0, args.Limit),
+	}
+	begs, ends, err := mango.GetConcRanges(
+		conc, int64(args.Offset), int64(args.Offset+args.Limit))
+	if err != nil {
+		return nil, err
+	}
+	src := corpusKWICSource{corp: conc.Corpus()}
+	for i := range begs {
+		line, err := buildKWICLine(src, begs[i], ends[i], conc.CorpSize(), args)
+		if err != nil {
+			return nil, err
+		}
+		ans.Lines = append(ans.Lines, line)
+	}
+	return ans, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testKWICSource struct {
+	attrs   map[string][]string
+	structs map[string][][2]int64
+}
+
+func (src testKWICSource) attrValues(attr string, fromPos, toPos int64) ([]string, error) {
+	return src.attrs[attr][fromPos:toPos], nil
+}
+
+func (src testKWICSource) structRanges(strct string, fromPos, toPos int64) ([]int64, []int64, error) {
+	begs, ends := []int64{}, []int64{}
+	for _, r := range src.structs[strct] {
+		if r[0] < toPos && r[1] > fromPos {
+			begs = append(begs, r[0])
+			ends = append(ends, r[1])
+		}
+	}
+	return begs, ends, nil
+}
+
+func TestBuildKWICLine(t *testing.T) {
+	src := testKWICSource{
+		attrs: map[string][]string{
+			"word":  {"A", "dog", "barks", ".", "It", "is", "loud", "."},
+			"lemma": {"a", "dog", "bark", ".", "it", "be", "loud", "."},
+		},
+		structs: map[string][][2]int64{
+			"doc": {{0, 8}},
+			"s":   {{0, 4}, {4, 8}},
+		},
+	}
+	line, err := buildKWICLine(src, 2, 3, 8, KWICArgs{
+		Attrs:   []string{"word", "lemma"},
+		Structs: []string{"doc", "s"},
+		Ctx:     2,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), line.Pos)
+	assert.Len(t, line.KWIC, 1)
+	assert.Equal(t, map[string]string{"word": "barks", "lemma": "bark"}, line.KWIC[0].Attrs)
+
+	// the doc and the first sentence begin at 0
+	assert.Equal(t, KWICItemStructBegin, line.Left[0].Type)
+	assert.Equal(t, "doc", line.Left[0].Struct)
+	assert.Equal(t, "s", line.Left[1].Struct)
+	assert.Equal(t, "A", line.Left[2].Attrs["word"])
+	assert.Equal(t, "dog", line.Left[3].Attrs["word"])
+
+	types := make([]string, len(line.Right))
+	for i, item := range line.Right {
+		types[i] = item.Type
+	}
+	assert.Equal(
+		t,
+		[]string{KWICItemToken, KWICItemStructEnd, KWICItemStructBegin, KWICItemToken},
+		types,
+	)
+	assert.Equal(t, "It", line.Right[3].Attrs["word"])
+}
+
+func TestBuildKWICLineCorpusEdges(t *testing.T) {
+	src := testKWICSource{attrs: map[string][]string{"word": {"a", "b", "c"}}}
+	line, err := buildKWICLine(src, 0, 2, 3, KWICArgs{Attrs: []string{"word"}, Ctx: 5})
+	assert.NoError(t, err)
+	assert.Len(t, line.Left, 0)
+	assert.Len(t, line.KWIC, 2)
+	assert.Len(t, line.Right, 1)
+}
+
+func TestKWICArgsValidate(t *testing.T) {
+	args := KWICArgs{Limit: 20, Ctx: 5, Attrs: []string{"word"}}
+	assert.NoError(t, args.Validate())
+	args.Limit = MaxKWICLimit + 1
+	assert.Error(t, args.Validate())
+	args.Limit = 20
+	args.Attrs = []string{}
+	assert.Error(t, args.Validate())
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"masm/v3/corpus"
+	"masm/v3/jobs"
+)
+
+const (
+	CachePrewarmJobType = "cachePrewarm"
+
+	MaxPrewarmQueries = 1000
+)
+
+// PrewarmArgs is a request body of the cache pre-warming action
+type PrewarmArgs struct {
+	Queries []string `json:"queries"`
+}
+
+func (args PrewarmArgs) Validate() error {
+	if len(args.Queries) == 0 {
+		return fmt.Errorf("%w: no queries specified", corpus.ErrInvalidArgs)
+	}
+	if len(args.Queries) > MaxPrewarmQueries {
+		return fmt.Errorf(
+			"%w: too many queries (max. %d)", corpus.ErrInvalidArgs, MaxPrewarmQueries)
+	}
+	for i, q := range args.Queries {
+		if q == "" {
+			return fmt.Errorf("%w: empty query at position %d", corpus.ErrInvalidArgs, i)
+		}
+	}
+	return nil
+}
+
+// PrewarmQueryResult describes a result of a single pre-warmed query
+type PrewarmQueryResult struct {
+	Query    string `json:"query"`
+	OK       bool   `json:"ok"`
+	ConcSize int64  `json:"concSize"`
+	Error    string `json:"error,omitempty"`
+
+	// Cached is true if the concordance has been already cached
+	Cached bool `json:"cached"`
+}
+
+// PrewarmResult is a result of a cache pre-warming job
+type PrewarmResult struct {
+	CorpusID  string               `json:"corpusId"`
+	NumFailed int                  `json:"numFailed"`
+	Queries   []PrewarmQueryResult `json:"queries"`
+}
+
+// CachePrewarm calculates concordances of a list of queries
+// so they are available in the cache
+type CachePrewarm struct {
+	corpusID string
+	queries  []string
+	pool     *corpus.CorpusPool
+	provider *ConcProvider
+}
+
+// Run is a jobs.Func calculating the concordances one by one.
+// Failed queries do not stop the job, they are reported in the result.
+func (cp *CachePrewarm) Run(ctx context.Context, job *jobs.Job) (any, error) {
+	corpHandle, err := cp.pool.Acquire(cp.corpusID)
+	if err != nil {
+		return nil, err
+	}
+	defer corpHandle.Release()
+	ans := &PrewarmResult{
+		CorpusID: cp.corpusID,
+		Queries:  make([]PrewarmQueryResult, 0, len(cp.queries)),
+	}
+	total := int64(len(cp.queries))
+	for i, q := range cp.queries {
+		if err := ctx.Err(); err != nil {
+			return ans, err
+		}
+		job.SetProgress(int64(i), total, q)
+		res := PrewarmQueryResult{Query: q}
+		entry, cached, err := cp.provider.Prewarm(corpHandle, cp.corpusID, q)
+		res.ConcSize = entry.ConcSize
+		res.Cached = cached
+		if err != nil {
+			res.Error = err.Error()
+			ans.NumFailed++
+
+		} else {
+			res.OK = true
+		}
+		ans.Queries = append(ans.Queries, res)
+	}
+	job.SetProgress(total, total, "")
+	return ans, nil
+}
+
+func NewCachePrewarm(
+	corpusID string,
+	args PrewarmArgs,
+	pool *corpus.CorpusPool,
+	provider *ConcProvider,
+) *CachePrewarm {
+	return &CachePrewarm{
+		corpusID: corpusID,
+		queries:  args.Queries,
+		pool:     pool,
+		provider: provider,
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"masm/v3/corpus"
+	"masm/v3/mango"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+	"github.com/rs/zerolog/log"
+)
+
+// concFlight represents a concordance being calculated
+// (and stored to the cache). Failed calculations are stored
+// to the cache as negative entries so the error is available
+// also to callers arriving after the flight is finished.
+type concFlight struct {
+	done chan struct{}
+
+	// err is a concordance calculation error
+	// (saving errors are not reported to waiters)
+	err error
+}
+
+// concRef counts users of a concordance (a caller and possibly
+// the asynchronous save to the cache). The concordance is freed
+// once all of them are done with it.
+type concRef struct {
+	conc   *mango.GoConc
+	refs   atomic.Int32
+	freeFn func(conc *mango.GoConc)
+}
+
+func (r *concRef) release() {
+	if r.refs.Add(-1) == 0 {
+		r.freeFn(r.conc)
+	}
+}
+
+// callerRelease returns a release function for a caller. It is safe
+// to call the function more than once.
+func (r *concRef) callerRelease() func() {
+	return sync.OnceFunc(r.release)
+}
+
+func newConcRef(conc *mango.GoConc, refs int32, freeFn func(conc *mango.GoConc)) *concRef {
+	ans := &concRef{conc: conc, freeFn: freeFn}
+	ans.refs.Store(refs)
+	return ans
+}
+
+// ConcProvider is a single point where query actions obtain their
+// concordances. Concordances are shared via the cache and identical
+// queries calculated concurrently are calculated just once - other
+// callers wait for the calculation to finish and then load the
+// concordance from the cache.
+type ConcProvider struct {
+	mu       sync.Mutex
+	cache    *Cache
+	inFlight map[string]*concFlight
+
+	createFn func(corpus *mango.GoCorpus, query string) (*mango.GoConc, error)
+	openFn   func(corpus *mango.GoCorpus, path string) (*mango.GoConc, error)
+	saveFn   func(conc *mango.GoConc, path string) error
+	deleteFn func(conc *mango.GoConc)
+}
+
+// Acquire returns a concordance for the query along with a function
+// releasing it. The release function must be called once the caller
+// is done with the concordance and before the corpus handle is released
+// (the concordance is freed once it is also saved to the cache).
+func (p *ConcProvider) Acquire(
+	corpHandle *corpus.CorpusHandle,
+	corpusID, q string,
+) (*mango.GoConc, func(), error) {
+	// a newly calculated concordance is saved asynchronously
+	// so it needs its own reference to the corpus
+	saveHandle := corpHandle.Retain()
+	return p.acquire(
+		corpHandle.Corpus(), corpHandle.DataMtime(), corpusID, q, saveHandle.Release)
+}
+
+// Prewarm makes sure a concordance of the query is stored in the cache.
+// Unlike Acquire, no concordance is returned - an already cached one
+// is not even opened and a newly calculated one is freed once it is saved.
+// The returned flag is true if the concordance was already cached.
+func (p *ConcProvider) Prewarm(
+	corpHandle *corpus.CorpusHandle,
+	corpusID, q string,
+) (CacheEntry, bool, error) {
+	saveHandle := corpHandle.Retain()
+	return p.prewarm(
+		corpHandle.Corpus(), corpHandle.DataMtime(), corpusID, q, saveHandle.Release)
+}
+
+// prewarm performs the actual work of Prewarm
+func (p *ConcProvider) prewarm(
+	corp *mango.GoCorpus,
+	dataMtime time.Time,
+	corpusID, q string,
+	release func(),
+) (CacheEntry, bool, error) {
+	if entry, ok := p.cache.Hit(corpusID, q, dataMtime); ok {
+		release()
+		return entry, true, entry.Err
+	}
+	conc, releaseConc, err := p.acquire(corp, dataMtime, corpusID, q, release)
+	if err != nil {
+		return CacheEntry{}, false, err
+	}
+	concSize := conc.Size()
+	releaseConc()
+	// the concordance may still be being saved
+	key := p.cache.mkKey(corpusID, q)
+	p.mu.Lock()
+	flight, ok := p.inFlight[key]
+	p.mu.Unlock()
+	if ok {
+		<-flight.done
+	}
+	entry, ok := p.cache.data.GetWithTest(key)
+	if !ok || entry.FulfilledAt.IsZero() || entry.Err != nil {
+		return CacheEntry{ConcSize: concSize}, false, fmt.Errorf(
+			"concordance calculated but not stored in the cache")
+	}
+	return entry, false, nil
+}
+
+// acquire performs the actual work of Acquire. The release function
+// is called once the corpus is no longer needed by the provider.
+func (p *ConcProvider) acquire(
+	corp *mango.GoCorpus,
+	dataMtime time.Time,
+	corpusID, q string,
+	release func(),
+) (*mango.GoConc, func(), error) {
+	key := p.cache.mkKey(corpusID, q)
+	for {
+		p.mu.Lock()
+		if flight, ok := p.inFlight[key]; ok {
+			p.mu.Unlock()
+			<-flight.done
+			if flight.err != nil {
+				release()
+				return nil, nil, flight.err
+			}
+			continue
+		}
+		if entry, ok := p.cache.Hit(corpusID, q, dataMtime); ok {
+			p.mu.Unlock()
+			if entry.Err != nil {
+				release()
+				return nil, nil, entry.Err
+			}
+			conc, err := p.openFn(corp, entry.FilePath)
+			if err == nil {
+				release()
+				return conc, newConcRef(conc, 1, p.deleteFn).callerRelease(), nil
+			}
+			// the entry may have been evicted in the meantime
+			log.Warn().
+				Err(err).
+				Str("corpusId", corpusID).
+				Str("query", q).
+				Msg("failed to open cached concordance, going to recalculate")
+			p.mu.Lock()
+			if _, ok := p.inFlight[key]; ok {
+				p.mu.Unlock()
+				continue
+			}
+		}
+		flight := &concFlight{done: make(chan struct{})}
+		p.inFlight[key] = flight
+		p.mu.Unlock()
+		return p.calculate(corp, dataMtime, corpusID, q, key, flight, release)
+	}
+}
+
+// calculate creates a new concordance and stores it to the cache.
+// The flight is finished once the concordance is saved (or once
+// the calculation fails). The concordance is shared by the caller
+// and the save so it is freed by whichever of them finishes last.
+func (p *ConcProvider) calculate(
+	corp *mango.GoCorpus,
+	dataMtime time.Time,
+	corpusID, q, key string,
+	flight *concFlight,
+	release func(),
+) (*mango.GoConc, func(), error) {
+	conc, err := p.createFn(corp, q)
+	if err != nil {
+		p.cache.SetFailed(corpusID, q, dataMtime, err)
+		flight.err = err
+		p.finish(key, flight)
+		release()
+		return nil, nil, err
+	}
+	ref := newConcRef(conc, 2, p.deleteFn)
+	saved := p.cache.Promise(
+		corpusID,
+		q,
+		dataMtime,
+		conc.Size(),
+		func(targetPath string) error {
+			// the concordance must be freed before the corpus
+			defer release()
+			defer ref.release()
+			targetDir := filepath.Dir(targetPath)
+			if !fs.PathExists(targetDir) {
+				if err := os.MkdirAll(targetDir, 0755); err != nil {
+					return err
+				}
+			}
+			return p.saveFn(conc, targetPath)
+		},
+	)
+	go func() {
+		entry := <-saved
+		if entry.Err != nil {
+			log.Error().
+				Err(entry.Err).
+				Str("corpusId", corpusID).
+				Str("query", q).
+				Msg("failed to save concordance to the cache")
+		}
+		p.finish(key, flight)
+	}()
+	return conc, ref.callerRelease(), nil
+}
+
+func (p *ConcProvider) finish(key string, flight *concFlight) {
+	p.mu.Lock()
+	delete(p.inFlight, key)
+	p.mu.Unlock()
+	close(flight.done)
+}
+
+func NewConcProvider(cache *Cache) *ConcProvider {
+	return &ConcProvider{
+		cache:    cache,
+		inFlight: make(map[string]*concFlight),
+		createFn: mango.CreateConcordance,
+		openFn:   mango.OpenConcordance,
+		saveFn:   mango.SaveConcordance,
+		deleteFn: mango.DeleteConcordance,
+	}
+}
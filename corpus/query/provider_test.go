@@ -0,0 +1,370 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package query
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"masm/v3/corpus"
+	"masm/v3/mango"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testProviderCounters struct {
+	created  atomic.Int32
+	opened   atomic.Int32
+	saved    atomic.Int32
+	deleted  atomic.Int32
+	released atomic.Int32
+}
+
+func newTestProvider(
+	t *testing.T,
+	createErr, saveErr error,
+	unblock <-chan struct{},
+) (*ConcProvider, *testProviderCounters) {
+	counters := &testProviderCounters{}
+	p := NewConcProvider(NewCache(t.TempDir(), time.UTC, corpus.ConcCacheSetup{}))
+	p.createFn = func(corpus *mango.GoCorpus, query string) (*mango.GoConc, error) {
+		counters.created.Add(1)
+		if unblock != nil {
+			<-unblock
+		}
+		if createErr != nil {
+			return nil, createErr
+		}
+		return &mango.GoConc{}, nil
+	}
+	p.openFn = func(corpus *mango.GoCorpus, path string) (*mango.GoConc, error) {
+		counters.opened.Add(1)
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+		return &mango.GoConc{}, nil
+	}
+	p.saveFn = func(conc *mango.GoConc, path string) error {
+		counters.saved.Add(1)
+		if saveErr != nil {
+			return saveErr
+		}
+		return os.WriteFile(path, []byte("conc"), 0644)
+	}
+	p.deleteFn = func(conc *mango.GoConc) {
+		counters.deleted.Add(1)
+	}
+	return p, counters
+}
+
+func (c *testProviderCounters) release() {
+	c.released.Add(1)
+}
+
+func waitForFlights(p *ConcProvider) {
+	for {
+		p.mu.Lock()
+		n := len(p.inFlight)
+		p.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConcProviderDeduplicatesQueries(t *testing.T) {
+	unblock := make(chan struct{})
+	p, counters := newTestProvider(t, nil, nil, unblock)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conc, releaseConc, err := p.acquire(
+				&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=\"x\"]", counters.release)
+			assert.NoError(t, err)
+			assert.NotNil(t, conc)
+			releaseConc()
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(unblock)
+	wg.Wait()
+	waitForFlights(p)
+	assert.Equal(t, int32(1), counters.created.Load())
+	assert.Equal(t, int32(1), counters.saved.Load())
+	assert.Equal(t, int32(9), counters.opened.Load())
+	assert.Equal(t, int32(10), counters.deleted.Load())
+	assert.Equal(t, int32(10), counters.released.Load())
+	assert.Empty(t, p.inFlight)
+}
+
+func TestConcProviderReleasesConc(t *testing.T) {
+	p, counters := newTestProvider(t, nil, nil, nil)
+	// a fresh concordance is freed once both the caller and the save are done
+	_, releaseConc, err := p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=\"x\"]", counters.release)
+	assert.NoError(t, err)
+	waitForFlights(p)
+	assert.Equal(t, int32(1), counters.saved.Load())
+	assert.Equal(t, int32(0), counters.deleted.Load())
+	releaseConc()
+	releaseConc()
+	assert.Equal(t, int32(1), counters.deleted.Load())
+
+	// a concordance loaded from the cache is freed by the caller
+	_, releaseConc, err = p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=\"x\"]", counters.release)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), counters.opened.Load())
+	assert.Equal(t, int32(1), counters.deleted.Load())
+	releaseConc()
+	assert.Equal(t, int32(2), counters.deleted.Load())
+	assert.Equal(t, int32(2), counters.released.Load())
+}
+
+func TestConcProviderReleasesConcAfterSave(t *testing.T) {
+	p, counters := newTestProvider(t, nil, nil, nil)
+	unblockSave := make(chan struct{})
+	p.saveFn = func(conc *mango.GoConc, path string) error {
+		<-unblockSave
+		counters.saved.Add(1)
+		return os.WriteFile(path, []byte("conc"), 0644)
+	}
+	_, releaseConc, err := p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=\"x\"]", counters.release)
+	assert.NoError(t, err)
+	releaseConc()
+	// the save still uses the concordance
+	assert.Equal(t, int32(0), counters.deleted.Load())
+	close(unblockSave)
+	waitForFlights(p)
+	assert.Equal(t, int32(1), counters.saved.Load())
+	assert.Equal(t, int32(1), counters.deleted.Load())
+	assert.Equal(t, int32(1), counters.released.Load())
+}
+
+func TestConcProviderUsesCache(t *testing.T) {
+	p, counters := newTestProvider(t, nil, nil, nil)
+	_, _, err := p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=\"x\"]", counters.release)
+	assert.NoError(t, err)
+	waitForFlights(p)
+	_, _, err = p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=\"x\"]", counters.release)
+	assert.NoError(t, err)
+	_, _, err = p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=\"y\"]", counters.release)
+	assert.NoError(t, err)
+	waitForFlights(p)
+	assert.Equal(t, int32(2), counters.created.Load())
+	assert.Equal(t, int32(1), counters.opened.Load())
+	assert.Equal(t, int32(3), counters.released.Load())
+}
+
+func TestConcProviderPropagatesError(t *testing.T) {
+	unblock := make(chan struct{})
+	createErr := errors.New("syntax error")
+	p, counters := newTestProvider(t, createErr, nil, unblock)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=", counters.release)
+			assert.ErrorIs(t, err, createErr)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(unblock)
+	wg.Wait()
+	assert.Equal(t, int32(1), counters.created.Load())
+	assert.Equal(t, int32(0), counters.saved.Load())
+	assert.Equal(t, int32(5), counters.released.Load())
+	assert.Empty(t, p.inFlight)
+
+	// the error is remembered as a negative entry
+	_, _, err := p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=", counters.release)
+	assert.ErrorIs(t, err, createErr)
+	assert.Equal(t, int32(1), counters.created.Load())
+	entry, ok := getTestEntry(p.cache, "syn2020", "[word=")
+	assert.True(t, ok)
+	assert.ErrorIs(t, entry.Err, createErr)
+}
+
+func TestConcProviderNegativeEntryExpires(t *testing.T) {
+	createErr := errors.New("syntax error")
+	p, counters := newTestProvider(t, createErr, nil, nil)
+	p.cache.negativeTTL = 20 * time.Millisecond
+	_, _, err := p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=", counters.release)
+	assert.ErrorIs(t, err, createErr)
+	_, _, err = p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=", counters.release)
+	assert.ErrorIs(t, err, createErr)
+	assert.Equal(t, int32(1), counters.created.Load())
+	time.Sleep(30 * time.Millisecond)
+	_, _, err = p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=", counters.release)
+	assert.ErrorIs(t, err, createErr)
+	assert.Equal(t, int32(2), counters.created.Load())
+}
+
+func TestConcProviderDiscardsPartialFile(t *testing.T) {
+	p, counters := newTestProvider(t, nil, nil, nil)
+	p.saveFn = func(conc *mango.GoConc, path string) error {
+		counters.saved.Add(1)
+		if err := os.WriteFile(path, []byte("partial"), 0644); err != nil {
+			return err
+		}
+		return errors.New("disk full")
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conc, _, err := p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=\"x\"]", counters.release)
+			assert.NoError(t, err)
+			assert.NotNil(t, conc)
+		}()
+	}
+	wg.Wait()
+	waitForFlights(p)
+	targetPath := p.cache.mkPath("syn2020", "[word=\"x\"]")
+	assert.NoFileExists(t, targetPath)
+	assert.NoFileExists(t, targetPath+tmpFileSuffix)
+	assert.NoFileExists(t, targetPath+metaFileSuffix)
+	assert.False(t, hasTestEntry(p.cache, "syn2020", "[word=\"x\"]"))
+	assert.Equal(t, int32(0), counters.opened.Load())
+	assert.Equal(t, counters.created.Load(), counters.saved.Load())
+	assert.Equal(t, int32(10), counters.released.Load())
+}
+
+func TestConcProviderConcurrentMixedQueries(t *testing.T) {
+	p, counters := newTestProvider(t, nil, nil, nil)
+	queryErr := errors.New("syntax error")
+	p.createFn = func(corpus *mango.GoCorpus, query string) (*mango.GoConc, error) {
+		counters.created.Add(1)
+		time.Sleep(time.Millisecond)
+		if query == "bad" {
+			return nil, queryErr
+		}
+		return &mango.GoConc{}, nil
+	}
+	queries := []string{"q1", "q2", "q3", "bad"}
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(q string) {
+			defer wg.Done()
+			conc, _, err := p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", q, counters.release)
+			if q == "bad" {
+				assert.ErrorIs(t, err, queryErr)
+				assert.Nil(t, conc)
+
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, conc)
+			}
+		}(queries[i%len(queries)])
+	}
+	wg.Wait()
+	waitForFlights(p)
+	// each query is calculated exactly once
+	assert.Equal(t, int32(len(queries)), counters.created.Load())
+	assert.Equal(t, int32(len(queries)-1), counters.saved.Load())
+	assert.Equal(t, int32(200), counters.released.Load())
+	for _, q := range queries[:3] {
+		entry, ok := getTestEntry(p.cache, "syn2020", q)
+		assert.True(t, ok)
+		assert.NoError(t, entry.Err)
+		assert.FileExists(t, entry.FilePath)
+		assert.FileExists(t, entry.FilePath+metaFileSuffix)
+	}
+	entry, ok := getTestEntry(p.cache, "syn2020", "bad")
+	assert.True(t, ok)
+	assert.ErrorIs(t, entry.Err, queryErr)
+}
+
+func TestConcProviderRecalculatesUnsavedConc(t *testing.T) {
+	p, counters := newTestProvider(t, nil, errors.New("disk full"), nil)
+	_, _, err := p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=\"x\"]", counters.release)
+	assert.NoError(t, err)
+	waitForFlights(p)
+	_, _, err = p.acquire(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=\"x\"]", counters.release)
+	assert.NoError(t, err)
+	waitForFlights(p)
+	assert.Equal(t, int32(2), counters.created.Load())
+	assert.Equal(t, int32(0), counters.opened.Load())
+}
+
+func TestConcProviderRecalculatesAfterDataChange(t *testing.T) {
+	p, counters := newTestProvider(t, nil, nil, nil)
+	mtime1 := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	_, _, err := p.acquire(&mango.GoCorpus{}, mtime1, "syn2020", "[word=\"x\"]", counters.release)
+	assert.NoError(t, err)
+	waitForFlights(p)
+	_, _, err = p.acquire(&mango.GoCorpus{}, mtime1, "syn2020", "[word=\"x\"]", counters.release)
+	assert.NoError(t, err)
+	_, _, err = p.acquire(
+		&mango.GoCorpus{}, mtime1.Add(time.Hour), "syn2020", "[word=\"x\"]", counters.release)
+	assert.NoError(t, err)
+	waitForFlights(p)
+	assert.Equal(t, int32(2), counters.created.Load())
+	assert.Equal(t, int32(1), counters.opened.Load())
+}
+
+func TestConcProviderPrewarm(t *testing.T) {
+	p, counters := newTestProvider(t, nil, nil, nil)
+	entry, cached, err := p.prewarm(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=\"x\"]", counters.release)
+	assert.NoError(t, err)
+	assert.False(t, cached)
+	assert.FileExists(t, entry.FilePath)
+	// the calculated concordance is freed once saved
+	assert.Equal(t, int32(1), counters.saved.Load())
+	assert.Equal(t, int32(1), counters.deleted.Load())
+	assert.Empty(t, p.inFlight)
+
+	// cached concordances are not opened at all
+	entry2, cached, err := p.prewarm(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=\"x\"]", counters.release)
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, entry.FilePath, entry2.FilePath)
+	assert.Equal(t, int64(1), entry2.Hits)
+	assert.Equal(t, int32(1), counters.created.Load())
+	assert.Equal(t, int32(0), counters.opened.Load())
+	assert.Equal(t, int32(1), counters.deleted.Load())
+	assert.Equal(t, int32(2), counters.released.Load())
+}
+
+func TestConcProviderPrewarmFailures(t *testing.T) {
+	createErr := errors.New("syntax error")
+	p, counters := newTestProvider(t, createErr, nil, nil)
+	_, cached, err := p.prewarm(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=", counters.release)
+	assert.ErrorIs(t, err, createErr)
+	assert.False(t, cached)
+	// negative entries are reported as cached failures
+	_, cached, err = p.prewarm(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=", counters.release)
+	assert.ErrorIs(t, err, createErr)
+	assert.True(t, cached)
+	assert.Equal(t, int32(1), counters.created.Load())
+
+	p, counters = newTestProvider(t, nil, errors.New("disk full"), nil)
+	_, _, err = p.prewarm(&mango.GoCorpus{}, time.Time{}, "syn2020", "[word=\"x\"]", counters.release)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), counters.deleted.Load())
+	assert.Equal(t, int32(1), counters.released.Load())
+}
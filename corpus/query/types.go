@@ -19,8 +19,18 @@
 package query
 
 type FreqDistribItem struct {
-	Word string  `json:"word"`
-	Freq int64   `json:"freq"`
-	Norm int64   `json:"norm"`
-	IPM  float32 `json:"ipm"`
+	Word   string   `json:"word"`
+	Values []string `json:"values"`
+	Freq   int64    `json:"freq"`
+	Norm   int64    `json:"norm"`
+	IPM    float32  `json:"ipm"`
+}
+
+// CollItem is a collocation with its scores. For backward
+// compatibility, Value contains the score of the sorting function.
+type CollItem struct {
+	Word   string             `json:"Word"`
+	Value  float64            `json:"Value"`
+	Freq   int64              `json:"Freq"`
+	Scores map[string]float64 `json:"Scores"`
 }
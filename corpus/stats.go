@@ -0,0 +1,170 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"masm/v3/mango"
+	"os"
+	"strings"
+)
+
+// AttrStats contains information about a positional attribute
+type AttrStats struct {
+	Name         string  `json:"name"`
+	LexiconSize  int64   `json:"lexiconSize"`
+	IndexSize    int64   `json:"indexSize"`
+	ManateeError *string `json:"manateeError,omitempty"`
+}
+
+// StructAttrStats contains information about a structural attribute
+type StructAttrStats struct {
+	Name         string  `json:"name"`
+	NumValues    int64   `json:"numValues"`
+	IndexSize    int64   `json:"indexSize"`
+	ManateeError *string `json:"manateeError,omitempty"`
+}
+
+// StructStats contains information about a structure
+// and its attributes
+type StructStats struct {
+	Name         string            `json:"name"`
+	NumInstances int64             `json:"numInstances"`
+	IndexSize    int64             `json:"indexSize"`
+	Attrs        []StructAttrStats `json:"attrs"`
+	ManateeError *string           `json:"manateeError,omitempty"`
+}
+
+// indexFiles maps names of files in a corpus data directory
+// to their sizes
+type indexFiles map[string]int64
+
+// sizeOf returns total size of files belonging to an attribute
+// or a structure (i.e. files named [name].*). Files of structural
+// attributes can be excluded by providing their names.
+func (f indexFiles) sizeOf(name string, excluded ...string) int64 {
+	var ans int64
+	for file, size := range f {
+		if !strings.HasPrefix(file, name+".") {
+			continue
+		}
+		isExcluded := false
+		for _, ex := range excluded {
+			if strings.HasPrefix(file, ex+".") {
+				isExcluded = true
+				break
+			}
+		}
+		if !isExcluded {
+			ans += size
+		}
+	}
+	return ans
+}
+
+func loadIndexFiles(dataDirPath string) (indexFiles, error) {
+	entries, err := os.ReadDir(dataDirPath)
+	if err != nil {
+		return nil, err
+	}
+	ans := make(indexFiles)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		ans[entry.Name()] = info.Size()
+	}
+	return ans, nil
+}
+
+func splitConfList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
+
+func errPtr(err error) *string {
+	if err == nil {
+		return nil
+	}
+	ans := err.Error()
+	return &ans
+}
+
+// getAttrsStats obtains information about positional attributes
+// and structures listed in corpus configuration. Manatee errors
+// related to individual items are attached to the items.
+func getAttrsStats(corpus *mango.GoCorpus, dataDirPath string) ([]AttrStats, []StructStats, error) {
+	files, err := loadIndexFiles(dataDirPath)
+	if err != nil {
+		return nil, nil, InfoError{err}
+	}
+	attrList, err := mango.GetCorpusConf(corpus, "ATTRLIST")
+	if err != nil {
+		return nil, nil, InfoError{err}
+	}
+	attrs := make([]AttrStats, 0, 20)
+	for _, name := range splitConfList(attrList) {
+		item := AttrStats{Name: name, IndexSize: files.sizeOf(name)}
+		item.LexiconSize, err = mango.GetAttrSize(corpus, name)
+		item.ManateeError = errPtr(err)
+		attrs = append(attrs, item)
+	}
+
+	structList, err := mango.GetCorpusConf(corpus, "STRUCTLIST")
+	if err != nil {
+		return nil, nil, InfoError{err}
+	}
+	structAttrList, err := mango.GetCorpusConf(corpus, "STRUCTATTRLIST")
+	if err != nil {
+		return nil, nil, InfoError{err}
+	}
+	structAttrs := splitConfList(structAttrList)
+	structs := make([]StructStats, 0, 10)
+	for _, name := range splitConfList(structList) {
+		ownAttrs := make([]string, 0, 10)
+		for _, fullName := range structAttrs {
+			if strings.HasPrefix(fullName, name+".") {
+				ownAttrs = append(ownAttrs, fullName)
+			}
+		}
+		item := StructStats{
+			Name:      name,
+			IndexSize: files.sizeOf(name, ownAttrs...),
+			Attrs:     make([]StructAttrStats, 0, len(ownAttrs)),
+		}
+		item.NumInstances, err = mango.GetStructSize(corpus, name)
+		item.ManateeError = errPtr(err)
+		for _, fullName := range ownAttrs {
+			attr := StructAttrStats{
+				Name:      strings.TrimPrefix(fullName, name+"."),
+				IndexSize: files.sizeOf(fullName),
+			}
+			attr.NumValues, err = mango.GetAttrSize(corpus, fullName)
+			attr.ManateeError = errPtr(err)
+			item.Attrs = append(item.Attrs, attr)
+		}
+		structs = append(structs, item)
+	}
+	return attrs, structs, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexFilesSizeOf(t *testing.T) {
+	files := indexFiles{
+		"word.lex":        10,
+		"word.lex.idx":    5,
+		"word.rev":        100,
+		"lemma.lex":       20,
+		"lemma_lc.lex":    7,
+		"lemma_lc.rev":    8,
+		"doc.rng":         30,
+		"doc.id.lex":      3,
+		"doc.id.lex.idx":  1,
+		"doc.title.lex":   40,
+		"docx.rng":        1000,
+		"s.rng":           50,
+		"sentences.rng":   2000,
+		"word":            999,
+		"unrelated.files": 1,
+	}
+	assert.Equal(t, int64(115), files.sizeOf("word"))
+	// attributes sharing a prefix are not mixed
+	assert.Equal(t, int64(20), files.sizeOf("lemma"))
+	assert.Equal(t, int64(15), files.sizeOf("lemma_lc"))
+	assert.Equal(t, int64(50), files.sizeOf("s"))
+
+	// structural attributes are excluded only if provided
+	assert.Equal(t, int64(74), files.sizeOf("doc"))
+	assert.Equal(t, int64(70), files.sizeOf("doc", "doc.id"))
+	assert.Equal(t, int64(30), files.sizeOf("doc", "doc.id", "doc.title"))
+	assert.Equal(t, int64(4), files.sizeOf("doc.id"))
+	assert.Equal(t, int64(0), files.sizeOf("missing"))
+}
+
+func TestLoadIndexFiles(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "word.lex"), make([]byte, 12), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "doc.rng"), make([]byte, 3), 0644))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "word.subdir"), 0755))
+	files, err := loadIndexFiles(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, indexFiles{"word.lex": 12, "doc.rng": 3}, files)
+
+	_, err = loadIndexFiles(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestSplitConfList(t *testing.T) {
+	assert.Equal(t, []string{}, splitConfList(""))
+	assert.Equal(t, []string{"word", "lemma", "tag"}, splitConfList("word,lemma,tag"))
+}
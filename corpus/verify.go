@@ -0,0 +1,301 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"masm/v3/jobs"
+	"masm/v3/mango"
+)
+
+const (
+	DataVerifyJobType = "dataVerify"
+
+	VerifyStatusOK      VerifyStatus = "ok"
+	VerifyStatusFailed  VerifyStatus = "failed"
+	VerifyStatusSkipped VerifyStatus = "skipped"
+
+	VerifyCheckAttrFiles     = "attrFiles"
+	VerifyCheckStructFiles   = "structFiles"
+	VerifyCheckAttrSize      = "attrSize"
+	VerifyCheckLimitedSubset = "limitedSubset"
+	VerifyCheckVerticalMtime = "verticalMtime"
+)
+
+var (
+	requiredAttrFileSuffixes   = []string{".lex", ".lex.idx", ".rev", ".text"}
+	requiredStructFileSuffixes = []string{".rng"}
+)
+
+type VerifyStatus string
+
+// VerifyCheck is a result of a single integrity check
+type VerifyCheck struct {
+	Check   string       `json:"check"`
+	Item    string       `json:"item,omitempty"`
+	Status  VerifyStatus `json:"status"`
+	Message string       `json:"message,omitempty"`
+}
+
+// VerifyReport is a result of a corpus data integrity check
+type VerifyReport struct {
+	CorpusID  string        `json:"corpusId"`
+	DataPath  string        `json:"dataPath"`
+	Size      int64         `json:"size"`
+	OK        bool          `json:"ok"`
+	NumFailed int           `json:"numFailed"`
+	Checks    []VerifyCheck `json:"checks"`
+}
+
+func (r *VerifyReport) add(check, item string, status VerifyStatus, msg string, args ...any) {
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	r.Checks = append(r.Checks, VerifyCheck{Check: check, Item: item, Status: status, Message: msg})
+	if status == VerifyStatusFailed {
+		r.NumFailed++
+	}
+}
+
+// DataVerification checks consistency of corpus data directory.
+// The verification owns an opened corpus which is closed once
+// Run finishes (or by calling Close in case Run is not called).
+type DataVerification struct {
+	corpusID string
+	setup    *CorporaSetup
+	corpus   *mango.GoCorpus
+}
+
+func (dv *DataVerification) Close() {
+	mango.CloseCorpus(dv.corpus)
+}
+
+// checkFiles tests existence of index files. It returns mtime
+// of the oldest found file.
+func (dv *DataVerification) checkFiles(
+	report *VerifyReport,
+	check, name string,
+	suffixes []string,
+	oldest time.Time,
+) time.Time {
+	missing := make([]string, 0, len(suffixes))
+	for _, suff := range suffixes {
+		st, err := os.Stat(filepath.Join(report.DataPath, name+suff))
+		if err != nil || !st.Mode().IsRegular() {
+			missing = append(missing, name+suff)
+			continue
+		}
+		if oldest.IsZero() || st.ModTime().Before(oldest) {
+			oldest = st.ModTime()
+		}
+	}
+	if len(missing) > 0 {
+		report.add(check, name, VerifyStatusFailed, "missing files: %v", missing)
+
+	} else {
+		report.add(check, name, VerifyStatusOK, "")
+	}
+	return oldest
+}
+
+func (dv *DataVerification) checkAttrSize(report *VerifyReport, attr string) {
+	size, err := mango.GetAttrTextSize(dv.corpus, attr)
+	if err != nil {
+		report.add(VerifyCheckAttrSize, attr, VerifyStatusFailed, "failed to get text size: %s", err)
+
+	} else if size != report.Size {
+		report.add(
+			VerifyCheckAttrSize, attr, VerifyStatusFailed,
+			"text size %d does not match corpus size %d", size, report.Size)
+
+	} else {
+		report.add(VerifyCheckAttrSize, attr, VerifyStatusOK, "")
+	}
+}
+
+func missingItems(items, inItems []string) []string {
+	idx := make(map[string]bool)
+	for _, v := range inItems {
+		idx[v] = true
+	}
+	ans := make([]string, 0, len(items))
+	for _, v := range items {
+		if !idx[v] {
+			ans = append(ans, v)
+		}
+	}
+	return ans
+}
+
+// checkLimitedVariant tests whether the limited variant of the corpus
+// (if any) is a subset of the primary one (i.e. it is not larger and
+// it does not contain other attributes and structures)
+func (dv *DataVerification) checkLimitedVariant(report *VerifyReport, attrs, structs []string) {
+	if strings.Contains(dv.corpusID, "/") {
+		report.add(VerifyCheckLimitedSubset, "", VerifyStatusSkipped, "not applicable to corpora in sub-directories")
+		return
+	}
+	regPath := dv.setup.GetFirstValidRegistry(dv.corpusID, CorpusVariantLimited.SubDir())
+	if regPath == "" {
+		report.add(VerifyCheckLimitedSubset, "", VerifyStatusSkipped, "no limited variant found")
+		return
+	}
+	limited, err := mango.OpenCorpus(regPath)
+	if err != nil {
+		report.add(VerifyCheckLimitedSubset, "", VerifyStatusFailed, "failed to open limited variant: %s", err)
+		return
+	}
+	defer mango.CloseCorpus(limited)
+	problems := make([]string, 0, 3)
+	size, err := mango.GetCorpusSize(limited)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("failed to get size: %s", err))
+
+	} else if size > report.Size {
+		problems = append(problems, fmt.Sprintf("size %d exceeds primary size %d", size, report.Size))
+	}
+	for _, prop := range []struct {
+		name    string
+		primary []string
+	}{{"ATTRLIST", attrs}, {"STRUCTLIST", structs}} {
+		v, err := mango.GetCorpusConf(limited, prop.name)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("failed to get %s: %s", prop.name, err))
+			continue
+		}
+		if extra := missingItems(splitConfList(v), prop.primary); len(extra) > 0 {
+			problems = append(problems, fmt.Sprintf("%s items not in primary: %v", prop.name, extra))
+		}
+	}
+	if len(problems) > 0 {
+		report.add(VerifyCheckLimitedSubset, regPath, VerifyStatusFailed, "%v", problems)
+
+	} else {
+		report.add(VerifyCheckLimitedSubset, regPath, VerifyStatusOK, "")
+	}
+}
+
+func (dv *DataVerification) checkVertical(report *VerifyReport, indexMtime time.Time) {
+	vertical, err := mango.GetCorpusConf(dv.corpus, "VERTICAL")
+	if err != nil || vertical == "" {
+		report.add(VerifyCheckVerticalMtime, "", VerifyStatusSkipped, "no vertical configured")
+		return
+	}
+	checkVerticalMtime(report, vertical, indexMtime)
+}
+
+// checkVerticalMtime tests whether the vertical file has not been
+// modified after the oldest index file has been created
+func checkVerticalMtime(report *VerifyReport, vertical string, indexMtime time.Time) {
+	st, err := os.Stat(vertical)
+	if err != nil {
+		report.add(VerifyCheckVerticalMtime, vertical, VerifyStatusSkipped, "vertical not available")
+		return
+	}
+	if indexMtime.IsZero() {
+		report.add(VerifyCheckVerticalMtime, vertical, VerifyStatusSkipped, "no index files found")
+
+	} else if st.ModTime().After(indexMtime) {
+		report.add(
+			VerifyCheckVerticalMtime, vertical, VerifyStatusFailed,
+			"vertical (%s) is newer than index (%s)",
+			st.ModTime().Format(fileMtimeFormat), indexMtime.Format(fileMtimeFormat))
+
+	} else {
+		report.add(VerifyCheckVerticalMtime, vertical, VerifyStatusOK, "")
+	}
+}
+
+// Run performs all the checks and returns a *VerifyReport
+func (dv *DataVerification) Run(ctx context.Context, job *jobs.Job) (any, error) {
+	defer dv.Close()
+	report := &VerifyReport{CorpusID: dv.corpusID, Checks: make([]VerifyCheck, 0, 30)}
+	dataPath, err := mango.GetCorpusConf(dv.corpus, "PATH")
+	if err != nil {
+		return nil, CorpusError{err}
+	}
+	report.DataPath = filepath.Clean(dataPath)
+	report.Size, err = mango.GetCorpusSize(dv.corpus)
+	if err != nil {
+		return nil, CorpusError{err}
+	}
+	var attrs, structs []string
+	for _, prop := range []struct {
+		name   string
+		target *[]string
+	}{{"ATTRLIST", &attrs}, {"STRUCTLIST", &structs}} {
+		v, err := mango.GetCorpusConf(dv.corpus, prop.name)
+		if err != nil {
+			return nil, CorpusError{err}
+		}
+		*prop.target = splitConfList(v)
+	}
+
+	total := int64(len(attrs) + len(structs) + 2)
+	var done int64
+	var indexMtime time.Time
+	for _, attr := range attrs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		job.SetProgress(done, total, fmt.Sprintf("checking attribute %s", attr))
+		if dyn, _ := mango.GetCorpusConf(dv.corpus, attr+".DYNAMIC"); dyn != "" {
+			report.add(VerifyCheckAttrFiles, attr, VerifyStatusSkipped, "dynamic attribute")
+
+		} else {
+			indexMtime = dv.checkFiles(
+				report, VerifyCheckAttrFiles, attr, requiredAttrFileSuffixes, indexMtime)
+		}
+		dv.checkAttrSize(report, attr)
+		done++
+	}
+	for _, strct := range structs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		job.SetProgress(done, total, fmt.Sprintf("checking structure %s", strct))
+		indexMtime = dv.checkFiles(
+			report, VerifyCheckStructFiles, strct, requiredStructFileSuffixes, indexMtime)
+		done++
+	}
+	job.SetProgress(done, total, "checking limited variant")
+	dv.checkLimitedVariant(report, attrs, structs)
+	done++
+	job.SetProgress(done, total, "checking vertical")
+	dv.checkVertical(report, indexMtime)
+	done++
+	job.SetProgress(done, total, "done")
+	report.OK = report.NumFailed == 0
+	return report, nil
+}
+
+// NewDataVerification opens a corpus and prepares its verification
+func NewDataVerification(corpusID string, setup *CorporaSetup) (*DataVerification, error) {
+	corp, err := OpenCorpus(corpusID, setup)
+	if err != nil {
+		return nil, err
+	}
+	return &DataVerification{corpusID: corpusID, setup: setup, corpus: corp}, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissingItems(t *testing.T) {
+	assert.Equal(t, []string{}, missingItems([]string{}, []string{"word"}))
+	assert.Equal(t, []string{}, missingItems([]string{"word", "lemma"}, []string{"lemma", "word", "tag"}))
+	assert.Equal(t, []string{"tag"}, missingItems([]string{"word", "tag"}, []string{"word", "lemma"}))
+	assert.Equal(t, []string{"doc", "p"}, missingItems([]string{"doc", "p"}, nil))
+}
+
+func TestCheckFiles(t *testing.T) {
+	root := t.TempDir()
+	older := time.Now().Add(-48 * time.Hour)
+	newer := time.Now().Add(-24 * time.Hour)
+	writeTestFile(t, filepath.Join(root, "word.lex"), "x", newer)
+	writeTestFile(t, filepath.Join(root, "word.lex.idx"), "x", older)
+	writeTestFile(t, filepath.Join(root, "word.rev"), "x", newer)
+	writeTestFile(t, filepath.Join(root, "doc.rng"), "x", newer)
+
+	report := &VerifyReport{DataPath: root}
+	dv := &DataVerification{}
+	oldest := dv.checkFiles(report, VerifyCheckAttrFiles, "word", requiredAttrFileSuffixes, time.Time{})
+	assert.True(t, oldest.Equal(older))
+	oldest = dv.checkFiles(report, VerifyCheckStructFiles, "doc", requiredStructFileSuffixes, oldest)
+	assert.True(t, oldest.Equal(older))
+	assert.Equal(t, 1, report.NumFailed)
+	assert.Equal(t, VerifyStatusFailed, report.Checks[0].Status)
+	assert.Contains(t, report.Checks[0].Message, "word.text")
+	assert.Equal(t, VerifyStatusOK, report.Checks[1].Status)
+}
+
+func TestCheckVerticalMtime(t *testing.T) {
+	root := t.TempDir()
+	vertical := filepath.Join(root, "susanne.vert")
+	indexMtime := time.Now().Add(-24 * time.Hour)
+
+	writeTestFile(t, vertical, "<doc>\n</doc>\n", indexMtime.Add(-time.Hour))
+	report := &VerifyReport{}
+	checkVerticalMtime(report, vertical, indexMtime)
+	assert.Equal(t, VerifyStatusOK, report.Checks[0].Status)
+
+	writeTestFile(t, vertical, "<doc>\n</doc>\n", indexMtime.Add(time.Hour))
+	checkVerticalMtime(report, vertical, indexMtime)
+	assert.Equal(t, VerifyStatusFailed, report.Checks[1].Status)
+	assert.Contains(t, report.Checks[1].Message, "is newer than index")
+
+	checkVerticalMtime(report, vertical, time.Time{})
+	assert.Equal(t, VerifyStatusSkipped, report.Checks[2].Status)
+
+	checkVerticalMtime(report, filepath.Join(root, "missing.vert"), indexMtime)
+	assert.Equal(t, VerifyStatusSkipped, report.Checks[3].Status)
+
+	assert.Equal(t, 1, report.NumFailed)
+	assert.Equal(t, VerifyCheckVerticalMtime, report.Checks[0].Check)
+	assert.Equal(t, vertical, report.Checks[0].Item)
+}
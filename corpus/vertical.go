@@ -0,0 +1,410 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"masm/v3/jobs"
+	"masm/v3/mango"
+)
+
+const (
+	VerticalStatsJobType = "verticalStats"
+
+	// maxReportedVerticalProblems limits the number of problems
+	// listed in the report (all the problems are still counted)
+	maxReportedVerticalProblems = 100
+
+	defaultSentenceStruct = "s"
+	defaultDocStruct      = "doc"
+)
+
+var (
+	ErrVerticalNotAvailable = errors.New("vertical file not available")
+
+	verticalTagRegexp     = regexp.MustCompile(`^<(/?)([a-zA-Z_][a-zA-Z0-9_.-]*)((?:\s+[a-zA-Z_][a-zA-Z0-9_.-]*="[^"]*")*)\s*(/?)>$`)
+	verticalTagAttrRegexp = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_.-]*)="`)
+)
+
+// VerticalProblem describes a malformed line of a vertical file
+type VerticalProblem struct {
+	Line    int64  `json:"line"`
+	Message string `json:"message"`
+}
+
+// VerticalStructStats contains information about a structure
+// found in a vertical file
+type VerticalStructStats struct {
+	Name       string   `json:"name"`
+	Count      int64    `json:"count"`
+	Attrs      []string `json:"attrs"`
+	InRegistry bool     `json:"inRegistry"`
+
+	// AttrsNotInRegistry lists attributes missing in STRUCTATTRLIST
+	AttrsNotInRegistry []string `json:"attrsNotInRegistry"`
+}
+
+// VerticalStats is a result of vertical file inspection
+type VerticalStats struct {
+	Path            string                `json:"path"`
+	NumLines        int64                 `json:"numLines"`
+	NumTokens       int64                 `json:"numTokens"`
+	NumSentences    int64                 `json:"numSentences"`
+	NumDocs         int64                 `json:"numDocs"`
+	ExpectedColumns []string              `json:"expectedColumns"`
+	Structures      []VerticalStructStats `json:"structures"`
+	NumProblems     int64                 `json:"numProblems"`
+	Problems        []VerticalProblem     `json:"problems"`
+
+	// Partial is set in case the parsing stopped
+	// after VerticalParserConf.MaxTokens tokens
+	Partial bool `json:"partial,omitempty"`
+
+	// TokenSample contains columns of the first
+	// VerticalParserConf.SampleTokens tokens
+	TokenSample [][]string `json:"-"`
+}
+
+// VerticalParserConf configures vertical file parsing
+type VerticalParserConf struct {
+	// Columns contains names of positional attributes
+	// stored in the vertical (in the order of columns)
+	Columns        []string
+	Structs        []string
+	StructAttrs    []string
+	SentenceStruct string
+	DocStruct      string
+
+	// MaxTokens stops the parsing after the specified
+	// number of tokens (0 means no limit)
+	MaxTokens int64
+
+	// SampleTokens specifies how many tokens (from the beginning)
+	// are stored in VerticalStats.TokenSample
+	SampleTokens int
+}
+
+type verticalParser struct {
+	conf    VerticalParserConf
+	ans     *VerticalStats
+	structs map[string]*VerticalStructStats
+	attrs   map[string]map[string]bool
+	open    []string
+	lineNum int64
+}
+
+func (vp *verticalParser) addProblem(msg string, args ...any) {
+	vp.ans.NumProblems++
+	if len(vp.ans.Problems) < maxReportedVerticalProblems {
+		vp.ans.Problems = append(
+			vp.ans.Problems,
+			VerticalProblem{Line: vp.lineNum, Message: fmt.Sprintf(msg, args...)},
+		)
+	}
+}
+
+func (vp *verticalParser) registerStruct(name string) *VerticalStructStats {
+	st, ok := vp.structs[name]
+	if !ok {
+		st = &VerticalStructStats{Name: name}
+		for _, s := range vp.conf.Structs {
+			if s == name {
+				st.InRegistry = true
+				break
+			}
+		}
+		vp.structs[name] = st
+		vp.attrs[name] = make(map[string]bool)
+	}
+	return st
+}
+
+func (vp *verticalParser) parseTag(line string) {
+	srch := verticalTagRegexp.FindStringSubmatch(line)
+	if srch == nil {
+		vp.addProblem("malformed tag %q", line)
+		return
+	}
+	isClosing, name, attrs, isEmpty := srch[1] == "/", srch[2], srch[3], srch[4] == "/"
+	if isClosing {
+		if attrs != "" || isEmpty {
+			vp.addProblem("malformed closing tag %q", line)
+			return
+		}
+		for i := len(vp.open) - 1; i >= 0; i-- {
+			if vp.open[i] == name {
+				for j := len(vp.open) - 1; j > i; j-- {
+					vp.addProblem("unclosed structure <%s> (closed by </%s>)", vp.open[j], name)
+				}
+				vp.open = vp.open[:i]
+				return
+			}
+		}
+		vp.addProblem("unexpected closing tag </%s>", name)
+		return
+	}
+	st := vp.registerStruct(name)
+	st.Count++
+	for _, m := range verticalTagAttrRegexp.FindAllStringSubmatch(attrs, -1) {
+		vp.attrs[name][m[1]] = true
+	}
+	switch name {
+	case vp.conf.SentenceStruct:
+		vp.ans.NumSentences++
+	case vp.conf.DocStruct:
+		vp.ans.NumDocs++
+	}
+	if !isEmpty {
+		vp.open = append(vp.open, name)
+	}
+}
+
+func (vp *verticalParser) parseToken(line string) {
+	vp.ans.NumTokens++
+	if len(vp.ans.TokenSample) < vp.conf.SampleTokens {
+		vp.ans.TokenSample = append(vp.ans.TokenSample, strings.Split(line, "\t"))
+	}
+	numCols := strings.Count(line, "\t") + 1
+	if len(vp.conf.Columns) > 0 && numCols != len(vp.conf.Columns) {
+		vp.addProblem("expected %d columns, found %d", len(vp.conf.Columns), numCols)
+	}
+}
+
+func (vp *verticalParser) finish() *VerticalStats {
+	// in case of a partial parsing, the structures may be closed later
+	for i := len(vp.open) - 1; i >= 0 && !vp.ans.Partial; i-- {
+		vp.addProblem("unclosed structure <%s>", vp.open[i])
+	}
+	structAttrs := make(map[string]bool)
+	for _, sa := range vp.conf.StructAttrs {
+		structAttrs[sa] = true
+	}
+	for name, st := range vp.structs {
+		st.Attrs = make([]string, 0, len(vp.attrs[name]))
+		st.AttrsNotInRegistry = make([]string, 0, 5)
+		for attr := range vp.attrs[name] {
+			st.Attrs = append(st.Attrs, attr)
+			if !structAttrs[name+"."+attr] {
+				st.AttrsNotInRegistry = append(st.AttrsNotInRegistry, attr)
+			}
+		}
+		sort.Strings(st.Attrs)
+		sort.Strings(st.AttrsNotInRegistry)
+		vp.ans.Structures = append(vp.ans.Structures, *st)
+	}
+	sort.Slice(vp.ans.Structures, func(i, j int) bool {
+		return vp.ans.Structures[i].Name < vp.ans.Structures[j].Name
+	})
+	return vp.ans
+}
+
+// ParseVertical reads a vertical file and collects information about
+// its contents. Malformed lines are reported as problems of the result.
+// The function returns an error only in case the data cannot be read.
+func ParseVertical(ctx context.Context, r io.Reader, conf VerticalParserConf) (*VerticalStats, error) {
+	vp := &verticalParser{
+		conf: conf,
+		ans: &VerticalStats{
+			ExpectedColumns: conf.Columns,
+			Structures:      make([]VerticalStructStats, 0, 10),
+			Problems:        make([]VerticalProblem, 0, 10),
+		},
+		structs: make(map[string]*VerticalStructStats),
+		attrs:   make(map[string]map[string]bool),
+		open:    make([]string, 0, 5),
+	}
+	reader := bufio.NewReaderSize(r, 1024*1024)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			vp.lineNum++
+			if vp.lineNum%100000 == 0 && ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch {
+			case line == "":
+			case strings.HasPrefix(line, "<"):
+				vp.parseTag(line)
+			default:
+				vp.parseToken(line)
+			}
+			if conf.MaxTokens > 0 && vp.ans.NumTokens >= conf.MaxTokens {
+				vp.ans.Partial = true
+				break
+			}
+		}
+		if err == io.EOF {
+			break
+
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	vp.ans.NumLines = vp.lineNum
+	return vp.finish(), nil
+}
+
+// progressReader reports number of bytes read from
+// the underlying reader
+type progressReader struct {
+	r      io.Reader
+	read   int64
+	onRead func(read int64)
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	prev := pr.read
+	pr.read += int64(n)
+	if pr.read/(10*1024*1024) != prev/(10*1024*1024) {
+		pr.onRead(pr.read)
+	}
+	return n, err
+}
+
+// NewVerticalReader wraps a reader of vertical data. In case the data
+// are gzip-compressed (detected by the magic bytes), the returned reader
+// decompresses them. Closing the returned reader does not close r.
+func NewVerticalReader(r io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip data: %w", err)
+		}
+		return gz, nil
+	}
+	return io.NopCloser(buffered), nil
+}
+
+// VerticalInspection reads a corpus vertical file and
+// collects information about it
+type VerticalInspection struct {
+	path string
+	conf VerticalParserConf
+}
+
+// Run reads the vertical file (plain or gzip-compressed) and
+// returns *VerticalStats
+func (vi *VerticalInspection) Run(ctx context.Context, job *jobs.Job) (any, error) {
+	f, err := os.Open(vi.path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrVerticalNotAvailable, err)
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	total := st.Size()
+	job.SetProgress(0, total, "reading vertical")
+	var src io.Reader = &progressReader{
+		r: f,
+		onRead: func(read int64) {
+			job.SetProgress(read, total, "reading vertical")
+		},
+	}
+	vert, err := NewVerticalReader(src)
+	if err != nil {
+		return nil, err
+	}
+	defer vert.Close()
+	ans, err := ParseVertical(ctx, vert, vi.conf)
+	if err != nil {
+		return nil, err
+	}
+	ans.Path = vi.path
+	job.SetProgress(total, total, "done")
+	return ans, nil
+}
+
+// NewVerticalInspection obtains vertical file path and configuration
+// (columns, structures) from the corpus registry. In case the sentence
+// or document structure names are empty, defaults are used
+// (`s` and DOCSTRUCTURE or `doc`).
+func NewVerticalInspection(
+	corpusID string,
+	setup *CorporaSetup,
+	sentenceStruct, docStruct string,
+) (*VerticalInspection, error) {
+	corp, err := OpenCorpus(corpusID, setup)
+	if err != nil {
+		return nil, err
+	}
+	defer mango.CloseCorpus(corp)
+	path, err := mango.GetCorpusConf(corp, "VERTICAL")
+	if err != nil {
+		return nil, CorpusError{err}
+	}
+	path = strings.TrimSpace(path)
+	if path == "" || strings.HasPrefix(path, "|") {
+		return nil, fmt.Errorf("%w: vertical must be a file (found %q)", ErrVerticalNotAvailable, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrVerticalNotAvailable, err)
+	}
+	conf := VerticalParserConf{SentenceStruct: sentenceStruct, DocStruct: docStruct}
+	if conf.SentenceStruct == "" {
+		conf.SentenceStruct = defaultSentenceStruct
+	}
+	if conf.DocStruct == "" {
+		conf.DocStruct, _ = mango.GetCorpusConf(corp, "DOCSTRUCTURE")
+		if conf.DocStruct == "" {
+			conf.DocStruct = defaultDocStruct
+		}
+	}
+	attrList, err := mango.GetCorpusConf(corp, "ATTRLIST")
+	if err != nil {
+		return nil, CorpusError{err}
+	}
+	conf.Columns = make([]string, 0, 10)
+	for _, attr := range splitConfList(attrList) {
+		// dynamic attributes are not stored in the vertical
+		if dyn, _ := mango.GetCorpusConf(corp, attr+".DYNAMIC"); dyn == "" {
+			conf.Columns = append(conf.Columns, attr)
+		}
+	}
+	structList, err := mango.GetCorpusConf(corp, "STRUCTLIST")
+	if err != nil {
+		return nil, CorpusError{err}
+	}
+	conf.Structs = splitConfList(structList)
+	structAttrList, err := mango.GetCorpusConf(corp, "STRUCTATTRLIST")
+	if err != nil {
+		return nil, CorpusError{err}
+	}
+	conf.StructAttrs = splitConfList(structAttrList)
+	return &VerticalInspection{path: path, conf: conf}, nil
+}
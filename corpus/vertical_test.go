@@ -0,0 +1,119 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package corpus
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testVertical = `<doc id="1" title="Test">
+<p>
+<s>
+Hello	hello	NN
+world	world	NN
+</s>
+<s>
+Bye	bye
+<g/>
+!	!	Z
+</s>
+</p>
+<p broken>
+</doc>
+<doc id="2">
+<s>
+Hi	hi	NN
+</doc>
+`
+
+func TestParseVertical(t *testing.T) {
+	stats, err := ParseVertical(
+		context.Background(),
+		strings.NewReader(testVertical),
+		VerticalParserConf{
+			Columns:        []string{"word", "lemma", "tag"},
+			Structs:        []string{"doc", "p", "s"},
+			StructAttrs:    []string{"doc.id"},
+			SentenceStruct: "s",
+			DocStruct:      "doc",
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(18), stats.NumLines)
+	assert.Equal(t, int64(5), stats.NumTokens)
+	assert.Equal(t, int64(3), stats.NumSentences)
+	assert.Equal(t, int64(2), stats.NumDocs)
+	assert.Len(t, stats.Structures, 4)
+	assert.Equal(t, "doc", stats.Structures[0].Name)
+	assert.Equal(t, []string{"id", "title"}, stats.Structures[0].Attrs)
+	assert.Equal(t, []string{"title"}, stats.Structures[0].AttrsNotInRegistry)
+	assert.Equal(t, "g", stats.Structures[1].Name)
+	assert.False(t, stats.Structures[1].InRegistry)
+	assert.Equal(t, int64(3), stats.NumProblems)
+	lines := make([]int64, len(stats.Problems))
+	for i, p := range stats.Problems {
+		lines[i] = p.Line
+	}
+	assert.Equal(t, []int64{8, 13, 18}, lines)
+	assert.Contains(t, stats.Problems[2].Message, "unclosed structure <s>")
+}
+
+func TestParseVerticalMaxTokens(t *testing.T) {
+	stats, err := ParseVertical(
+		context.Background(),
+		strings.NewReader(testVertical),
+		VerticalParserConf{MaxTokens: 2, SampleTokens: 1},
+	)
+	assert.NoError(t, err)
+	assert.True(t, stats.Partial)
+	assert.Equal(t, int64(2), stats.NumTokens)
+	assert.Equal(t, [][]string{{"Hello", "hello", "NN"}}, stats.TokenSample)
+	// open structures are not reported in case of a partial parsing
+	assert.Equal(t, int64(0), stats.NumProblems)
+}
+
+func TestNewVerticalReader(t *testing.T) {
+	var buff bytes.Buffer
+	gz := gzip.NewWriter(&buff)
+	_, err := gz.Write([]byte(testVertical))
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+
+	for _, src := range [][]byte{buff.Bytes(), []byte(testVertical)} {
+		r, err := NewVerticalReader(bytes.NewReader(src))
+		assert.NoError(t, err)
+		data, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.NoError(t, r.Close())
+		assert.Equal(t, testVertical, string(data))
+	}
+
+	r, err := NewVerticalReader(bytes.NewReader([]byte{}))
+	assert.NoError(t, err)
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// RemoteJobs represents an external source of jobs (Frodo)
+// which are available through the same API as local jobs
+type RemoteJobs interface {
+	Jobs(ctx *gin.Context)
+	ListJobs() ([]json.RawMessage, error)
+}
+
+type Actions struct {
+	manager *Manager
+	remote  RemoteJobs
+}
+
+// JobInfo returns information about a job. Jobs not known
+// locally are looked up in the remote job source.
+func (a *Actions) JobInfo(ctx *gin.Context) {
+	info, ok := a.manager.Get(ctx.Param("jobId"))
+	if !ok {
+		if a.remote != nil {
+			a.remote.Jobs(ctx)
+			return
+		}
+		uniresp.WriteJSONErrorResponse(
+			ctx.Writer,
+			uniresp.NewActionError("job not found"),
+			http.StatusNotFound,
+		)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, info)
+}
+
+// List lists both local and remote jobs. In case the remote
+// source is not available, only local jobs are returned.
+func (a *Actions) List(ctx *gin.Context) {
+	ans := make([]any, 0, 10)
+	for _, info := range a.manager.List() {
+		ans = append(ans, info)
+	}
+	if a.remote != nil {
+		remote, err := a.remote.ListJobs()
+		if err != nil {
+			log.Error().Err(err).Msg("failed to fetch remote jobs, listing only local ones")
+
+		} else {
+			for _, item := range remote {
+				ans = append(ans, item)
+			}
+		}
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
+func NewActions(manager *Manager, remote RemoteJobs) *Actions {
+	return &Actions{
+		manager: manager,
+		remote:  remote,
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// finishedJobTTL specifies how long we keep information
+	// about finished jobs
+	finishedJobTTL = 24 * time.Hour
+)
+
+var ErrJobRunning = errors.New("job already running")
+
+// Progress describes how much work a job has done. The units
+// of Done and Total depend on the job type (e.g. bytes, files).
+type Progress struct {
+	Done    int64  `json:"done"`
+	Total   int64  `json:"total"`
+	Message string `json:"message,omitempty"`
+}
+
+// JobInfo describes a state of a job
+type JobInfo struct {
+	ID       string    `json:"id"`
+	Type     string    `json:"type"`
+	CorpusID string    `json:"corpusId"`
+	Start    time.Time `json:"start"`
+	Update   time.Time `json:"update"`
+	Finished bool      `json:"finished"`
+	OK       bool      `json:"ok"`
+	Error    string    `json:"error,omitempty"`
+	Progress Progress  `json:"progress"`
+	Result   any       `json:"result,omitempty"`
+}
+
+// Job is a running (or finished) job. Job functions use it
+// to report their progress.
+type Job struct {
+	mu   sync.Mutex
+	info JobInfo
+}
+
+// SetProgress updates job's progress information
+func (j *Job) SetProgress(done, total int64, message string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.info.Progress = Progress{Done: done, Total: total, Message: message}
+	j.info.Update = time.Now()
+}
+
+// Info returns a snapshot of the job's state
+func (j *Job) Info() JobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.info
+}
+
+func (j *Job) finish(result any, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.info.Finished = true
+	j.info.Update = time.Now()
+	j.info.Result = result
+	if err != nil {
+		j.info.Error = err.Error()
+
+	} else {
+		j.info.OK = true
+	}
+}
+
+// Func is a function performing a job. The context is cancelled
+// once the server is shutting down.
+type Func func(ctx context.Context, job *Job) (any, error)
+
+// Manager runs jobs in the background and keeps information
+// about them (finished jobs are kept for finishedJobTTL).
+type Manager struct {
+	mu   sync.RWMutex
+	jobs map[string]*Job
+	ctx  context.Context
+}
+
+func (m *Manager) removeExpired() {
+	for id, job := range m.jobs {
+		info := job.Info()
+		if info.Finished && time.Since(info.Update) > finishedJobTTL {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// Start runs a new job in the background. Only one job of
+// a specific type can run for a corpus at a time. Jobs of
+// conflictingTypes (e.g. jobs working with the same files)
+// prevent the job from starting as well.
+func (m *Manager) Start(jobType, corpusID string, fn Func, conflictingTypes ...string) (JobInfo, error) {
+	m.mu.Lock()
+	m.removeExpired()
+	for _, job := range m.jobs {
+		info := job.Info()
+		if info.CorpusID != corpusID || info.Finished {
+			continue
+		}
+		if info.Type == jobType || slices.Contains(conflictingTypes, info.Type) {
+			m.mu.Unlock()
+			return info, fmt.Errorf("%w: %s for %s (%s)", ErrJobRunning, info.Type, corpusID, info.ID)
+		}
+	}
+	now := time.Now()
+	job := &Job{
+		info: JobInfo{
+			ID:       uuid.New().String(),
+			Type:     jobType,
+			CorpusID: corpusID,
+			Start:    now,
+			Update:   now,
+		},
+	}
+	m.jobs[job.info.ID] = job
+	m.mu.Unlock()
+
+	go func() {
+		log.Info().
+			Str("jobId", job.info.ID).
+			Str("type", jobType).
+			Str("corpusId", corpusID).
+			Msg("starting job")
+		result, err := fn(m.ctx, job)
+		job.finish(result, err)
+		if err != nil {
+			log.Error().Err(err).Str("jobId", job.info.ID).Msg("job failed")
+
+		} else {
+			log.Info().Str("jobId", job.info.ID).Msg("job finished")
+		}
+	}()
+	return job.Info(), nil
+}
+
+// Get returns information about a job
+func (m *Manager) Get(jobID string) (JobInfo, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	job, ok := m.jobs[jobID]
+	if !ok {
+		return JobInfo{}, false
+	}
+	return job.Info(), true
+}
+
+// List returns information about all the jobs
+// (the most recent first)
+func (m *Manager) List() []JobInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ans := make([]JobInfo, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		ans = append(ans, job.Info())
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		return ans[i].Start.After(ans[j].Start)
+	})
+	return ans
+}
+
+// NewManager creates a new job manager. The ctx is passed
+// to all the job functions.
+func NewManager(ctx context.Context) *Manager {
+	return &Manager{
+		jobs: make(map[string]*Job),
+		ctx:  ctx,
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagerRejectsParallelJobs(t *testing.T) {
+	m := NewManager(context.Background())
+	release := make(chan struct{})
+	fn := func(ctx context.Context, job *Job) (any, error) {
+		job.SetProgress(1, 2, "waiting")
+		<-release
+		return "done", nil
+	}
+	info, err := m.Start("test", "susanne", fn)
+	assert.NoError(t, err)
+	_, err = m.Start("test", "susanne", fn)
+	assert.ErrorIs(t, err, ErrJobRunning)
+	info2, err := m.Start("test", "syn2020", fn)
+	assert.NoError(t, err)
+	assert.Len(t, m.List(), 2)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		info, _ = m.Get(info.ID)
+		info2, _ = m.Get(info2.ID)
+		return info.Finished && info2.Finished
+	}, time.Second, 5*time.Millisecond)
+	assert.True(t, info.OK)
+	assert.Equal(t, "done", info.Result)
+	_, err = m.Start("test", "susanne", fn)
+	assert.NoError(t, err)
+}
+
+func TestManagerRejectsConflictingJobs(t *testing.T) {
+	m := NewManager(context.Background())
+	release := make(chan struct{})
+	fn := func(ctx context.Context, job *Job) (any, error) {
+		<-release
+		return nil, nil
+	}
+	info, err := m.Start("sync", "susanne", fn, "verify")
+	assert.NoError(t, err)
+	_, err = m.Start("verify", "susanne", fn, "sync")
+	assert.ErrorIs(t, err, ErrJobRunning)
+	_, err = m.Start("verify", "syn2020", fn, "sync")
+	assert.NoError(t, err)
+	_, err = m.Start("stats", "susanne", fn)
+	assert.NoError(t, err)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		info, _ = m.Get(info.ID)
+		return info.Finished
+	}, time.Second, 5*time.Millisecond)
+	_, err = m.Start("verify", "susanne", fn, "sync")
+	assert.NoError(t, err)
+}
@@ -334,3 +334,22 @@ func NewLiveAttrsActions(ctx context.Context, conf LAConf) (*LiveAttrsActions, e
 		httpClient:   client,
 	}, nil
 }
+
+// ListJobs fetches the list of jobs from Frodo. In case Frodo
+// does not respond with a list of jobs, an error is returned.
+func (la *LiveAttrsActions) ListJobs() ([]json.RawMessage, error) {
+	targetURL := la.frodoURL.JoinPath("jobs")
+	resp, err := la.httpClient.Get(targetURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Frodo jobs: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list Frodo jobs: status %d", resp.StatusCode)
+	}
+	var ans []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&ans); err != nil {
+		return nil, fmt.Errorf("failed to list Frodo jobs: %w", err)
+	}
+	return ans, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package mango
+
+import (
+	"errors"
+	"regexp"
+)
+
+var (
+	ErrQuerySyntax    = errors.New("query syntax error")
+	ErrCorpusNotFound = errors.New("corpus not found")
+	ErrAttrNotFound   = errors.New("attribute not found")
+	ErrIO             = errors.New("I/O error")
+	ErrManatee        = errors.New("Manatee error")
+
+	// manateeErrorPatterns maps Manatee error messages to typed errors.
+	// The order matters as the first matching pattern is used.
+	manateeErrorPatterns = []struct {
+		pattern *regexp.Regexp
+		kind    error
+	}{
+		{regexp.MustCompile(`CorpInfoNotFound`), ErrCorpusNotFound},
+		{regexp.MustCompile(`AttrNotFound|StructNotFound|[Uu]nknown (attribute|structure)`), ErrAttrNotFound},
+		{regexp.MustCompile(`(?i)syntax error|EvalQueryException|unexpected (character|token)|lexical error`), ErrQuerySyntax},
+		{regexp.MustCompile(`FileAccessError|(?i)no such file|cannot open|permission denied|input/output error`), ErrIO},
+	}
+)
+
+// ManateeError is an error reported by Manatee. The original
+// message is preserved while the error type (one of ErrQuerySyntax,
+// ErrCorpusNotFound, ErrAttrNotFound, ErrIO, ErrManatee) can be
+// tested via errors.Is.
+type ManateeError struct {
+	Kind    error
+	Message string
+}
+
+func (err *ManateeError) Error() string {
+	return err.Message
+}
+
+func (err *ManateeError) Unwrap() error {
+	return err.Kind
+}
+
+// newManateeError creates a typed error based on
+// a message returned by Manatee
+func newManateeError(msg string) error {
+	for _, p := range manateeErrorPatterns {
+		if p.pattern.MatchString(msg) {
+			return &ManateeError{Kind: p.kind, Message: msg}
+		}
+	}
+	return &ManateeError{Kind: ErrManatee, Message: msg}
+}
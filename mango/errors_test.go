@@ -0,0 +1,41 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package mango
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewManateeError(t *testing.T) {
+	for msg, kind := range map[string]error{
+		"CorpInfoNotFound (syn2020)":                    ErrCorpusNotFound,
+		"AttrNotFound (lemmax)":                         ErrAttrNotFound,
+		"Query syntax error at position 3: [word=\"x\"": ErrQuerySyntax,
+		"syntax error, unexpected $end, expecting ']'":  ErrQuerySyntax,
+		"FileAccessError (/data/syn2020/word.lex)":      ErrIO,
+		"something else went wrong":                     ErrManatee,
+	} {
+		err := newManateeError(msg)
+		assert.True(t, errors.Is(err, kind), msg)
+		assert.Equal(t, msg, err.Error())
+	}
+}
@@ -18,6 +18,7 @@
 
 
 #include "corp/corpus.hh"
+#include "corp/dynfun.hh"
 #include "concord/concord.hh"
 #include "concord/concord.hh"
 #include "concord/concstat.hh"
@@ -63,6 +64,42 @@ CorpusSizeRetrval get_corpus_size(CorpusV corpus) {
     return ans;
 }
 
+CorpusSizeRetrval get_attr_size(CorpusV corpus, const char* attrName) {
+    CorpusSizeRetrval ans;
+    ans.err = nullptr;
+    try {
+        ans.value = ((Corpus*)corpus)->get_attr(attrName)->id_range();
+
+    } catch (std::exception &e) {
+        ans.err = strdup(e.what());
+    }
+    return ans;
+}
+
+CorpusSizeRetrval get_struct_size(CorpusV corpus, const char* structName) {
+    CorpusSizeRetrval ans;
+    ans.err = nullptr;
+    try {
+        ans.value = ((Corpus*)corpus)->get_struct(structName)->size();
+
+    } catch (std::exception &e) {
+        ans.err = strdup(e.what());
+    }
+    return ans;
+}
+
+CorpusSizeRetrval get_attr_text_size(CorpusV corpus, const char* attrName) {
+    CorpusSizeRetrval ans;
+    ans.err = nullptr;
+    try {
+        ans.value = ((Corpus*)corpus)->get_attr(attrName)->size();
+
+    } catch (std::exception &e) {
+        ans.err = strdup(e.what());
+    }
+    return ans;
+}
+
 CorpusStringRetval get_corpus_conf(CorpusV corpus, const char* prop) {
     CorpusStringRetval ans;
     ans.err = nullptr;
@@ -78,6 +115,56 @@ CorpusStringRetval get_corpus_conf(CorpusV corpus, const char* prop) {
     }
 }
 
+AttrValuesRetval get_attr_values(CorpusV corpus, const char* attrName, PosInt maxItems) {
+    AttrValuesRetval ans;
+    ans.err = nullptr;
+    ans.values = nullptr;
+    try {
+        PosAttr* attr = ((Corpus*)corpus)->get_attr(attrName);
+        std::unique_ptr<vector<string>> values(new vector<string>);
+        PosInt size = attr->id_range();
+        for (PosInt i = 0; i < size && i < maxItems; i++) {
+            values->push_back(string(attr->id2str(i)));
+        }
+        ans.values = values.release();
+
+    } catch (std::exception &e) {
+        ans.err = strdup(e.what());
+    }
+    return ans;
+}
+
+
+DynFunRetval create_dynfun(const char* funtype, const char* lib, const char* fnName,
+                           const char* arg1, const char* arg2) {
+    DynFunRetval ans;
+    ans.err = nullptr;
+    ans.value = nullptr;
+    try {
+        ans.value = createDynFun(funtype, lib, fnName, arg1, arg2);
+
+    } catch (std::exception &e) {
+        ans.err = strdup(e.what());
+    }
+    return ans;
+}
+
+CorpusStringRetval apply_dynfun(DynFunV fn, const char* value) {
+    CorpusStringRetval ans;
+    ans.err = nullptr;
+    ans.value = nullptr;
+    try {
+        ans.value = (*((DynFun*)fn))(value);
+
+    } catch (std::exception &e) {
+        ans.err = strdup(e.what());
+    }
+    return ans;
+}
+
+void delete_dynfun(DynFunV fn) {
+    delete (DynFun*)fn;
+}
 
 ConcRetval create_concordance(CorpusV corpus, char* query) {
     string q(query);
@@ -126,6 +213,78 @@ ConcSaveRetval save_concordance(ConcV conc, const char* path) {
     return ans;
 }
 
+void delete_concordance(ConcV conc) {
+    delete (Concordance *)conc;
+}
+
+RangesRetval get_conc_ranges(ConcV conc, PosInt fromLine, PosInt toLine) {
+    RangesRetval ans;
+    ans.err = nullptr;
+    ans.begs = nullptr;
+    ans.ends = nullptr;
+    try {
+        Concordance* concObj = (Concordance*)conc;
+        std::unique_ptr<vector<PosInt>> begs(new vector<PosInt>);
+        std::unique_ptr<vector<PosInt>> ends(new vector<PosInt>);
+        for (PosInt i = fromLine; i < toLine && i < concObj->size(); i++) {
+            begs->push_back(concObj->beg_at(i));
+            ends->push_back(concObj->end_at(i));
+        }
+        ans.begs = begs.release();
+        ans.ends = ends.release();
+
+    } catch (std::exception &e) {
+        ans.err = strdup(e.what());
+    }
+    return ans;
+}
+
+AttrValuesRetval get_attr_values_at(CorpusV corpus, const char* attrName,
+                                    PosInt fromPos, PosInt toPos) {
+    AttrValuesRetval ans;
+    ans.err = nullptr;
+    ans.values = nullptr;
+    try {
+        PosAttr* attr = ((Corpus*)corpus)->get_attr(attrName);
+        std::unique_ptr<vector<string>> values(new vector<string>);
+        for (PosInt i = fromPos; i < toPos && i < attr->size(); i++) {
+            values->push_back(string(attr->pos2str(i)));
+        }
+        ans.values = values.release();
+
+    } catch (std::exception &e) {
+        ans.err = strdup(e.what());
+    }
+    return ans;
+}
+
+RangesRetval get_struct_ranges(CorpusV corpus, const char* structName,
+                               PosInt fromPos, PosInt toPos) {
+    RangesRetval ans;
+    ans.err = nullptr;
+    ans.begs = nullptr;
+    ans.ends = nullptr;
+    try {
+        ranges* rng = ((Corpus*)corpus)->get_struct(structName)->rng;
+        std::unique_ptr<vector<PosInt>> begs(new vector<PosInt>);
+        std::unique_ptr<vector<PosInt>> ends(new vector<PosInt>);
+        NumOfPos n = rng->num_at_pos(fromPos);
+        if (n < 0) {
+            n = rng->num_next_pos(fromPos);
+        }
+        for (; n >= 0 && n < rng->size() && rng->beg_at(n) < toPos; n++) {
+            begs->push_back(rng->beg_at(n));
+            ends->push_back(rng->end_at(n));
+        }
+        ans.begs = begs.release();
+        ans.ends = ends.release();
+
+    } catch (std::exception &e) {
+        ans.err = strdup(e.what());
+    }
+    return ans;
+}
+
 FreqsRetval freq_dist(CorpusV corpus, ConcV conc, char* fcrit, PosInt flimit) {
     Corpus* corpusObj = (Corpus*)corpus;
     Concordance* concObj = (Concordance *)conc;
@@ -137,12 +296,18 @@ FreqsRetval freq_dist(CorpusV corpus, ConcV conc, char* fcrit, PosInt flimit) {
     auto xnorms = new vector<PosInt>;
     vector<PosInt>& norms = *xnorms;
 
-    corpusObj->freq_dist (concObj->RS(), fcrit, flimit, words, freqs, norms);
     FreqsRetval ans {
         static_cast<void*>(xwords),
         static_cast<void*>(xfreqs),
-        static_cast<void*>(xnorms)
+        static_cast<void*>(xnorms),
+        nullptr
     };
+    try {
+        corpusObj->freq_dist (concObj->RS(), fcrit, flimit, words, freqs, norms);
+
+    } catch (std::exception &e) {
+        ans.err = strdup(e.what());
+    }
     return ans;
 }
 
@@ -193,16 +358,18 @@ CollsRetVal collocations(ConcV conc, const char * attr_name, char sort_fun_code,
 }
 
 
-CollVal next_colloc_item(CollsV colls, char collFn) {
+CollVal next_colloc_item(CollsV colls, char collFn, const char* scoreFns, double* scores) {
     CollVal ans;
     ans.err = nullptr;
+    ans.word = nullptr;
     CollocItems* collsObj = (CollocItems*)colls;
     try {
-        string word = string(collsObj->get_item());
-        double value = collsObj->get_bgr(collFn);
-        ans.value = value;
-        ans.word = word.c_str();
+        ans.value = collsObj->get_bgr(collFn);
+        for (int i = 0; scoreFns[i] != 0; i++) {
+            scores[i] = collsObj->get_bgr(scoreFns[i]);
+        }
         ans.freq = collsObj->get_cnt();
+        ans.word = strdup(collsObj->get_item());
         collsObj->next();
 
     } catch (std::exception &e) {
@@ -211,6 +378,10 @@ CollVal next_colloc_item(CollsV colls, char collFn) {
     return ans;
 }
 
+void delete_collocations(CollsV colls) {
+    delete (CollocItems*)colls;
+}
+
 int has_next_colloc(CollsV colls) {
     CollVal ans;
     ans.err = nullptr;
@@ -44,8 +44,12 @@ type GoVector struct {
 
 type Freqs struct {
 	Words []string
-	Freqs []int64
-	Norms []int64
+
+	// Values contains values of individual levels
+	// in case of multi-level criteria
+	Values [][]string
+	Freqs  []int64
+	Norms  []int64
 }
 
 type GoConc struct {
@@ -72,6 +76,10 @@ type GoColls struct {
 	Word  string
 	Value float64
 	Freq  int64
+
+	// Scores contains values of CollocationsArgs.ScoreFns
+	// (in the same order)
+	Scores []float64
 }
 
 // OpenCorpus is a factory function creating
@@ -82,13 +90,13 @@ func OpenCorpus(path string) (*GoCorpus, error) {
 	ans := C.open_corpus(C.CString(path))
 
 	if ans.err != nil {
-		err = fmt.Errorf(C.GoString(ans.err))
+		err = newManateeError(C.GoString(ans.err))
 		defer C.free(unsafe.Pointer(ans.err))
 		return ret, err
 	}
 	ret.corp = ans.value
 	if ret.corp == nil {
-		return ret, fmt.Errorf("Corpus %s not found", path)
+		return ret, &ManateeError{Kind: ErrCorpusNotFound, Message: fmt.Sprintf("Corpus %s not found", path)}
 	}
 	return ret, nil
 }
@@ -104,7 +112,7 @@ func CloseCorpus(corpus *GoCorpus) error {
 func GetCorpusSize(corpus *GoCorpus) (int64, error) {
 	ans := (C.get_corpus_size(corpus.corp))
 	if ans.err != nil {
-		err := fmt.Errorf(C.GoString(ans.err))
+		err := newManateeError(C.GoString(ans.err))
 		defer C.free(unsafe.Pointer(ans.err))
 		return -1, err
 	}
@@ -116,18 +124,132 @@ func GetCorpusSize(corpus *GoCorpus) (int64, error) {
 func GetCorpusConf(corpus *GoCorpus, prop string) (string, error) {
 	ans := (C.get_corpus_conf(corpus.corp, C.CString(prop)))
 	if ans.err != nil {
-		err := fmt.Errorf(C.GoString(ans.err))
+		err := newManateeError(C.GoString(ans.err))
 		defer C.free(unsafe.Pointer(ans.err))
 		return "", err
 	}
 	return C.GoString(ans.value), nil
 }
 
+// GetAttrSize returns number of distinct values (lexicon size)
+// of a positional or structural (using the "struct.attr" notation)
+// attribute.
+func GetAttrSize(corpus *GoCorpus, attrName string) (int64, error) {
+	cName := C.CString(attrName)
+	defer C.free(unsafe.Pointer(cName))
+	ans := C.get_attr_size(corpus.corp, cName)
+	if ans.err != nil {
+		err := newManateeError(C.GoString(ans.err))
+		defer C.free(unsafe.Pointer(ans.err))
+		return -1, err
+	}
+	return int64(ans.value), nil
+}
+
+// GetStructSize returns number of instances of a structure
+func GetStructSize(corpus *GoCorpus, structName string) (int64, error) {
+	cName := C.CString(structName)
+	defer C.free(unsafe.Pointer(cName))
+	ans := C.get_struct_size(corpus.corp, cName)
+	if ans.err != nil {
+		err := newManateeError(C.GoString(ans.err))
+		defer C.free(unsafe.Pointer(ans.err))
+		return -1, err
+	}
+	return int64(ans.value), nil
+}
+
+// GetAttrTextSize returns text length (i.e. number of positions)
+// of a positional attribute
+func GetAttrTextSize(corpus *GoCorpus, attrName string) (int64, error) {
+	cName := C.CString(attrName)
+	defer C.free(unsafe.Pointer(cName))
+	ans := C.get_attr_text_size(corpus.corp, cName)
+	if ans.err != nil {
+		err := newManateeError(C.GoString(ans.err))
+		defer C.free(unsafe.Pointer(ans.err))
+		return -1, err
+	}
+	return int64(ans.value), nil
+}
+
+// GetAttrValues returns up to maxItems values of a positional
+// (or structural, using the "struct.attr" notation) attribute.
+// The values are returned in the order of their lexicon IDs.
+func GetAttrValues(corpus *GoCorpus, attrName string, maxItems int) ([]string, error) {
+	cName := C.CString(attrName)
+	defer C.free(unsafe.Pointer(cName))
+	ans := C.get_attr_values(corpus.corp, cName, C.longlong(maxItems))
+	if ans.err != nil {
+		err := newManateeError(C.GoString(ans.err))
+		defer C.free(unsafe.Pointer(ans.err))
+		return []string{}, err
+	}
+	defer C.delete_str_vector(ans.values)
+	return StrVectorToSlice(GoVector{ans.values}), nil
+}
+
+// GoDynFun is a Go wrapper for Manatee dynamic function
+// (as used by DYNAMIC attributes)
+type GoDynFun struct {
+	fn C.DynFunV
+}
+
+// Apply calls the function on a provided value
+func (gf *GoDynFun) Apply(value string) (string, error) {
+	cValue := C.CString(value)
+	defer C.free(unsafe.Pointer(cValue))
+	ans := C.apply_dynfun(gf.fn, cValue)
+	if ans.err != nil {
+		err := newManateeError(C.GoString(ans.err))
+		defer C.free(unsafe.Pointer(ans.err))
+		return "", err
+	}
+	return C.GoString(ans.value), nil
+}
+
+// Close releases the function. The instance should become unusable.
+func (gf *GoDynFun) Close() {
+	C.delete_dynfun(gf.fn)
+}
+
+// CreateDynFun instantiates a dynamic function from a dynlib
+// ("internal" for Manatee's own functions). The funtype and args
+// correspond to the FUNTYPE and ARG1, ARG2 registry properties.
+func CreateDynFun(funtype, dynlib, fnName string, args []string) (*GoDynFun, error) {
+	if len(args) > 2 {
+		return nil, fmt.Errorf("dynamic functions support up to 2 arguments, %d provided", len(args))
+	}
+	if funtype == "" {
+		funtype = "0"
+	}
+	cArgs := [2]*C.char{C.CString(""), C.CString("")}
+	for i, arg := range args {
+		C.free(unsafe.Pointer(cArgs[i]))
+		cArgs[i] = C.CString(arg)
+	}
+	cFuntype := C.CString(funtype)
+	cDynlib := C.CString(dynlib)
+	cFnName := C.CString(fnName)
+	defer func() {
+		for _, v := range []*C.char{cArgs[0], cArgs[1], cFuntype, cDynlib, cFnName} {
+			C.free(unsafe.Pointer(v))
+		}
+	}()
+	ans := C.create_dynfun(cFuntype, cDynlib, cFnName, cArgs[0], cArgs[1])
+	if ans.err != nil {
+		err := newManateeError(C.GoString(ans.err))
+		defer C.free(unsafe.Pointer(ans.err))
+		return nil, err
+	}
+	return &GoDynFun{fn: ans.value}, nil
+}
+
 func CreateConcordance(corpus *GoCorpus, query string) (*GoConc, error) {
 	var ret GoConc
 	ans := C.create_concordance(corpus.corp, C.CString(query))
 	if ans.err != nil {
-		err := fmt.Errorf(C.GoString(ans.err))
+		err := newManateeError(C.GoString(ans.err))
 		defer C.free(unsafe.Pointer(ans.err))
 		return nil, err
 	}
@@ -146,7 +268,7 @@ func OpenConcordance(corpus *GoCorpus, path string) (*GoConc, error) {
 	var ret GoConc
 	ans := C.open_concordance(corpus.corp, C.CString(path))
 	if ans.err != nil {
-		err := fmt.Errorf(C.GoString(ans.err))
+		err := newManateeError(C.GoString(ans.err))
 		defer C.free(unsafe.Pointer(ans.err))
 		return nil, err
 	}
@@ -164,13 +286,19 @@ func OpenConcordance(corpus *GoCorpus, path string) (*GoConc, error) {
 func SaveConcordance(conc *GoConc, path string) error {
 	ans := C.save_concordance(conc.conc, C.CString(path))
 	if ans.err != nil {
-		err := fmt.Errorf(C.GoString(ans.err))
+		err := newManateeError(C.GoString(ans.err))
 		defer C.free(unsafe.Pointer(ans.err))
 		return err
 	}
 	return nil
 }
 
+// DeleteConcordance frees the concordance. The instance
+// becomes unusable.
+func DeleteConcordance(conc *GoConc) {
+	C.delete_concordance(conc.conc)
+}
+
 func CalcFreqDist(conc *GoConc, fcrit string, flimit int) (*Freqs, error) {
 	var ret Freqs
 	ans := C.freq_dist(conc.Corpus().corp, conc.conc, C.CString(fcrit), C.longlong(flimit))
@@ -180,16 +308,72 @@ func CalcFreqDist(conc *GoConc, fcrit string, flimit int) (*Freqs, error) {
 		C.delete_str_vector(ans.words)
 	}()
 	if ans.err != nil {
-		err := fmt.Errorf(C.GoString(ans.err))
+		err := newManateeError(C.GoString(ans.err))
 		defer C.free(unsafe.Pointer(ans.err))
 		return &ret, err
 	}
 	ret.Freqs = IntVectorToSlice(GoVector{ans.freqs})
 	ret.Norms = IntVectorToSlice(GoVector{ans.norms})
 	ret.Words = StrVectorToSlice(GoVector{ans.words})
+	ret.Values = make([][]string, len(ret.Words))
+	for i, w := range strVectorToRawSlice(GoVector{ans.words}) {
+		ret.Values[i] = strings.Split(w, "\t")
+		for j, v := range ret.Values[i] {
+			ret.Values[i][j] = normalizeMultiword(v)
+		}
+	}
 	return &ret, nil
 }
 
+// GetConcRanges returns beginnings and ends (exclusive) of concordance
+// lines fromLine, ..., toLine - 1
+func GetConcRanges(conc *GoConc, fromLine, toLine int64) ([]int64, []int64, error) {
+	ans := C.get_conc_ranges(conc.conc, C.longlong(fromLine), C.longlong(toLine))
+	if ans.err != nil {
+		err := newManateeError(C.GoString(ans.err))
+		defer C.free(unsafe.Pointer(ans.err))
+		return []int64{}, []int64{}, err
+	}
+	defer func() {
+		C.delete_int_vector(ans.begs)
+		C.delete_int_vector(ans.ends)
+	}()
+	return IntVectorToSlice(GoVector{ans.begs}), IntVectorToSlice(GoVector{ans.ends}), nil
+}
+
+// GetAttrValuesAt returns values of a positional attribute
+// at positions fromPos, ..., toPos - 1
+func GetAttrValuesAt(corpus *GoCorpus, attrName string, fromPos, toPos int64) ([]string, error) {
+	cName := C.CString(attrName)
+	defer C.free(unsafe.Pointer(cName))
+	ans := C.get_attr_values_at(corpus.corp, cName, C.longlong(fromPos), C.longlong(toPos))
+	if ans.err != nil {
+		err := newManateeError(C.GoString(ans.err))
+		defer C.free(unsafe.Pointer(ans.err))
+		return []string{}, err
+	}
+	defer C.delete_str_vector(ans.values)
+	return StrVectorToSlice(GoVector{ans.values}), nil
+}
+
+// GetStructRanges returns beginnings and ends (exclusive) of structure
+// instances overlapping with positions fromPos, ..., toPos - 1
+func GetStructRanges(corpus *GoCorpus, structName string, fromPos, toPos int64) ([]int64, []int64, error) {
+	cName := C.CString(structName)
+	defer C.free(unsafe.Pointer(cName))
+	ans := C.get_struct_ranges(corpus.corp, cName, C.longlong(fromPos), C.longlong(toPos))
+	if ans.err != nil {
+		err := newManateeError(C.GoString(ans.err))
+		defer C.free(unsafe.Pointer(ans.err))
+		return []int64{}, []int64{}, err
+	}
+	defer func() {
+		C.delete_int_vector(ans.begs)
+		C.delete_int_vector(ans.ends)
+	}()
+	return IntVectorToSlice(GoVector{ans.begs}), IntVectorToSlice(GoVector{ans.ends}), nil
+}
+
 func normalizeMultiword(w string) string {
 	return strings.TrimSpace(strings.Map(func(c rune) rune {
 		if unicode.IsSpace(c) {
@@ -199,6 +383,17 @@ func normalizeMultiword(w string) string {
 	}, w))
 }
 
+// strVectorToRawSlice is like StrVectorToSlice but
+// it keeps the values untouched
+func strVectorToRawSlice(vector GoVector) []string {
+	size := int(C.str_vector_get_size(vector.v))
+	slice := make([]string, size)
+	for i := 0; i < size; i++ {
+		slice[i] = C.GoString(C.str_vector_get_element(vector.v, C.int(i)))
+	}
+	return slice
+}
+
 func StrVectorToSlice(vector GoVector) []string {
 	size := int(C.str_vector_get_size(vector.v))
 	slice := make([]string, size)
@@ -219,7 +414,8 @@ func IntVectorToSlice(vector GoVector) []int64 {
 	return slice
 }
 
-// GetCollcations
+// CollocationsArgs specifies collocations calculation.
+// Available functions:
 //
 // 't': 'T-score',
 // 'm': 'MI',
@@ -230,37 +426,60 @@ func IntVectorToSlice(vector GoVector) []int64 {
 // 'r': 'relative freq. [%]',
 // 'f': 'absolute freq.',
 // 'd': 'logDice'
-func GetCollcations(
-	conc *GoConc,
-	attrName string,
-	calcFn byte,
-	minFreq int64,
-	maxItems int,
-) ([]*GoColls, error) {
-	colls := C.collocations(conc.conc, C.CString(attrName), C.char(calcFn),
-		C.longlong(minFreq), C.longlong(minFreq), -5, 5, C.int(maxItems))
+type CollocationsArgs struct {
+	Attr string
+
+	// SortFn is a function used for sorting
+	SortFn byte
+
+	// ScoreFns are additional functions calculated
+	// for each item (see GoColls.Scores)
+	ScoreFns []byte
+	MinFreq  int64
+	MinBgr   int64
+
+	// FromW and ToW specify the context window
+	// (e.g. -5 and 5)
+	FromW    int
+	ToW      int
+	MaxItems int
+}
+
+// GetCollcations calculates collocations of a concordance
+func GetCollcations(conc *GoConc, args CollocationsArgs) ([]*GoColls, error) {
+	cAttr := C.CString(args.Attr)
+	defer C.free(unsafe.Pointer(cAttr))
+	colls := C.collocations(conc.conc, cAttr, C.char(args.SortFn),
+		C.longlong(args.MinFreq), C.longlong(args.MinBgr),
+		C.int(args.FromW), C.int(args.ToW), C.int(args.MaxItems))
 	if colls.err != nil {
-		err := fmt.Errorf(C.GoString(colls.err))
+		err := newManateeError(C.GoString(colls.err))
 		defer C.free(unsafe.Pointer(colls.err))
 		return []*GoColls{}, err
 	}
-	ret := make([]*GoColls, 0, 50) // TODO capacity
+	defer C.delete_collocations(colls.value)
+	cScoreFns := C.CString(string(args.ScoreFns))
+	defer C.free(unsafe.Pointer(cScoreFns))
+	scores := make([]C.double, len(args.ScoreFns)+1)
+	ret := make([]*GoColls, 0, args.MaxItems)
 	for C.has_next_colloc(colls.value) == 1 {
-		ans := C.next_colloc_item(colls.value, C.char(calcFn))
+		ans := C.next_colloc_item(colls.value, C.char(args.SortFn), cScoreFns, &scores[0])
 		if ans.err != nil {
-			err := fmt.Errorf(C.GoString(ans.err))
+			err := newManateeError(C.GoString(ans.err))
 			defer C.free(unsafe.Pointer(ans.err))
 			return []*GoColls{}, err
 		}
-		ret = append(
-			ret,
-			&GoColls{
-				Word:  C.GoString(ans.word),
-				Value: float64(ans.value),
-				Freq:  int64(ans.freq),
-			},
-		)
+		item := &GoColls{
+			Word:   C.GoString(ans.word),
+			Value:  float64(ans.value),
+			Freq:   int64(ans.freq),
+			Scores: make([]float64, len(args.ScoreFns)),
+		}
+		C.free(unsafe.Pointer(ans.word))
+		for i := range args.ScoreFns {
+			item.Scores[i] = float64(scores[i])
+		}
+		ret = append(ret, item)
 	}
-
 	return ret, nil
 }
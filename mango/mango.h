@@ -26,6 +26,7 @@ typedef void* StructV;
 typedef void* ConcV;
 typedef void* MVector;
 typedef void* CollsV;
+typedef void* DynFunV;
 
 
 typedef long long int PosInt;
@@ -68,6 +69,22 @@ typedef struct FreqsRetval {
     const char * err;
 } FreqsRetval;
 
+typedef struct RangesRetval {
+    MVector begs;
+    MVector ends;
+    const char * err;
+} RangesRetval;
+
+typedef struct AttrValuesRetval {
+    MVector values;
+    const char * err;
+} AttrValuesRetval;
+
+typedef struct DynFunRetval {
+    DynFunV value;
+    const char * err;
+} DynFunRetval;
+
 typedef struct CollsRetVal {
     CollsV value;
     const char * err;
@@ -84,6 +101,44 @@ CorpusSizeRetrval get_corpus_size(CorpusV corpus);
 
 CorpusStringRetval get_corpus_conf(CorpusV corpus, const char* prop);
 
+/**
+ * Return number of distinct values (i.e. lexicon size) of
+ * a positional or structural ("struct.attr") attribute
+ */
+CorpusSizeRetrval get_attr_size(CorpusV corpus, const char* attrName);
+
+/**
+ * Return number of instances of a structure
+ */
+CorpusSizeRetrval get_struct_size(CorpusV corpus, const char* structName);
+
+/**
+ * Return text length (i.e. number of positions) of
+ * a positional attribute
+ */
+CorpusSizeRetrval get_attr_text_size(CorpusV corpus, const char* attrName);
+
+/**
+ * Return up to maxItems values from an attribute lexicon
+ * (in the order of their IDs)
+ */
+AttrValuesRetval get_attr_values(CorpusV corpus, const char* attrName, PosInt maxItems);
+
+/**
+ * Create a Manatee dynamic function (as used by DYNAMIC attributes).
+ * For functions without arguments, funtype should be "0".
+ */
+DynFunRetval create_dynfun(const char* funtype, const char* lib, const char* fnName,
+             const char* arg1, const char* arg2);
+
+/**
+ * Apply a dynamic function to a value. The returned value
+ * is owned by the function and must not be freed.
+ */
+CorpusStringRetval apply_dynfun(DynFunV fn, const char* value);
+
+void delete_dynfun(DynFunV fn);
+
 ConcRetval create_concordance(CorpusV corpus, char* query);
 
 PosInt concordance_size(ConcV conc);
@@ -92,6 +147,8 @@ ConcRetval open_concordance(CorpusV corpus, char* path);
 
 ConcSaveRetval save_concordance(ConcV conc, const char* path);
 
+void delete_concordance(ConcV conc);
+
 void delete_str_vector(MVector v);
 
 void delete_int_vector(MVector v);
@@ -104,6 +161,26 @@ PosInt int_vector_get_element(MVector v, int i);
 
 PosInt int_vector_get_size(MVector v);
 
+/**
+ * Return [beg, end) positions of concordance lines
+ * fromLine, ..., toLine - 1
+ */
+RangesRetval get_conc_ranges(ConcV conc, PosInt fromLine, PosInt toLine);
+
+/**
+ * Return values of a positional attribute at
+ * positions fromPos, ..., toPos - 1
+ */
+AttrValuesRetval get_attr_values_at(CorpusV corpus, const char* attrName,
+             PosInt fromPos, PosInt toPos);
+
+/**
+ * Return [beg, end) positions of structure instances
+ * overlapping with positions fromPos, ..., toPos - 1
+ */
+RangesRetval get_struct_ranges(CorpusV corpus, const char* structName,
+             PosInt fromPos, PosInt toPos);
+
 FreqsRetval freq_dist(CorpusV corpus, ConcV conc, char* fcrit, PosInt flimit);
 
 CollsRetVal collocations(ConcV conc, const char * attr_name, char sort_fun_code,
@@ -117,7 +194,16 @@ typedef struct CollVal {
 } CollVal;
 
 
-CollVal next_colloc_item(CollsV colls, char collFn);
+/**
+ * Return the current collocation item and move to the next one.
+ * The returned value contains score calculated by collFn. Scores
+ * of additional functions (scoreFns, one per character) are written
+ * to the scores array (which must be allocated by the caller).
+ * The returned word must be freed by the caller.
+ */
+CollVal next_colloc_item(CollsV colls, char collFn, const char* scoreFns, double* scores);
+
+void delete_collocations(CollsV colls);
 
 int has_next_colloc(CollsV colls);
 
@@ -22,6 +22,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"net/http"
@@ -41,8 +42,10 @@ import (
 	"masm/v3/corpus"
 	"masm/v3/corpus/query"
 	"masm/v3/general"
+	"masm/v3/jobs"
 	"masm/v3/liveattrs"
 	"masm/v3/registry"
+	"masm/v3/registry/parser"
 	"masm/v3/root"
 )
 
@@ -52,6 +55,29 @@ var (
 	gitCommit string
 )
 
+// runRegistryDiff prints a semantic diff of two registry files
+// to stdout. It returns true if the registries are equal.
+func runRegistryDiff(regPath1, regPath2 string) bool {
+	if regPath1 == "" || regPath2 == "" {
+		log.Fatal().Msg("regdiff requires two registry files")
+	}
+	doc1, err := parser.ParseRegistryFile(regPath1)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to parse registry")
+	}
+	doc2, err := parser.ParseRegistryFile(regPath2)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to parse registry")
+	}
+	diff := registry.DiffRegistries(regPath1, doc1, regPath2, doc2)
+	out, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to encode registry diff")
+	}
+	fmt.Println(string(out))
+	return diff.IsEmpty()
+}
+
 func main() {
 	version := general.VersionInfo{
 		Version:   version,
@@ -60,8 +86,8 @@ func main() {
 	}
 
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "CNC-MASM - Manatee administration setup middleware\n\nUsage:\n\t%s [options] start [config.json]\n\t%s [options] version\n",
-			filepath.Base(os.Args[0]), filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "CNC-MASM - Manatee administration setup middleware\n\nUsage:\n\t%s [options] start [config.json]\n\t%s [options] regdiff [registry1] [registry2]\n\t%s [options] version\n",
+			filepath.Base(os.Args[0]), filepath.Base(os.Args[0]), filepath.Base(os.Args[0]))
 		flag.PrintDefaults()
 	}
 	flag.Parse()
@@ -70,6 +96,12 @@ func main() {
 		fmt.Printf("cnc-masm %s\nbuild date: %s\nlast commit: %s\n", version.Version, version.BuildDate, version.GitCommit)
 		return
 
+	} else if action == "regdiff" {
+		if !runRegistryDiff(flag.Arg(1), flag.Arg(2)) {
+			os.Exit(1)
+		}
+		return
+
 	} else if action != "start" {
 		log.Fatal().Msgf("Unknown action %s", action)
 	}
@@ -122,32 +154,114 @@ func main() {
 
 	rootActions := root.Actions{Version: version, Conf: conf}
 
-	corpusActions := corpus.NewActions(conf.CorporaSetup, cncDB)
+	jobsManager := jobs.NewManager(ctx)
+	corpusPool := corpus.NewCorpusPool(conf.CorporaSetup)
+	corpusActions := corpus.NewActions(conf.CorporaSetup, cncDB, jobsManager, corpusPool)
 
-	concCache := query.NewCache(conf.CorporaSetup.ConcCacheDirPath, conf.GetLocation())
+	concCache := query.NewCache(
+		conf.CorporaSetup.ConcCacheDirPath, conf.GetLocation(), conf.CorporaSetup.ConcCache)
 	concCache.RestoreUnboundEntries()
-	concActions := query.NewActions(conf.CorporaSetup, conf.GetLocation(), concCache)
+	go concCache.RunAccessStatsWriter(ctx, query.ConcCacheStatsFlushInterval)
+	concProvider := query.NewConcProvider(concCache)
+	concActions := query.NewActions(
+		conf.CorporaSetup, conf.GetLocation(), concProvider, corpusPool, jobsManager)
 
-	registryActions := registry.NewActions(conf.CorporaSetup)
+	tagsets, err := registry.NewTagsetCatalogue(conf.CorporaSetup.TagsetsDirPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load tagsets")
+	}
+	go func() {
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		for {
+			select {
+			case <-reload:
+				if err := tagsets.Load(); err != nil {
+					log.Error().Err(err).Msg("failed to reload tagsets, keeping the previous ones")
+				}
+			case <-ctx.Done():
+				signal.Stop(reload)
+				return
+			}
+		}
+	}()
+	dynFns, err := registry.LoadDynFnCatalogue(conf.CorporaSetup)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load dynamic functions")
+	}
+	registryActions := registry.NewActions(conf.CorporaSetup, tagsets, dynFns, jobsManager, corpusPool)
 
 	engine.GET(
 		"/", rootActions.RootAction)
+	engine.GET(
+		"/corpora", corpusActions.ListCorpora)
+	engine.GET(
+		"/corpus-pool", corpusActions.CorpusPoolStats)
 	engine.GET(
 		"/corpora/:corpusId", corpusActions.GetCorpusInfo)
+	engine.GET(
+		"/corpora/:corpusId/:corpusIdInSubdir", corpusActions.GetCorpusInfo)
+	engine.POST(
+		"/corpora/:corpusId/_syncData", corpusActions.SyncData)
+	engine.POST(
+		"/corpora/:corpusId/:corpusIdInSubdir/_syncData", corpusActions.SyncData)
+	engine.POST(
+		"/corpora/:corpusId/_verify", corpusActions.VerifyData)
+	engine.POST(
+		"/corpora/:corpusId/:corpusIdInSubdir/_verify", corpusActions.VerifyData)
+	engine.GET(
+		"/corpora/:corpusId/vertical/_stats", corpusActions.VerticalStats)
+	engine.GET(
+		"/corpora/:corpusId/:corpusIdInSubdir/vertical/_stats", corpusActions.VerticalStats)
 
 	engine.GET(
 		"/freqs/:corpusId", concActions.FreqDistrib)
+	engine.GET(
+		"/freqs/:corpusId/:corpusIdInSubdir", concActions.FreqDistrib)
+
+	engine.GET(
+		"/conc/:corpusId", concActions.Conc)
+	engine.GET(
+		"/conc/:corpusId/:corpusIdInSubdir", concActions.Conc)
 
 	engine.GET(
 		"/collocs/:corpusId", concActions.Collocations)
+	engine.GET(
+		"/collocs/:corpusId/:corpusIdInSubdir", concActions.Collocations)
+
+	engine.GET(
+		"/cache", concActions.CacheList)
+	engine.GET(
+		"/cache/:corpusId", concActions.CacheCorpusInfo)
+	engine.GET(
+		"/cache/:corpusId/:corpusIdInSubdir", concActions.CacheCorpusInfo)
+	engine.DELETE(
+		"/cache/:corpusId", concActions.CacheDelete)
+	engine.DELETE(
+		"/cache/:corpusId/:corpusIdInSubdir", concActions.CacheDelete)
+	engine.POST(
+		"/cache/:corpusId/_prewarm", concActions.CachePrewarm)
+	engine.POST(
+		"/cache/:corpusId/:corpusIdInSubdir/_prewarm", concActions.CachePrewarm)
 
 	engine.GET(
 		"/registry/defaults/attribute/dynamic-functions",
 		registryActions.DynamicFunctions)
+	engine.POST(
+		"/registry/defaults/attribute/dynamic-functions/_preview",
+		registryActions.PreviewDynamicFunction)
 	engine.GET(
 		"/registry/defaults/wposlist", registryActions.PosSets)
+	engine.POST(
+		"/registry/defaults/wposlist", registryActions.CreatePosSet)
 	engine.GET(
 		"/registry/defaults/wposlist/:posId", registryActions.GetPosSetInfo)
+	engine.PUT(
+		"/registry/defaults/wposlist/:posId", registryActions.UpdatePosSet)
+	engine.DELETE(
+		"/registry/defaults/wposlist/:posId", registryActions.DeletePosSet)
+	engine.GET(
+		"/registry/defaults/wposlist/:posId/_test", registryActions.TestPosSet)
 	engine.GET(
 		"/registry/defaults/attribute/multivalue",
 		registryActions.GetAttrMultivalueDefaults)
@@ -166,22 +280,49 @@ func main() {
 	engine.GET(
 		"/registry/defaults/structure/multisep",
 		registryActions.GetStructMultisepDefaults)
+	engine.GET(
+		"/registry/:corpusId", registryActions.GetRegistry)
+	engine.GET(
+		"/registry/:corpusId/:corpusIdInSubdir", registryActions.GetRegistry)
+	engine.PUT(
+		"/registry/:corpusId", registryActions.PutRegistry)
+	engine.PUT(
+		"/registry/:corpusId/:corpusIdInSubdir", registryActions.PutRegistry)
+	engine.POST(
+		"/registry/:corpusId/_validate", registryActions.ValidateRegistry)
+	engine.POST(
+		"/registry/:corpusId/:corpusIdInSubdir/_validate", registryActions.ValidateRegistry)
+	engine.GET(
+		"/registry/:corpusId/_diff", registryActions.DiffRegistries)
+	engine.GET(
+		"/registry/:corpusId/:corpusIdInSubdir/_diff", registryActions.DiffRegistries)
+	engine.POST(
+		"/registry/:corpusId/_generate", registryActions.GenerateRegistry)
+	engine.POST(
+		"/registry/:corpusId/:corpusIdInSubdir/_generate", registryActions.GenerateRegistry)
 
 	laActions, err := liveattrs.NewLiveAttrsActions(ctx, conf.LiveAttrsConf)
 	engine.POST(
 		"/liveAttributes/:corpusId/data", laActions.Create)
 
-	engine.GET("/jobs/:jobId", laActions.Jobs)
+	jobsActions := jobs.NewActions(jobsManager, laActions)
+	engine.GET("/jobs/:jobId", jobsActions.JobInfo)
 
-	engine.GET("/jobs", laActions.Jobs)
+	engine.GET("/jobs", jobsActions.List)
 
-	cncdbActions := cncdb.NewActions(conf.CNCDB, conf.CorporaSetup, cncDB)
+	cncdbActions := cncdb.NewActions(conf.CNCDB, conf.CorporaSetup, cncDB, corpusPool)
 	engine.POST(
 		"/corpora-database/:corpusId/auto-update",
 		cncdbActions.UpdateCorpusInfo)
+	engine.POST(
+		"/corpora-database/:corpusId/:corpusIdInSubdir/auto-update",
+		cncdbActions.UpdateCorpusInfo)
 	engine.PUT(
 		"/corpora-database/:corpusId/kontextDefaults",
 		cncdbActions.InferKontextDefaults)
+	engine.PUT(
+		"/corpora-database/:corpusId/:corpusIdInSubdir/kontextDefaults",
+		cncdbActions.InferKontextDefaults)
 
 	log.Info().Msgf("starting to listen at %s:%d", conf.ListenAddress, conf.ListenPort)
 	srv := &http.Server{
@@ -207,4 +348,5 @@ func main() {
 	if err := srv.Shutdown(ctxShutDown); err != nil {
 		log.Fatal().Err(err).Msg("Server forced to shutdown")
 	}
+	concCache.FlushAccessStats()
 }
@@ -19,68 +19,212 @@
 package registry
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"masm/v3/corpus"
+	"masm/v3/general/collections"
+	"masm/v3/jobs"
+	"masm/v3/registry/parser"
 	"net/http"
+	"path/filepath"
 
+	"github.com/czcorpus/cnc-gokit/fs"
 	"github.com/czcorpus/cnc-gokit/uniresp"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
 type Actions struct {
-	conf *corpus.CorporaSetup
+	conf    *corpus.CorporaSetup
+	tagsets *TagsetCatalogue
+	dynFns  *DynFnCatalogue
+	jobs    *jobs.Manager
+	pool    *corpus.CorpusPool
 }
 
 // DynamicFunctions provides a list of Manatee internal + our configured functions
-// for generating dynamic attributes
+// for generating dynamic attributes. Functions where the configured dynlib
+// and their descriptions disagree contain the `problem` attribute.
 func (a *Actions) DynamicFunctions(ctx *gin.Context) {
-	fullList := dynFnList[:]
-	fullList = append(fullList, DynFn{
-		Name:        "geteachncharbysep",
-		Args:        []string{"str", "n"},
-		Description: "Separate a string by \"|\" and return all the pos-th elements from respective items",
-		Dynlib:      a.conf.ManateeDynlibPath,
-	})
-	uniresp.WriteCacheableJSONResponse(ctx.Writer, ctx.Request, fullList)
+	uniresp.WriteCacheableJSONResponse(ctx.Writer, ctx.Request, a.dynFns.All())
 }
 
+// PreviewDynamicFunction applies a dynamic function to provided
+// values (or to values of a corpus attribute) so registry authors
+// can see the result before Manatee compiles the attribute.
+func (a *Actions) PreviewDynamicFunction(ctx *gin.Context) {
+	var args PreviewArgs
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&args); err != nil {
+		corpus.WriteErrorResponse(
+			ctx.Writer, fmt.Errorf("%w: failed to decode arguments: %s", corpus.ErrInvalidRequest, err))
+		return
+	}
+	ans, err := PreviewDynFn(args, a.dynFns, a.pool)
+	var callErrs DynFnCallErrors
+	if errors.As(err, &callErrs) {
+		uniresp.WriteCustomJSONErrorResponse(
+			ctx.Writer,
+			struct {
+				Code      int              `json:"code"`
+				Error     string           `json:"error"`
+				ErrorCode corpus.ErrorCode `json:"errorCode"`
+				Details   DynFnCallErrors  `json:"details"`
+			}{
+				Code:      http.StatusBadRequest,
+				Error:     "invalid dynamic function arguments",
+				ErrorCode: corpus.ErrorCodeInvalidRequest,
+				Details:   callErrs,
+			},
+			http.StatusBadRequest,
+		)
+		return
+
+	} else if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
+// PosSets provides all the available tagsets. An optional
+// `lang` argument specifies a language of labels.
 func (a *Actions) PosSets(ctx *gin.Context) {
-	ans := make([]Pos, len(posList))
-	for i, v := range posList {
-		ans[i] = v
+	lang := ctx.Query("lang")
+	ans := a.tagsets.List()
+	if lang != "" {
+		for i, v := range ans {
+			ans[i] = v.Localized(lang)
+		}
 	}
 	uniresp.WriteCacheableJSONResponse(ctx.Writer, ctx.Request, ans)
 }
 
 func (a *Actions) GetPosSetInfo(ctx *gin.Context) {
 	posID := ctx.Param("posId")
-	var srch Pos
-	for _, v := range posList {
-		if v.ID == posID {
-			srch = v
-		}
-	}
-	if srch.ID == "" {
-		uniresp.WriteJSONErrorResponse(ctx.Writer, uniresp.NewActionError("Tagset %s not found", posID), http.StatusInternalServerError)
+	srch, ok := a.tagsets.Get(posID)
+	if !ok {
+		corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf("%w: %s", ErrTagsetNotFound, posID))
 
 	} else {
+		if lang := ctx.Query("lang"); lang != "" {
+			srch = srch.Localized(lang)
+		}
 		uniresp.WriteJSONResponse(ctx.Writer, srch)
 	}
 }
 
+// TestPosSet starts a background job running patterns of a tagset
+// against a tag attribute of a corpus specified by the `corpus` argument.
+// The attribute can be changed using the `attr` argument (default is `tag`).
+// Once finished, the job result contains *TagsetTestReport.
+func (a *Actions) TestPosSet(ctx *gin.Context) {
+	posID := ctx.Param("posId")
+	pos, ok := a.tagsets.Get(posID)
+	if !ok {
+		corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf("%w: %s", ErrTagsetNotFound, posID))
+		return
+	}
+	corpusID := ctx.Query("corpus")
+	if corpusID == "" {
+		corpus.WriteErrorResponse(
+			ctx.Writer, fmt.Errorf("%w: missing corpus argument", corpus.ErrInvalidRequest))
+		return
+	}
+	tagsetTest, err := NewTagsetTest(pos, corpusID, ctx.Query("attr"), a.pool)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	jobInfo, err := a.jobs.Start(TagsetTestJobType, corpusID, tagsetTest.Run)
+	if err != nil {
+		tagsetTest.Close()
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusAccepted, jobInfo)
+}
+
+func decodePosSet(ctx *gin.Context) (Pos, error) {
+	var ans Pos
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&ans); err != nil {
+		return ans, fmt.Errorf("%w: failed to decode tagset: %s", corpus.ErrInvalidRequest, err)
+	}
+	if err := ans.Validate(); err != nil {
+		return ans, fmt.Errorf("%w: %s", corpus.ErrInvalidRequest, err)
+	}
+	return ans, nil
+}
+
+// CreatePosSet adds a new tagset to the catalogue
+func (a *Actions) CreatePosSet(ctx *gin.Context) {
+	pos, err := decodePosSet(ctx)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	if err := a.tagsets.Create(pos); err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	uniresp.WriteJSONResponseWithStatus(ctx.Writer, http.StatusCreated, pos)
+}
+
+// UpdatePosSet replaces an existing tagset. The tagset ID in
+// the request body (if specified) must match the URL one.
+func (a *Actions) UpdatePosSet(ctx *gin.Context) {
+	posID := ctx.Param("posId")
+	var pos Pos
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&pos); err != nil {
+		corpus.WriteErrorResponse(
+			ctx.Writer, fmt.Errorf("%w: failed to decode tagset: %s", corpus.ErrInvalidRequest, err))
+		return
+	}
+	if pos.ID == "" {
+		pos.ID = posID
+
+	} else if pos.ID != posID {
+		corpus.WriteErrorResponse(
+			ctx.Writer,
+			fmt.Errorf("%w: tagset ID %s does not match %s", corpus.ErrInvalidRequest, pos.ID, posID),
+		)
+		return
+	}
+	if err := pos.Validate(); err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf("%w: %s", corpus.ErrInvalidRequest, err))
+		return
+	}
+	if err := a.tagsets.Update(pos); err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, pos)
+}
+
+// DeletePosSet removes a tagset from the catalogue
+func (a *Actions) DeletePosSet(ctx *gin.Context) {
+	posID := ctx.Param("posId")
+	if err := a.tagsets.Delete(posID); err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+}
+
 func (a *Actions) GetAttrMultivalueDefaults(ctx *gin.Context) {
 	uniresp.WriteJSONResponse(ctx.Writer, availBoolValues)
 }
 
 func (a *Actions) GetAttrMultisepDefaults(ctx *gin.Context) {
-	ans := []multisep{
-		{Value: "|", Description: "A default value used within the CNC"},
-	}
+	ans := []multisep{cncMultisep}
 	uniresp.WriteJSONResponse(ctx.Writer, ans)
 }
 
 func (a *Actions) GetAttrDynlibDefaults(ctx *gin.Context) {
 	ans := []dynlibItem{
-		{Value: "internal", Description: "Functions provided by Manatee"},
+		{Value: dynlibInternal, Description: "Functions provided by Manatee"},
 		{Value: a.conf.ManateeDynlibPath, Description: "Custom functions provided by the CNC"},
 	}
 	uniresp.WriteJSONResponse(ctx.Writer, ans)
@@ -95,8 +239,201 @@ func (a *Actions) GetStructMultivalueDefaults(ctx *gin.Context) {
 }
 
 func (a *Actions) GetStructMultisepDefaults(ctx *gin.Context) {
-	ans := []multisep{
-		{Value: "|", Description: "A default value used within the CNC"},
+	ans := []multisep{cncMultisep}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
+}
+
+// GetRegistry provides a complete parsed registry file of a corpus
+func (a *Actions) GetRegistry(ctx *gin.Context) {
+	corpusID, err := corpus.CorpusIDFromRequest(ctx)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	regPath := a.conf.GetFirstValidRegistry(corpusID, corpus.CorpusVariantPrimary.SubDir())
+	if regPath == "" {
+		corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf("registry for %s %w", corpusID, corpus.ErrNotFound))
+		return
+	}
+	doc, err := parser.ParseRegistryFile(regPath)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf("failed to parse registry of %s: %w", corpusID, err))
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, doc)
+}
+
+// PutRegistry validates a structured registry document (as provided
+// by GetRegistry) and writes it to the corpus registry file.
+func (a *Actions) PutRegistry(ctx *gin.Context) {
+	corpusID, err := corpus.CorpusIDFromRequest(ctx)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	regPath := a.conf.GetFirstValidRegistry(corpusID, corpus.CorpusVariantPrimary.SubDir())
+	if regPath == "" {
+		corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf("registry for %s %w", corpusID, corpus.ErrNotFound))
+		return
+	}
+	var doc parser.Document
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&doc); err != nil {
+		corpus.WriteErrorResponse(
+			ctx.Writer, fmt.Errorf("%w: failed to decode registry document: %s", corpus.ErrInvalidRequest, err))
+		return
+	}
+	if err := doc.Validate(); err != nil {
+		var details []string
+		var verrs parser.ValidationErrors
+		if errors.As(err, &verrs) {
+			details = make([]string, len(verrs))
+			for i, verr := range verrs {
+				details[i] = verr.Error()
+			}
+		}
+		corpus.WriteErrorResponse(
+			ctx.Writer,
+			fmt.Errorf("%w: invalid registry document for %s", corpus.ErrInvalidArgs, corpusID),
+			details...,
+		)
+		return
+	}
+	if err := writeRegistryFile(&doc, regPath, a.conf.RegistryTmpDir); err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	log.Info().
+		Str("corpusId", corpusID).
+		Str("registryPath", regPath).
+		Msg("registry file updated")
+	uniresp.WriteJSONResponse(ctx.Writer, map[string]any{"ok": true})
+}
+
+// ValidateRegistry checks a registry against the indexed data.
+// In case the request body contains a registry document (in the same
+// format as returned by GetRegistry), the document is validated.
+// Otherwise, the current registry file of the corpus is validated.
+func (a *Actions) ValidateRegistry(ctx *gin.Context) {
+	corpusID, err := corpus.CorpusIDFromRequest(ctx)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	var doc *parser.Document
+	if len(bytes.TrimSpace(body)) > 0 {
+		doc = new(parser.Document)
+		if err := json.Unmarshal(body, doc); err != nil {
+			corpus.WriteErrorResponse(
+				ctx.Writer, fmt.Errorf("%w: failed to decode registry document: %s", corpus.ErrInvalidRequest, err))
+			return
+		}
+
+	} else {
+		regPath := a.conf.GetFirstValidRegistry(corpusID, corpus.CorpusVariantPrimary.SubDir())
+		if regPath == "" {
+			corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf("registry for %s %w", corpusID, corpus.ErrNotFound))
+			return
+		}
+		doc, err = parser.ParseRegistryFile(regPath)
+		if err != nil {
+			corpus.WriteErrorResponse(
+				ctx.Writer,
+				fmt.Errorf("%w: failed to parse registry of %s: %s", corpus.ErrInvalidArgs, corpusID, err),
+			)
+			return
+		}
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ValidateRegistry(corpusID, doc, a.conf, a.dynFns))
+}
+
+// findRegistryFile returns a path to a registry file of a corpus variant.
+// In case regRoot is empty, the first registry root containing the
+// corpus is used. Otherwise regRoot must be one of configured
+// registry roots. An empty string is returned if nothing is found.
+func (a *Actions) findRegistryFile(corpusID string, variant corpus.CorpusVariant, regRoot string) (string, error) {
+	if !a.conf.IsValidVariant(variant) {
+		return "", fmt.Errorf("%w: unknown corpus variant %s", corpus.ErrInvalidRequest, variant)
+	}
+	if regRoot == "" {
+		return a.conf.GetFirstValidRegistry(corpusID, variant.SubDir()), nil
+	}
+	if !collections.SliceContains(a.conf.RegistryDirPaths, regRoot) {
+		return "", fmt.Errorf("%w: %s is not a configured registry directory", corpus.ErrInvalidRequest, regRoot)
+	}
+	regPath := filepath.Join(regRoot, variant.SubDir(), corpusID)
+	isFile, err := fs.IsFile(regPath)
+	if err != nil || !isFile {
+		return "", err
+	}
+	return regPath, nil
+}
+
+// DiffRegistries compares two registries of a corpus. By default,
+// the primary and the limited ("omezeni") variants are compared.
+// URL args variant1, variant2 specify compared variants and
+// root1, root2 specify registry roots (from registryDirPaths).
+func (a *Actions) DiffRegistries(ctx *gin.Context) {
+	corpusID, err := corpus.CorpusIDFromRequest(ctx)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	variant1 := corpus.CorpusVariant(ctx.DefaultQuery("variant1", string(corpus.CorpusVariantPrimary)))
+	variant2 := corpus.CorpusVariant(ctx.DefaultQuery("variant2", string(corpus.CorpusVariantLimited)))
+	regPaths := make([]string, 2)
+	docs := make([]*parser.Document, 2)
+	for i, v := range []corpus.CorpusVariant{variant1, variant2} {
+		var err error
+		regPaths[i], err = a.findRegistryFile(corpusID, v, ctx.Query(fmt.Sprintf("root%d", i+1)))
+		if err != nil {
+			corpus.WriteErrorResponse(ctx.Writer, err)
+			return
+		}
+		if regPaths[i] == "" {
+			corpus.WriteErrorResponse(
+				ctx.Writer, fmt.Errorf("registry for %s (variant %s) %w", corpusID, v, corpus.ErrNotFound))
+			return
+		}
+		docs[i], err = parser.ParseRegistryFile(regPaths[i])
+		if err != nil {
+			corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf("failed to parse registry of %s: %w", corpusID, err))
+			return
+		}
+	}
+	uniresp.WriteJSONResponse(
+		ctx.Writer,
+		DiffRegistries(regPaths[0], docs[0], regPaths[1], docs[1]),
+	)
+}
+
+// GenerateRegistry produces a registry draft for indexed data.
+// The request body should contain a data path ("path") and
+// optionally a vertical file path ("vertical").
+func (a *Actions) GenerateRegistry(ctx *gin.Context) {
+	corpusID, err := corpus.CorpusIDFromRequest(ctx)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
+	}
+	var args GenerateArgs
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&args); err != nil {
+		corpus.WriteErrorResponse(
+			ctx.Writer, fmt.Errorf("%w: failed to decode arguments: %s", corpus.ErrInvalidRequest, err))
+		return
+	}
+	if args.DataPath == "" {
+		corpus.WriteErrorResponse(ctx.Writer, fmt.Errorf("%w: missing data path", corpus.ErrInvalidRequest))
+		return
+	}
+	ans, err := GenerateRegistry(ctx.Request.Context(), corpusID, args, a.conf, a.tagsets)
+	if err != nil {
+		corpus.WriteErrorResponse(ctx.Writer, err)
+		return
 	}
 	uniresp.WriteJSONResponse(ctx.Writer, ans)
 }
@@ -104,8 +441,16 @@ func (a *Actions) GetStructMultisepDefaults(ctx *gin.Context) {
 // NewActions is the default factory for Actions
 func NewActions(
 	conf *corpus.CorporaSetup,
+	tagsets *TagsetCatalogue,
+	dynFns *DynFnCatalogue,
+	jobsManager *jobs.Manager,
+	pool *corpus.CorpusPool,
 ) *Actions {
 	return &Actions{
-		conf: conf,
+		conf:    conf,
+		tagsets: tagsets,
+		dynFns:  dynFns,
+		jobs:    jobsManager,
+		pool:    pool,
 	}
 }
@@ -0,0 +1,97 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"masm/v3/corpus"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRegistryCorpusID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "sub"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "sub", "corp"), []byte("PATH \"/data/corp\"\n"), 0644))
+	actions := NewActions(&corpus.CorporaSetup{RegistryDirPaths: []string{root}}, nil, nil, nil, nil)
+	engine := gin.New()
+	engine.GET("/registry/:corpusId", actions.GetRegistry)
+	engine.GET("/registry/:corpusId/:corpusIdInSubdir", actions.GetRegistry)
+
+	tests := []struct {
+		url    string
+		status int
+	}{
+		{"/registry/sub/corp", http.StatusOK},
+		{"/registry/missing", http.StatusNotFound},
+		{"/registry/..", http.StatusBadRequest},
+		{"/registry/sub/..", http.StatusBadRequest},
+		{"/registry/.hidden", http.StatusBadRequest},
+	}
+	for _, tst := range tests {
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tst.url, nil)
+		engine.ServeHTTP(resp, req)
+		assert.Equal(t, tst.status, resp.Code, tst.url)
+	}
+}
+
+func TestPosSetActionsErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	writeTagsetFile(t, dir, "my_tagset.json", testTagsetJSON)
+	tagsets, err := NewTagsetCatalogue(dir)
+	assert.NoError(t, err)
+	actions := NewActions(&corpus.CorporaSetup{}, tagsets, nil, nil, nil)
+	engine := gin.New()
+	engine.GET("/tagsets/:posId", actions.GetPosSetInfo)
+	engine.POST("/tagsets", actions.CreatePosSet)
+	engine.DELETE("/tagsets/:posId", actions.DeletePosSet)
+
+	tests := []struct {
+		method string
+		url    string
+		body   string
+		status int
+		code   corpus.ErrorCode
+	}{
+		{http.MethodGet, "/tagsets/missing", "", http.StatusNotFound, corpus.ErrorCodeNotFound},
+		{http.MethodPost, "/tagsets", testTagsetJSON, http.StatusConflict, corpus.ErrorCodeAlreadyExists},
+		{http.MethodPost, "/tagsets", "{", http.StatusBadRequest, corpus.ErrorCodeInvalidRequest},
+		{http.MethodDelete, "/tagsets/" + builtinPosList[0].ID, "", http.StatusForbidden, corpus.ErrorCodeForbidden},
+	}
+	for _, tst := range tests {
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest(tst.method, tst.url, strings.NewReader(tst.body))
+		engine.ServeHTTP(resp, req)
+		assert.Equal(t, tst.status, resp.Code, tst.url)
+		var ans corpus.ErrorResponse
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &ans))
+		assert.Equal(t, tst.code, ans.ErrorCode, tst.url)
+	}
+}
@@ -28,9 +28,16 @@ type boolValue struct {
 	TargetType bool   `json:"targetType"`
 }
 
-var availBoolValues = []boolValue{
-	{Value: "y", TargetType: true},
-	{Value: "n", TargetType: false},
-	{Value: "yes", TargetType: true},
-	{Value: "no", TargetType: false},
-}
+var (
+	boolValueYes = boolValue{Value: "yes", TargetType: true}
+	boolValueNo  = boolValue{Value: "no", TargetType: false}
+
+	availBoolValues = []boolValue{
+		{Value: "y", TargetType: true},
+		{Value: "n", TargetType: false},
+		boolValueYes,
+		boolValueNo,
+	}
+
+	cncMultisep = multisep{Value: "|", Description: "A default value used within the CNC"}
+)
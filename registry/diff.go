@@ -0,0 +1,224 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"masm/v3/registry/parser"
+)
+
+// PropValue is a property added to or removed from a registry
+type PropValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// PropChange is a property with different values
+// in compared registries
+type PropChange struct {
+	Key      string `json:"key"`
+	OldValue string `json:"oldValue"`
+	NewValue string `json:"newValue"`
+}
+
+// PropsDiff describes differences between two sets
+// of properties
+type PropsDiff struct {
+	Added   []PropValue  `json:"added"`
+	Removed []PropValue  `json:"removed"`
+	Changed []PropChange `json:"changed"`
+}
+
+func (d PropsDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// AttrChange describes a changed attribute
+type AttrChange struct {
+	Name       string    `json:"name"`
+	Properties PropsDiff `json:"properties"`
+}
+
+// AttrsDiff describes differences between two lists
+// of attributes
+type AttrsDiff struct {
+	Added   []string     `json:"added"`
+	Removed []string     `json:"removed"`
+	Changed []AttrChange `json:"changed"`
+}
+
+func (d AttrsDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// StructChange describes a changed structure including
+// its attributes
+type StructChange struct {
+	Name       string    `json:"name"`
+	Properties PropsDiff `json:"properties"`
+	Attributes AttrsDiff `json:"attributes"`
+}
+
+// StructsDiff describes differences between two lists
+// of structures
+type StructsDiff struct {
+	Added   []string       `json:"added"`
+	Removed []string       `json:"removed"`
+	Changed []StructChange `json:"changed"`
+}
+
+func (d StructsDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff is a semantic difference between two registries.
+// Comments and formatting are ignored.
+type Diff struct {
+	Left       string      `json:"left"`
+	Right      string      `json:"right"`
+	Properties PropsDiff   `json:"properties"`
+	Attributes AttrsDiff   `json:"attributes"`
+	Structures StructsDiff `json:"structures"`
+}
+
+func (d *Diff) IsEmpty() bool {
+	return d.Properties.IsEmpty() && d.Attributes.IsEmpty() && d.Structures.IsEmpty()
+}
+
+// collectProps returns properties in the order of their
+// first occurrence. In case a key is repeated, the last
+// value is used.
+func collectProps(entries []*parser.Entry) ([]string, map[string]string) {
+	keys := make([]string, 0, len(entries))
+	values := make(map[string]string)
+	for _, e := range entries {
+		if e.Property == nil {
+			continue
+		}
+		if _, ok := values[e.Property.Key]; !ok {
+			keys = append(keys, e.Property.Key)
+		}
+		values[e.Property.Key] = e.Property.Value
+	}
+	return keys, values
+}
+
+func diffProps(left, right []*parser.Entry) PropsDiff {
+	ans := PropsDiff{
+		Added:   []PropValue{},
+		Removed: []PropValue{},
+		Changed: []PropChange{},
+	}
+	leftKeys, leftValues := collectProps(left)
+	rightKeys, rightValues := collectProps(right)
+	for _, k := range leftKeys {
+		rv, ok := rightValues[k]
+		if !ok {
+			ans.Removed = append(ans.Removed, PropValue{Key: k, Value: leftValues[k]})
+
+		} else if rv != leftValues[k] {
+			ans.Changed = append(
+				ans.Changed, PropChange{Key: k, OldValue: leftValues[k], NewValue: rv})
+		}
+	}
+	for _, k := range rightKeys {
+		if _, ok := leftValues[k]; !ok {
+			ans.Added = append(ans.Added, PropValue{Key: k, Value: rightValues[k]})
+		}
+	}
+	return ans
+}
+
+func diffAttrs(left, right []*parser.Attribute) AttrsDiff {
+	ans := AttrsDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []AttrChange{},
+	}
+	rightIdx := make(map[string]*parser.Attribute)
+	for _, a := range right {
+		rightIdx[a.Name] = a
+	}
+	leftIdx := make(map[string]*parser.Attribute)
+	for _, a := range left {
+		leftIdx[a.Name] = a
+		ra, ok := rightIdx[a.Name]
+		if !ok {
+			ans.Removed = append(ans.Removed, a.Name)
+			continue
+		}
+		pd := diffProps(a.Entries, ra.Entries)
+		if !pd.IsEmpty() {
+			ans.Changed = append(ans.Changed, AttrChange{Name: a.Name, Properties: pd})
+		}
+	}
+	for _, a := range right {
+		if _, ok := leftIdx[a.Name]; !ok {
+			ans.Added = append(ans.Added, a.Name)
+		}
+	}
+	return ans
+}
+
+func diffStructs(left, right []*parser.Structure) StructsDiff {
+	ans := StructsDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []StructChange{},
+	}
+	rightIdx := make(map[string]*parser.Structure)
+	for _, s := range right {
+		rightIdx[s.Name] = s
+	}
+	leftIdx := make(map[string]*parser.Structure)
+	for _, s := range left {
+		leftIdx[s.Name] = s
+		rs, ok := rightIdx[s.Name]
+		if !ok {
+			ans.Removed = append(ans.Removed, s.Name)
+			continue
+		}
+		change := StructChange{
+			Name:       s.Name,
+			Properties: diffProps(s.Entries, rs.Entries),
+			Attributes: diffAttrs(s.Attributes(), rs.Attributes()),
+		}
+		if !change.Properties.IsEmpty() || !change.Attributes.IsEmpty() {
+			ans.Changed = append(ans.Changed, change)
+		}
+	}
+	for _, s := range right {
+		if _, ok := leftIdx[s.Name]; !ok {
+			ans.Added = append(ans.Added, s.Name)
+		}
+	}
+	return ans
+}
+
+// DiffRegistries produces a semantic diff of two registries.
+// The leftName and rightName arguments are used only to label
+// the compared registries in the result.
+func DiffRegistries(leftName string, left *parser.Document, rightName string, right *parser.Document) *Diff {
+	return &Diff{
+		Left:       leftName,
+		Right:      rightName,
+		Properties: diffProps(left.Entries, right.Entries),
+		Attributes: diffAttrs(left.Attributes(), right.Attributes()),
+		Structures: diffStructs(left.Structures(), right.Structures()),
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"masm/v3/corpus"
+	"masm/v3/registry/parser"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseTestRegistry(t *testing.T, name string) *parser.Document {
+	doc, err := parser.ParseRegistryFile(filepath.Join("parser", "testdata", name))
+	assert.NoError(t, err)
+	return doc
+}
+
+func copyTestRegistry(t *testing.T, name, dstPath string) {
+	src, err := os.ReadFile(filepath.Join("parser", "testdata", name))
+	assert.NoError(t, err)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(dstPath), 0755))
+	assert.NoError(t, os.WriteFile(dstPath, src, 0644))
+}
+
+func TestDiffRegistriesSame(t *testing.T) {
+	diff := DiffRegistries(
+		"a", parseTestRegistry(t, "syn_mixed"), "b", parseTestRegistry(t, "syn_mixed"))
+	assert.True(t, diff.IsEmpty())
+	assert.Equal(t, "a", diff.Left)
+	assert.Equal(t, "b", diff.Right)
+}
+
+func TestDiffRegistriesProperties(t *testing.T) {
+	diff := DiffRegistries(
+		"susanne", parseTestRegistry(t, "susanne"),
+		"intercorp_cs", parseTestRegistry(t, "intercorp_cs"),
+	)
+	assert.Equal(
+		t,
+		[]PropValue{
+			{Key: "ALIGNED", Value: "intercorp_v13_en,intercorp_v13_de"},
+			{Key: "ALIGNSTRUCT", Value: "seg"},
+		},
+		diff.Properties.Added,
+	)
+	removed := make([]string, len(diff.Properties.Removed))
+	for i, v := range diff.Properties.Removed {
+		removed[i] = v.Key
+	}
+	assert.Equal(
+		t,
+		[]string{
+			"VERTICAL", "LANGUAGE", "LOCALE", "INFO", "DEFAULTATTR", "TAGSETDOC",
+			"SUBCORPATTRS", "FULLREF", "SHORTREF", "WPOSLIST",
+		},
+		removed,
+	)
+	assert.Equal(
+		t,
+		[]PropChange{
+			{Key: "NAME", OldValue: "Susanne", NewValue: "InterCorp v13 - Czech"},
+			{
+				Key:      "PATH",
+				OldValue: "/var/lib/manatee/data/susanne/",
+				NewValue: "/cnk/run/manatee/data/intercorp_v13_cs/",
+			},
+		},
+		diff.Properties.Changed,
+	)
+}
+
+func TestDiffRegistriesAttributes(t *testing.T) {
+	diff := DiffRegistries(
+		"susanne", parseTestRegistry(t, "susanne"),
+		"syn_mixed", parseTestRegistry(t, "syn_mixed"),
+	)
+	assert.Equal(t, []string{"pos", "lemma_lc"}, diff.Attributes.Added)
+	assert.Equal(t, []string{"lc"}, diff.Attributes.Removed)
+	assert.Equal(
+		t,
+		[]AttrChange{
+			{
+				Name: "lemma",
+				Properties: PropsDiff{
+					Added: []PropValue{
+						{Key: "MULTIVALUE", Value: "yes"},
+						{Key: "MULTISEP", Value: "|"},
+					},
+					Removed: []PropValue{{Key: "LABEL", Value: "lemma"}},
+					Changed: []PropChange{},
+				},
+			},
+			{
+				Name: "tag",
+				Properties: PropsDiff{
+					Added:   []PropValue{{Key: "MULTIVALUE", Value: "n"}},
+					Removed: []PropValue{},
+					Changed: []PropChange{},
+				},
+			},
+		},
+		diff.Attributes.Changed,
+	)
+}
+
+func TestDiffRegistriesStructures(t *testing.T) {
+	diff := DiffRegistries(
+		"susanne", parseTestRegistry(t, "susanne"),
+		"syn_mixed", parseTestRegistry(t, "syn_mixed"),
+	)
+	assert.Equal(t, []string{"g"}, diff.Structures.Added)
+	assert.Equal(t, []string{"p"}, diff.Structures.Removed)
+	if assert.Len(t, diff.Structures.Changed, 2) {
+		doc := diff.Structures.Changed[0]
+		assert.Equal(t, "doc", doc.Name)
+		assert.Equal(t, []PropValue{{Key: "DEFAULTVALUE", Value: ""}}, doc.Properties.Added)
+		assert.Empty(t, doc.Properties.Removed)
+		assert.Equal(t, []string{"title", "author"}, doc.Attributes.Added)
+		assert.Equal(t, []string{"file", "n", "wordcount"}, doc.Attributes.Removed)
+		assert.Empty(t, doc.Attributes.Changed)
+
+		s := diff.Structures.Changed[1]
+		assert.Equal(t, "s", s.Name)
+		assert.Equal(
+			t,
+			[]PropValue{
+				{Key: "DISPLAYTAG", Value: "0"},
+				{Key: "DISPLAYBEGIN", Value: "_EMPTY_"},
+			},
+			s.Properties.Added,
+		)
+		assert.True(t, s.Attributes.IsEmpty())
+	}
+}
+
+func TestDiffRegistriesStructAttributes(t *testing.T) {
+	right := parseTestRegistry(t, "syn_mixed")
+	for _, e := range right.Structure("doc").Attribute("title").Entries {
+		if e.Property != nil && e.Property.Key == "MULTISEP" {
+			e.Property.Value = ";"
+		}
+	}
+	diff := DiffRegistries("left", parseTestRegistry(t, "syn_mixed"), "right", right)
+	assert.True(t, diff.Properties.IsEmpty())
+	assert.True(t, diff.Attributes.IsEmpty())
+	assert.Empty(t, diff.Structures.Added)
+	assert.Empty(t, diff.Structures.Removed)
+	assert.Equal(
+		t,
+		[]StructChange{
+			{
+				Name: "doc",
+				Properties: PropsDiff{
+					Added:   []PropValue{},
+					Removed: []PropValue{},
+					Changed: []PropChange{},
+				},
+				Attributes: AttrsDiff{
+					Added:   []string{},
+					Removed: []string{},
+					Changed: []AttrChange{
+						{
+							Name: "title",
+							Properties: PropsDiff{
+								Added:   []PropValue{},
+								Removed: []PropValue{},
+								Changed: []PropChange{{Key: "MULTISEP", OldValue: "|", NewValue: ";"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		diff.Structures.Changed,
+	)
+}
+
+func TestFindRegistryFile(t *testing.T) {
+	root1 := t.TempDir()
+	root2 := t.TempDir()
+	copyTestRegistry(t, "susanne", filepath.Join(root1, "corp"))
+	copyTestRegistry(t, "intercorp_cs", filepath.Join(root2, "corp"))
+	copyTestRegistry(t, "syn_mixed", filepath.Join(root2, "omezeni", "corp"))
+	actions := NewActions(
+		&corpus.CorporaSetup{RegistryDirPaths: []string{root1, root2}}, nil, nil, nil, nil)
+
+	tests := []struct {
+		variant corpus.CorpusVariant
+		root    string
+		path    string
+		err     error
+	}{
+		{corpus.CorpusVariantPrimary, "", filepath.Join(root1, "corp"), nil},
+		{corpus.CorpusVariantLimited, "", filepath.Join(root2, "omezeni", "corp"), nil},
+		{corpus.CorpusVariantPrimary, root2, filepath.Join(root2, "corp"), nil},
+		{corpus.CorpusVariantLimited, root2, filepath.Join(root2, "omezeni", "corp"), nil},
+		{corpus.CorpusVariantLimited, root1, "", nil},
+		{corpus.CorpusVariant("foo"), "", "", corpus.ErrInvalidRequest},
+		{corpus.CorpusVariantPrimary, t.TempDir(), "", corpus.ErrInvalidRequest},
+	}
+	for _, tst := range tests {
+		path, err := actions.findRegistryFile("corp", tst.variant, tst.root)
+		if tst.err != nil {
+			assert.ErrorIs(t, err, tst.err, tst.variant)
+
+		} else {
+			assert.NoError(t, err, tst.variant)
+		}
+		assert.Equal(t, tst.path, path, tst.variant)
+	}
+}
+
+func TestDiffRegistriesAction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	root := t.TempDir()
+	copyTestRegistry(t, "susanne", filepath.Join(root, "corp"))
+	copyTestRegistry(t, "syn_mixed", filepath.Join(root, "omezeni", "corp"))
+	actions := NewActions(&corpus.CorporaSetup{RegistryDirPaths: []string{root}}, nil, nil, nil, nil)
+	engine := gin.New()
+	engine.GET("/registry/:corpusId/_diff", actions.DiffRegistries)
+
+	resp := httptest.NewRecorder()
+	engine.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/registry/corp/_diff", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var diff Diff
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &diff))
+	assert.Equal(t, filepath.Join(root, "corp"), diff.Left)
+	assert.Equal(t, filepath.Join(root, "omezeni", "corp"), diff.Right)
+	assert.Equal(t, []string{"g"}, diff.Structures.Added)
+
+	resp = httptest.NewRecorder()
+	engine.ServeHTTP(
+		resp, httptest.NewRequest(http.MethodGet, "/registry/corp/_diff?variant1=omezeni&variant2=primary", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &diff))
+	assert.Equal(t, filepath.Join(root, "omezeni", "corp"), diff.Left)
+	assert.Equal(t, []string{"p"}, diff.Structures.Added)
+
+	resp = httptest.NewRecorder()
+	engine.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/registry/corp/_diff?variant2=foo", nil))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
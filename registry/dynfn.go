@@ -20,83 +20,308 @@ package registry
 
 import (
 	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 
-	"github.com/rs/zerolog/log"
+	"golang.org/x/text/encoding/ianaindex"
 )
 
 // note: the function description data are taken from https://www.sketchengine.eu/dynamic-functions/
 
+// DynFnArgKind specifies a type of a dynamic function argument
+type DynFnArgKind string
+
+const (
+	ArgKindString   DynFnArgKind = "string"
+	ArgKindInt      DynFnArgKind = "int"
+	ArgKindChar     DynFnArgKind = "char"
+	ArgKindLocale   DynFnArgKind = "locale"
+	ArgKindEncoding DynFnArgKind = "encoding"
+
+	// maxDynFnArgs is a limit given by Manatee (ARG1, ARG2)
+	maxDynFnArgs = 2
+)
+
+var localeRegexp = regexp.MustCompile(`^(C|POSIX|[a-z]{2,3}(_[A-Z]{2})?(\.[A-Za-z0-9-]+)?(@[a-z]+)?)$`)
+
+// FuntypeCode returns a code used in registry FUNTYPE
+// for the argument kind
+func (k DynFnArgKind) FuntypeCode() (byte, bool) {
+	switch k {
+	case ArgKindString, ArgKindLocale, ArgKindEncoding:
+		return 's', true
+	case ArgKindInt:
+		return 'i', true
+	case ArgKindChar:
+		return 'c', true
+	}
+	return 0, false
+}
+
+// ValidateValue tests whether a registry ARGn value
+// is valid for the argument kind
+func (k DynFnArgKind) ValidateValue(value string) error {
+	switch k {
+	case ArgKindString:
+		return nil
+	case ArgKindInt:
+		// Manatee takes the value as a C int and all the functions
+		// use it as a count or a position so it must not be negative
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("value '%s' is not an integer in a supported range", value)
+		}
+		if n < 0 {
+			return fmt.Errorf("value '%s' must not be negative", value)
+		}
+	case ArgKindChar:
+		if len(value) != 1 {
+			return fmt.Errorf("value '%s' is not a single character", value)
+		}
+	case ArgKindLocale:
+		if !localeRegexp.MatchString(value) {
+			return fmt.Errorf("value '%s' is not a valid locale", value)
+		}
+	case ArgKindEncoding:
+		if enc, err := ianaindex.IANA.Encoding(value); err != nil || enc == nil {
+			return fmt.Errorf("value '%s' is not a known encoding", value)
+		}
+	default:
+		return fmt.Errorf("unknown argument kind '%s'", k)
+	}
+	return nil
+}
+
+// DynFnArg describes a single argument of a dynamic function
+// (not including the source value which is always the first one).
+// Optional arguments may be omitted in a registry but only
+// from the end of the argument list.
+type DynFnArg struct {
+	Name     string       `json:"name"`
+	Kind     DynFnArgKind `json:"kind"`
+	Optional bool         `json:"optional,omitempty"`
+}
+
+// DynFnCallError describes a problem with FUNTYPE or ARGn
+// properties of a dynamic attribute
+type DynFnCallError struct {
+	Property string `json:"property"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+// DynFnCallErrors is a list of problems with
+// a dynamic function call (i.e. FUNTYPE + ARGn)
+type DynFnCallErrors []DynFnCallError
+
+func (e DynFnCallErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, v := range e {
+		msgs[i] = v.Property + ": " + v.Message
+	}
+	return "invalid dynamic function call: " + strings.Join(msgs, "; ")
+}
+
+// Details returns individual errors as strings
+func (e DynFnCallErrors) Details() []string {
+	ans := make([]string, len(e))
+	for i, v := range e {
+		ans[i] = v.Property + ": " + v.Message
+	}
+	return ans
+}
+
 // DynFn describes Manatee dynamic function
 // (either an internal one or some external)
 type DynFn struct {
 	Name        string
-	Args        []string
+	Args        []DynFnArg
 	Dynlib      string
 	Description string
+
+	// Problem is non-empty in case the dynlib and the function
+	// description disagree (see DynFnProblem* constants)
+	Problem string
+
+	// SignatureErrors contains problems with the argument
+	// definitions (only for functions described in a dynlib
+	// sidecar file)
+	SignatureErrors []string
 }
 
-// Funtype produces function type codes as required in
-// corpus registry files. Please note that the codes are
-// based only on arguments starting from the second one as
-// the first argument is always the same (the source string).
+// MinArgs returns number of required arguments
+func (df *DynFn) MinArgs() int {
+	for i, arg := range df.Args {
+		if arg.Optional {
+			return i
+		}
+	}
+	return len(df.Args)
+}
+
+// ValidateSignature tests argument definitions
+func (df *DynFn) ValidateSignature() []string {
+	ans := make([]string, 0)
+	if len(df.Args) > maxDynFnArgs {
+		ans = append(
+			ans, fmt.Sprintf("function has %d arguments, max. %d supported", len(df.Args), maxDynFnArgs))
+	}
+	for i, arg := range df.Args {
+		if _, ok := arg.Kind.FuntypeCode(); !ok {
+			ans = append(ans, fmt.Sprintf("argument %d has unknown kind '%s'", i+1, arg.Kind))
+		}
+		if i > 0 && df.Args[i-1].Optional && !arg.Optional {
+			ans = append(ans, fmt.Sprintf("required argument %d follows an optional one", i+1))
+		}
+	}
+	return ans
+}
+
+// FuntypeFor produces function type code as required in
+// corpus registry files for a call with numArgs arguments.
+// The source value (always the first argument of the C function)
+// is not encoded. For a call without arguments, "0" is returned.
+func (df *DynFn) FuntypeFor(numArgs int) (string, error) {
+	if numArgs < df.MinArgs() || numArgs > len(df.Args) {
+		return "", fmt.Errorf(
+			"function %s accepts %d to %d argument(s), %d provided",
+			df.Name, df.MinArgs(), len(df.Args), numArgs)
+	}
+	if numArgs == 0 {
+		return "0", nil
+	}
+	var ans strings.Builder
+	for _, arg := range df.Args[:numArgs] {
+		code, ok := arg.Kind.FuntypeCode()
+		if !ok {
+			return "", fmt.Errorf(
+				"argument %s of function %s has unknown kind '%s'", arg.Name, df.Name, arg.Kind)
+		}
+		ans.WriteByte(code)
+	}
+	return ans.String(), nil
+}
+
+// Funtype produces function type code for a call
+// with all the arguments (including optional ones).
+// For invalid signatures, an empty string is returned.
 func (df *DynFn) Funtype() string {
-	usedArgs := df.Args[1:]
-	ans := make([]string, len(usedArgs))
-	for i, arg := range usedArgs {
-		switch arg {
-		case "str":
-			ans[i] = "s"
-		case "n":
-			ans[i] = "i"
-		case "c":
-			ans[i] = "c"
-		case "pointer":
-			ans[i] = "0"
-		case "locale":
-			ans[i] = "s"
-		case "enc":
-			ans[i] = "s"
-		default:
-			log.Warn().Msgf("Invalid Arg type: %s", arg)
-			ans[i] = "?"
+	ans, err := df.FuntypeFor(len(df.Args))
+	if err != nil {
+		return ""
+	}
+	return ans
+}
+
+// ValidateCall tests registry FUNTYPE and ARGn values
+// against the function signature. An empty funtype is treated
+// as "0" (i.e. no arguments).
+func (df *DynFn) ValidateCall(funtype string, args []string) DynFnCallErrors {
+	ans := make(DynFnCallErrors, 0)
+	if len(args) < df.MinArgs() || len(args) > len(df.Args) {
+		ans = append(ans, DynFnCallError{
+			Property: "ARG" + strconv.Itoa(len(args)+1),
+			Code:     "invalidArgCount",
+			Message: fmt.Sprintf(
+				"function %s accepts %d to %d argument(s), %d provided",
+				df.Name, df.MinArgs(), len(df.Args), len(args)),
+		})
+		return ans
+	}
+	for i, value := range args {
+		if err := df.Args[i].Kind.ValidateValue(value); err != nil {
+			ans = append(ans, DynFnCallError{
+				Property: "ARG" + strconv.Itoa(i+1),
+				Code:     "invalidArgValue",
+				Message:  fmt.Sprintf("argument %s: %s", df.Args[i].Name, err),
+			})
 		}
 	}
-	return strings.Join(ans, "")
+	expected, err := df.FuntypeFor(len(args))
+	if err != nil {
+		ans = append(ans, DynFnCallError{
+			Property: "FUNTYPE",
+			Code:     "invalidSignature",
+			Message:  err.Error(),
+		})
+
+	} else if funtype != expected && !(expected == "0" && funtype == "") {
+		ans = append(ans, DynFnCallError{
+			Property: "FUNTYPE",
+			Code:     "funtypeMismatch",
+			Message: fmt.Sprintf(
+				"FUNTYPE '%s' does not match function %s with %d argument(s) (expected '%s')",
+				funtype, df.Name, len(args), expected),
+		})
+	}
+	return ans
 }
 
 func (df DynFn) MarshalJSON() ([]byte, error) {
+	args := df.Args
+	if args == nil {
+		args = []DynFnArg{}
+	}
 	return json.Marshal(&struct {
-		Name        string   `json:"name"`
-		Args        []string `json:"args"`
-		Dynlib      string   `json:"dynlib"`
-		Description string   `json:"description"`
-		Funtype     string   `json:"funtype"`
+		Name            string     `json:"name"`
+		Args            []DynFnArg `json:"args"`
+		MinArgs         int        `json:"minArgs"`
+		Dynlib          string     `json:"dynlib"`
+		Description     string     `json:"description"`
+		Funtype         string     `json:"funtype"`
+		Problem         string     `json:"problem,omitempty"`
+		SignatureErrors []string   `json:"signatureErrors,omitempty"`
 	}{
-		Name:        df.Name,
-		Args:        df.Args,
-		Dynlib:      df.Dynlib,
-		Description: df.Description,
-		Funtype:     df.Funtype(),
+		Name:            df.Name,
+		Args:            args,
+		MinArgs:         df.MinArgs(),
+		Dynlib:          df.Dynlib,
+		Description:     df.Description,
+		Funtype:         df.Funtype(),
+		Problem:         df.Problem,
+		SignatureErrors: df.SignatureErrors,
 	})
 }
 
+var (
+	argN      = DynFnArg{Name: "n", Kind: ArgKindInt}
+	argC      = DynFnArg{Name: "c", Kind: ArgKindChar}
+	argLocale = DynFnArg{Name: "locale", Kind: ArgKindLocale}
+	argEnc    = DynFnArg{Name: "enc", Kind: ArgKindEncoding}
+
+	// for utf-8 functions, the locale argument is ignored by Manatee
+	// but registries often contain it (e.g. FUNTYPE s, ARG1 "C")
+	argIgnoredLocale = DynFnArg{Name: "locale", Kind: ArgKindLocale, Optional: true}
+)
+
 var dynFnList = []DynFn{
-	{"striplastn", []string{"str", "n"}, "internal", "returns str striped from last n characters"},
-	{"lowercase", []string{"str", "locale"}, "internal", "returns str in lowercase (for any single-byte encoding and the corresponding locale)"},
-	{"utf8lowercase", []string{"str"}, "internal", "returns str in lowercase (for any utf-8 encoded string str)"},
-	{"utf8uppercase", []string{"str"}, "internal", "returns str in uppercase (for any utf-8 encoded string str)"},
-	{"utf8capital", []string{"str"}, "internal", "returns str with first character capitalized (for any utf-8 encoded string str)"},
-	{"getfirstn", []string{"str", "n"}, "internal", "returns first n characters of str"},
-	{"getlastn", []string{"str", "n"}, "internal", "returns last n characters of str (for any single-byte encoding)"},
-	{"utf8getlastn", []string{"str", "n"}, "internal", "returns last n characters of str (for any utf-8 encoded string)"},
-	{"getfirstbysep", []string{"str", "c"}, "internal", "returns prefix of str up to the character c (excluding)"},
-	{"getnbysep", []string{"str", "c", "n"}, "internal", "returns n-th component of str according to the delimiter c (excluding)"},
-	{"getnchar", []string{"str", "n"}, "internal", "returns n-th character of str"},
-	{"getnextchars", []string{"str", "c", "n"}, "internal", "returns n characters after character c"},
-	{"getnextchar", []string{"str", "c"}, "internal", "returns the character after character c"},
-	{"url2domain", []string{"str", "n"}, "internal", "returns n-th component of the URL (0 = web domain, 1 = top level domain, 2 = second level domain)"},
-	{"ascii", []string{"str", "enc", "locale"}, "internal", "returns ASCII transliteration of the string according to the given encoding and locale"},
+	{Name: "striplastn", Args: []DynFnArg{argN}, Dynlib: "internal", Description: "returns str striped from last n characters"},
+	{Name: "lowercase", Args: []DynFnArg{argLocale}, Dynlib: "internal", Description: "returns str in lowercase (for any single-byte encoding and the corresponding locale)"},
+	{Name: "utf8lowercase", Args: []DynFnArg{argIgnoredLocale}, Dynlib: "internal", Description: "returns str in lowercase (for any utf-8 encoded string str)"},
+	{Name: "utf8uppercase", Args: []DynFnArg{argIgnoredLocale}, Dynlib: "internal", Description: "returns str in uppercase (for any utf-8 encoded string str)"},
+	{Name: "utf8capital", Args: []DynFnArg{argIgnoredLocale}, Dynlib: "internal", Description: "returns str with first character capitalized (for any utf-8 encoded string str)"},
+	{Name: "getfirstn", Args: []DynFnArg{argN}, Dynlib: "internal", Description: "returns first n characters of str"},
+	{Name: "getlastn", Args: []DynFnArg{argN}, Dynlib: "internal", Description: "returns last n characters of str (for any single-byte encoding)"},
+	{Name: "utf8getlastn", Args: []DynFnArg{argN}, Dynlib: "internal", Description: "returns last n characters of str (for any utf-8 encoded string)"},
+	{Name: "getfirstbysep", Args: []DynFnArg{argC}, Dynlib: "internal", Description: "returns prefix of str up to the character c (excluding)"},
+	{Name: "getnbysep", Args: []DynFnArg{argC, argN}, Dynlib: "internal", Description: "returns n-th component of str according to the delimiter c (excluding)"},
+	{Name: "getnchar", Args: []DynFnArg{argN}, Dynlib: "internal", Description: "returns n-th character of str"},
+	{Name: "getnextchars", Args: []DynFnArg{argC, argN}, Dynlib: "internal", Description: "returns n characters after character c"},
+	{Name: "getnextchar", Args: []DynFnArg{argC}, Dynlib: "internal", Description: "returns the character after character c"},
+	{Name: "url2domain", Args: []DynFnArg{argN}, Dynlib: "internal", Description: "returns n-th component of the URL (0 = web domain, 1 = top level domain, 2 = second level domain)"},
+	{Name: "ascii", Args: []DynFnArg{argEnc, argLocale}, Dynlib: "internal", Description: "returns ASCII transliteration of the string according to the given encoding and locale"},
+}
+
+// findInternalDynFn searches for a Manatee internal function
+func findInternalDynFn(name string) (DynFn, bool) {
+	for _, fn := range dynFnList {
+		if fn.Name == name {
+			return fn, true
+		}
+	}
+	return DynFn{}, false
 }
 
 type dynlibItem struct {
@@ -0,0 +1,81 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuntype(t *testing.T) {
+	fn, _ := findInternalDynFn("getnbysep")
+	assert.Equal(t, "ci", fn.Funtype())
+	fn, _ = findInternalDynFn("ascii")
+	assert.Equal(t, "ss", fn.Funtype())
+	fn, _ = findInternalDynFn("utf8lowercase")
+	assert.Equal(t, "s", fn.Funtype())
+	ft, err := fn.FuntypeFor(0)
+	assert.NoError(t, err)
+	assert.Equal(t, "0", ft)
+	_, err = fn.FuntypeFor(2)
+	assert.Error(t, err)
+}
+
+func TestValidateCall(t *testing.T) {
+	fn, _ := findInternalDynFn("utf8lowercase")
+	assert.Empty(t, fn.ValidateCall("s", []string{"C"}))
+	assert.Empty(t, fn.ValidateCall("0", []string{}))
+	assert.Empty(t, fn.ValidateCall("", []string{}))
+
+	fn, _ = findInternalDynFn("getnbysep")
+	assert.Empty(t, fn.ValidateCall("ci", []string{"|", "1"}))
+	errs := fn.ValidateCall("c?", []string{"|", "1"})
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "FUNTYPE", errs[0].Property)
+	assert.Equal(t, "funtypeMismatch", errs[0].Code)
+
+	errs = fn.ValidateCall("ci", []string{"||", "x"})
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "ARG1", errs[0].Property)
+	assert.Equal(t, "ARG2", errs[1].Property)
+
+	errs = fn.ValidateCall("c", []string{"|"})
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "invalidArgCount", errs[0].Code)
+
+	fn, _ = findInternalDynFn("ascii")
+	assert.Empty(t, fn.ValidateCall("ss", []string{"UTF-8", "cs_CZ.UTF-8"}))
+	assert.Len(t, fn.ValidateCall("ss", []string{"foo-enc", "cs_CZ"}), 1)
+}
+
+func TestValidateSignature(t *testing.T) {
+	fn := DynFn{
+		Name: "foo",
+		Args: []DynFnArg{
+			{Name: "a", Kind: ArgKindInt, Optional: true},
+			{Name: "b", Kind: "pointer"},
+		},
+	}
+	assert.Len(t, fn.ValidateSignature(), 2)
+	assert.Equal(t, "", fn.Funtype())
+	for _, fn := range dynFnList {
+		assert.Empty(t, fn.ValidateSignature(), fn.Name)
+	}
+}
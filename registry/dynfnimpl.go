@@ -0,0 +1,227 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// goDynFn is a Go implementation of a Manatee internal
+// dynamic function. The args correspond to registry ARG1, ARG2
+// values (i.e. without the source value).
+type goDynFn func(value string, args []string) (string, error)
+
+// internalDynFnImpl contains Go implementations of Manatee internal
+// functions. Their behaviour mimics the Manatee ones including the
+// fact that non-utf8 functions work with bytes. The `ascii` function
+// is an approximation of iconv transliteration.
+var internalDynFnImpl = map[string]goDynFn{
+	"striplastn": func(value string, args []string) (string, error) {
+		n, err := dynFnIntArg(args, 0)
+		if err != nil {
+			return "", err
+		}
+		if len(value) <= n {
+			return "", nil
+		}
+		return value[:len(value)-n], nil
+	},
+	"lowercase": func(value string, args []string) (string, error) {
+		// with a utf-8 locale, the byte-oriented tolower()
+		// affects only ASCII characters
+		return strings.Map(func(r rune) rune {
+			if r < utf8.RuneSelf {
+				return unicode.ToLower(r)
+			}
+			return r
+		}, value), nil
+	},
+	"utf8lowercase": func(value string, args []string) (string, error) {
+		return strings.ToLower(value), nil
+	},
+	"utf8uppercase": func(value string, args []string) (string, error) {
+		return strings.ToUpper(value), nil
+	},
+	"utf8capital": func(value string, args []string) (string, error) {
+		r, size := utf8.DecodeRuneInString(value)
+		if size == 0 {
+			return value, nil
+		}
+		return string(unicode.ToUpper(r)) + value[size:], nil
+	},
+	"getfirstn": func(value string, args []string) (string, error) {
+		n, err := dynFnIntArg(args, 0)
+		if err != nil {
+			return "", err
+		}
+		if len(value) <= n {
+			return value, nil
+		}
+		return value[:n], nil
+	},
+	"getlastn": func(value string, args []string) (string, error) {
+		n, err := dynFnIntArg(args, 0)
+		if err != nil {
+			return "", err
+		}
+		if len(value) <= n {
+			return value, nil
+		}
+		return value[len(value)-n:], nil
+	},
+	"utf8getlastn": func(value string, args []string) (string, error) {
+		n, err := dynFnIntArg(args, 0)
+		if err != nil {
+			return "", err
+		}
+		runes := []rune(value)
+		if len(runes) <= n {
+			return value, nil
+		}
+		return string(runes[len(runes)-n:]), nil
+	},
+	"getfirstbysep": func(value string, args []string) (string, error) {
+		c, err := dynFnCharArg(args, 0)
+		if err != nil {
+			return "", err
+		}
+		if i := strings.IndexByte(value, c); i >= 0 {
+			return value[:i], nil
+		}
+		return value, nil
+	},
+	"getnbysep": func(value string, args []string) (string, error) {
+		c, err := dynFnCharArg(args, 0)
+		if err != nil {
+			return "", err
+		}
+		n, err := dynFnIntArg(args, 1)
+		if err != nil {
+			return "", err
+		}
+		items := strings.Split(value, string(c))
+		if n < 0 || n >= len(items) {
+			return "", nil
+		}
+		return items[n], nil
+	},
+	"getnchar": func(value string, args []string) (string, error) {
+		n, err := dynFnIntArg(args, 0)
+		if err != nil {
+			return "", err
+		}
+		// positions start from 1
+		if n < 1 || n > len(value) {
+			return "", nil
+		}
+		return value[n-1 : n], nil
+	},
+	"getnextchars": func(value string, args []string) (string, error) {
+		c, err := dynFnCharArg(args, 0)
+		if err != nil {
+			return "", err
+		}
+		n, err := dynFnIntArg(args, 1)
+		if err != nil {
+			return "", err
+		}
+		i := strings.IndexByte(value, c)
+		if i < 0 {
+			return "", nil
+		}
+		rest := value[i+1:]
+		if len(rest) <= n {
+			return rest, nil
+		}
+		return rest[:n], nil
+	},
+	"getnextchar": func(value string, args []string) (string, error) {
+		c, err := dynFnCharArg(args, 0)
+		if err != nil {
+			return "", err
+		}
+		i := strings.IndexByte(value, c)
+		if i < 0 || i+1 >= len(value) {
+			return "", nil
+		}
+		return value[i+1 : i+2], nil
+	},
+	"url2domain": func(value string, args []string) (string, error) {
+		n, err := dynFnIntArg(args, 0)
+		if err != nil {
+			return "", err
+		}
+		host := value
+		if i := strings.Index(host, "://"); i >= 0 {
+			host = host[i+3:]
+		}
+		if i := strings.IndexAny(host, "/:?#"); i >= 0 {
+			host = host[:i]
+		}
+		if n <= 0 {
+			return host, nil
+		}
+		labels := strings.Split(host, ".")
+		if n > len(labels) {
+			return host, nil
+		}
+		return strings.Join(labels[len(labels)-n:], "."), nil
+	},
+	"ascii": func(value string, args []string) (string, error) {
+		var ans strings.Builder
+		for _, r := range norm.NFD.String(value) {
+			if unicode.Is(unicode.Mn, r) {
+				continue
+			}
+			if r >= utf8.RuneSelf {
+				ans.WriteByte('?')
+				continue
+			}
+			ans.WriteRune(r)
+		}
+		return ans.String(), nil
+	},
+}
+
+func dynFnIntArg(args []string, idx int) (int, error) {
+	if idx >= len(args) {
+		return 0, fmt.Errorf("missing argument ARG%d", idx+1)
+	}
+	ans, err := strconv.ParseInt(args[idx], 10, 32)
+	if err != nil || ans < 0 {
+		return 0, fmt.Errorf("argument ARG%d must be a non-negative integer", idx+1)
+	}
+	return int(ans), nil
+}
+
+func dynFnCharArg(args []string, idx int) (byte, error) {
+	if idx >= len(args) {
+		return 0, fmt.Errorf("missing argument ARG%d", idx+1)
+	}
+	if len(args[idx]) != 1 {
+		return 0, fmt.Errorf("argument ARG%d must be a single character", idx+1)
+	}
+	return args[idx][0], nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"testing"
+
+	"masm/v3/corpus"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInternalDynFnImplCoversAllInternalFns(t *testing.T) {
+	for _, fn := range dynFnList {
+		_, ok := internalDynFnImpl[fn.Name]
+		assert.True(t, ok, "missing Go implementation of %s", fn.Name)
+	}
+}
+
+func TestInternalDynFnImpl(t *testing.T) {
+	tests := []struct {
+		fn     string
+		value  string
+		args   []string
+		result string
+	}{
+		{"striplastn", "houses", []string{"2"}, "hous"},
+		{"striplastn", "ab", []string{"3"}, ""},
+		{"lowercase", "Hello WORLD", []string{"C"}, "hello world"},
+		{"utf8lowercase", "ŽLUŤOUČKÝ Kůň", nil, "žluťoučký kůň"},
+		{"utf8uppercase", "žluťoučký", nil, "ŽLUŤOUČKÝ"},
+		{"utf8capital", "čtvrtek", nil, "Čtvrtek"},
+		{"utf8capital", "", nil, ""},
+		{"getfirstn", "NNFS1-----A----", []string{"2"}, "NN"},
+		{"getfirstn", "N", []string{"2"}, "N"},
+		{"getlastn", "walked", []string{"2"}, "ed"},
+		{"utf8getlastn", "kůň", []string{"2"}, "ůň"},
+		{"getfirstbysep", "be-verb", []string{"-"}, "be"},
+		{"getfirstbysep", "be", []string{"-"}, "be"},
+		{"getnbysep", "a|b|c", []string{"|", "1"}, "b"},
+		{"getnbysep", "a|b|c", []string{"|", "5"}, ""},
+		{"getnchar", "VB-S---3P-AA---", []string{"1"}, "V"},
+		{"getnchar", "VB", []string{"3"}, ""},
+		{"getnextchars", "lemma-nn", []string{"-", "1"}, "n"},
+		{"getnextchars", "lemma-nn", []string{"-", "5"}, "nn"},
+		{"getnextchar", "lemma-v", []string{"-"}, "v"},
+		{"getnextchar", "lemma-", []string{"-"}, ""},
+		{"url2domain", "https://www.korpus.cz/kontext/query", []string{"0"}, "www.korpus.cz"},
+		{"url2domain", "https://www.korpus.cz/kontext/query", []string{"1"}, "cz"},
+		{"url2domain", "http://www.korpus.cz:8080/", []string{"2"}, "korpus.cz"},
+		{"ascii", "Příliš žluťoučký kůň", []string{"utf-8", "cs_CZ"}, "Prilis zlutoucky kun"},
+	}
+	for _, tst := range tests {
+		ans, err := internalDynFnImpl[tst.fn](tst.value, tst.args)
+		assert.NoError(t, err)
+		assert.Equal(t, tst.result, ans, "%s(%s, %v)", tst.fn, tst.value, tst.args)
+	}
+}
+
+func TestInternalDynFnImplInvalidArgs(t *testing.T) {
+	_, err := internalDynFnImpl["getnbysep"]("a|b", []string{"||", "1"})
+	assert.Error(t, err)
+	_, err = internalDynFnImpl["getfirstn"]("abc", []string{"x"})
+	assert.Error(t, err)
+	_, err = internalDynFnImpl["getfirstn"]("abc", nil)
+	assert.Error(t, err)
+}
+
+func TestInternalDynFnImplNegativeOrOutOfRangeN(t *testing.T) {
+	tests := []struct {
+		fn   string
+		args []string
+	}{
+		{"striplastn", []string{"-1"}},
+		{"getfirstn", []string{"-1"}},
+		{"getlastn", []string{"-2"}},
+		{"utf8getlastn", []string{"-1"}},
+		{"getnchar", []string{"-1"}},
+		{"getnbysep", []string{"|", "-1"}},
+		{"getnextchars", []string{"-", "-1"}},
+		{"url2domain", []string{"-1"}},
+		{"getfirstn", []string{"2147483648"}},
+		{"getnextchars", []string{"-", "99999999999"}},
+	}
+	for _, tst := range tests {
+		assert.NotPanics(t, func() {
+			_, err := internalDynFnImpl[tst.fn]("lemma-nn", tst.args)
+			assert.Error(t, err, "%s(%v)", tst.fn, tst.args)
+		})
+	}
+}
+
+func TestValidateCallRejectsNegativeN(t *testing.T) {
+	for _, name := range []string{"striplastn", "getfirstn", "getlastn", "utf8getlastn"} {
+		fn, _ := findInternalDynFn(name)
+		errs := fn.ValidateCall("i", []string{"-1"})
+		assert.Len(t, errs, 1, name)
+		assert.Equal(t, "ARG1", errs[0].Property)
+		errs = fn.ValidateCall("i", []string{"2147483648"})
+		assert.Len(t, errs, 1, name)
+		assert.Empty(t, fn.ValidateCall("i", []string{"0"}), name)
+	}
+	fn, _ := findInternalDynFn("getnextchars")
+	errs := fn.ValidateCall("ci", []string{"-", "-3"})
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "ARG2", errs[0].Property)
+}
+
+func TestPreviewDynFnRejectsNegativeN(t *testing.T) {
+	dynFns, err := LoadDynFnCatalogue(&corpus.CorporaSetup{})
+	assert.NoError(t, err)
+	_, err = PreviewDynFn(
+		PreviewArgs{Function: "getfirstn", Args: []string{"-1"}, Values: []string{"abc"}},
+		dynFns,
+		corpus.NewCorpusPool(&corpus.CorporaSetup{}),
+	)
+	var callErrs DynFnCallErrors
+	assert.ErrorAs(t, err, &callErrs)
+}
+
+func TestPreviewSourceValuesFromPool(t *testing.T) {
+	pool := corpus.NewCorpusPool(&corpus.CorporaSetup{RegistryDirPaths: []string{t.TempDir()}})
+	_, err := previewSourceValues(PreviewArgs{Corpus: "missing", FromAttr: "word"}, pool)
+	assert.ErrorIs(t, err, corpus.CorpusNotFound)
+	_, err = previewSourceValues(PreviewArgs{Corpus: "../etc", FromAttr: "word"}, pool)
+	assert.ErrorIs(t, err, corpus.ErrInvalidCorpusID)
+	_, err = previewSourceValues(PreviewArgs{Corpus: "syn2020"}, pool)
+	assert.ErrorIs(t, err, ErrPreviewArgs)
+	values, err := previewSourceValues(PreviewArgs{Values: []string{"a", "b", "c"}, Limit: 2}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, values)
+}
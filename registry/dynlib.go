@@ -0,0 +1,249 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"debug/elf"
+	"encoding/json"
+	"fmt"
+	"masm/v3/corpus"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DynFnProblemNotExported means that a function is described
+	// (either in the sidecar file or in MASM) but the dynlib
+	// does not export it
+	DynFnProblemNotExported = "notExported"
+
+	// DynFnProblemUndocumented means that a function is exported
+	// by the dynlib but there is no description of its arguments
+	DynFnProblemUndocumented = "undocumented"
+
+	// DynFnProblemDynlibUnreadable means that the dynlib itself
+	// cannot be read so we cannot tell whether the function exists
+	DynFnProblemDynlibUnreadable = "dynlibUnreadable"
+
+	// DynFnProblemInvalidSignature means that the function description
+	// contains invalid arguments (see DynFn.SignatureErrors)
+	DynFnProblemInvalidSignature = "invalidSignature"
+)
+
+// knownDynlibFns contains descriptions of CNC dynlib functions
+// used in case there is no sidecar file next to the dynlib.
+var knownDynlibFns = []dynlibFnMeta{
+	{
+		Name:        "geteachncharbysep",
+		Args:        []DynFnArg{{Name: "pos", Kind: ArgKindInt}},
+		Description: "Separate a string by \"|\" and return all the pos-th elements from respective items",
+	},
+}
+
+// dynlibFnMeta is a function description as stored
+// in a dynlib sidecar file
+type dynlibFnMeta struct {
+	Name        string     `json:"name"`
+	Args        []DynFnArg `json:"args"`
+	Description string     `json:"description"`
+}
+
+// DynFnCatalogue contains all the dynamic functions available
+// for registry DYNAMIC attributes. Functions of the configured dynlib
+// are discovered by reading its ELF dynamic symbols. Their arguments
+// and descriptions are taken from a sidecar JSON file
+// ([dynlib path].json, a list of {name, args, description} where
+// args is a list of {name, kind, optional}) or,
+// if the file does not exist, from knownDynlibFns.
+type DynFnCatalogue struct {
+	dynlibPath string
+	items      []DynFn
+}
+
+// All returns both Manatee internal functions and
+// the ones provided by the configured dynlib.
+func (dc *DynFnCatalogue) All() []DynFn {
+	return dc.items
+}
+
+// Find searches for a function of a provided name within
+// a specified dynlib ("internal" for Manatee functions)
+func (dc *DynFnCatalogue) Find(name, dynlib string) (DynFn, bool) {
+	for _, fn := range dc.items {
+		if fn.Name == name && fn.Dynlib == dynlib {
+			return fn, true
+		}
+	}
+	return DynFn{}, false
+}
+
+// Problems returns functions where the dynlib and
+// the function descriptions disagree
+func (dc *DynFnCatalogue) Problems() []DynFn {
+	ans := make([]DynFn, 0, len(dc.items))
+	for _, fn := range dc.items {
+		if fn.Problem != "" {
+			ans = append(ans, fn)
+		}
+	}
+	return ans
+}
+
+// readDynlibSymbols returns names of global functions
+// defined (i.e. exported) by a shared object
+func readDynlibSymbols(path string) ([]string, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return []string{}, err
+	}
+	defer f.Close()
+	symbols, err := f.DynamicSymbols()
+	if err != nil {
+		return []string{}, err
+	}
+	return exportedFunctions(symbols), nil
+}
+
+// exportedFunctions filters names of global functions
+// defined in a shared object (sorted alphabetically)
+func exportedFunctions(symbols []elf.Symbol) []string {
+	ans := make([]string, 0, len(symbols))
+	for _, sym := range symbols {
+		if elf.ST_TYPE(sym.Info) != elf.STT_FUNC ||
+			elf.ST_BIND(sym.Info) != elf.STB_GLOBAL ||
+			sym.Section == elf.SHN_UNDEF {
+			continue
+		}
+		// skip C++ mangled names and compiler/linker generated functions
+		// (dynamic functions must be exported as plain C functions)
+		if strings.HasPrefix(sym.Name, "_") {
+			continue
+		}
+		ans = append(ans, sym.Name)
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+func readDynlibSidecar(dynlibPath string) ([]dynlibFnMeta, error) {
+	path := dynlibPath + ".json"
+	isFile, err := fs.IsFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !isFile {
+		return knownDynlibFns, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ans []dynlibFnMeta
+	if err := json.Unmarshal(data, &ans); err != nil {
+		return nil, fmt.Errorf("failed to parse dynlib sidecar file %s: %w", path, err)
+	}
+	for _, item := range ans {
+		if item.Name == "" {
+			return nil, fmt.Errorf("invalid item in dynlib sidecar file %s: missing name", path)
+		}
+	}
+	return ans, nil
+}
+
+// LoadDynFnCatalogue creates a catalogue of dynamic functions
+// based on the Manatee internal functions and the functions
+// exported by the configured dynlib.
+func LoadDynFnCatalogue(conf *corpus.CorporaSetup) (*DynFnCatalogue, error) {
+	ans := &DynFnCatalogue{
+		dynlibPath: conf.ManateeDynlibPath,
+		items:      make([]DynFn, len(dynFnList)),
+	}
+	copy(ans.items, dynFnList)
+	if conf.ManateeDynlibPath == "" {
+		return ans, nil
+	}
+	meta, err := readDynlibSidecar(conf.ManateeDynlibPath)
+	if err != nil {
+		return nil, err
+	}
+	symbols, symErr := readDynlibSymbols(conf.ManateeDynlibPath)
+	if symErr != nil {
+		log.Error().
+			Err(symErr).
+			Str("dynlib", conf.ManateeDynlibPath).
+			Msg("failed to read dynlib symbols")
+	}
+	ans.items = append(ans.items, mergeDynlibFns(conf.ManateeDynlibPath, meta, symbols, symErr)...)
+	for _, fn := range ans.Problems() {
+		log.Warn().
+			Str("dynlib", fn.Dynlib).
+			Str("function", fn.Name).
+			Str("problem", fn.Problem).
+			Msg("dynlib and function descriptions disagree")
+	}
+	return ans, nil
+}
+
+// mergeDynlibFns creates dynlib functions from their descriptions
+// and from symbols exported by the dynlib (symErr is an error
+// of reading the symbols). Disagreements are reported via
+// DynFn.Problem.
+func mergeDynlibFns(dynlibPath string, meta []dynlibFnMeta, symbols []string, symErr error) []DynFn {
+	ans := make([]DynFn, 0, len(meta)+len(symbols))
+	exported := make(map[string]bool)
+	for _, sym := range symbols {
+		exported[sym] = true
+	}
+	described := make(map[string]bool)
+	for _, m := range meta {
+		fn := DynFn{
+			Name:        m.Name,
+			Args:        m.Args,
+			Dynlib:      dynlibPath,
+			Description: m.Description,
+		}
+		if sigErrs := fn.ValidateSignature(); len(sigErrs) > 0 {
+			fn.Problem = DynFnProblemInvalidSignature
+			fn.SignatureErrors = sigErrs
+
+		} else if symErr != nil {
+			fn.Problem = DynFnProblemDynlibUnreadable
+
+		} else if !exported[m.Name] {
+			fn.Problem = DynFnProblemNotExported
+		}
+		described[m.Name] = true
+		ans = append(ans, fn)
+	}
+	for _, sym := range symbols {
+		if described[sym] {
+			continue
+		}
+		ans = append(ans, DynFn{
+			Name:    sym,
+			Dynlib:  dynlibPath,
+			Problem: DynFnProblemUndocumented,
+		})
+	}
+	return ans
+}
@@ -0,0 +1,139 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"debug/elf"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"masm/v3/corpus"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportedFunctions(t *testing.T) {
+	globalFn := elf.ST_INFO(elf.STB_GLOBAL, elf.STT_FUNC)
+	symbols := []elf.Symbol{
+		{Name: "zfn", Info: globalFn, Section: 12},
+		{Name: "getfirst", Info: globalFn, Section: 12},
+		{Name: "localfn", Info: elf.ST_INFO(elf.STB_LOCAL, elf.STT_FUNC), Section: 12},
+		{Name: "weakfn", Info: elf.ST_INFO(elf.STB_WEAK, elf.STT_FUNC), Section: 12},
+		{Name: "somevar", Info: elf.ST_INFO(elf.STB_GLOBAL, elf.STT_OBJECT), Section: 12},
+		{Name: "strlen", Info: globalFn, Section: elf.SHN_UNDEF},
+		{Name: "_ZN7Manatee3fooEv", Info: globalFn, Section: 12},
+		{Name: "_init", Info: globalFn, Section: 12},
+	}
+	assert.Equal(t, []string{"getfirst", "zfn"}, exportedFunctions(symbols))
+}
+
+func TestReadDynlibSymbolsInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "libfoo.so")
+	assert.NoError(t, os.WriteFile(path, []byte("not an ELF file"), 0644))
+	_, err := readDynlibSymbols(path)
+	assert.Error(t, err)
+}
+
+func findDynFnByName(items []DynFn, name string) (DynFn, bool) {
+	for _, fn := range items {
+		if fn.Name == name {
+			return fn, true
+		}
+	}
+	return DynFn{}, false
+}
+
+func TestMergeDynlibFns(t *testing.T) {
+	meta := []dynlibFnMeta{
+		{Name: "documented", Args: []DynFnArg{{Name: "pos", Kind: ArgKindInt}}, Description: "ok"},
+		{Name: "missing", Description: "not exported"},
+		{Name: "badsig", Args: []DynFnArg{{Name: "x", Kind: "foo"}}},
+	}
+	items := mergeDynlibFns("/opt/lib.so", meta, []string{"documented", "extra", "badsig"}, nil)
+	assert.Len(t, items, 4)
+	for _, fn := range items {
+		assert.Equal(t, "/opt/lib.so", fn.Dynlib)
+	}
+	fn, _ := findDynFnByName(items, "documented")
+	assert.Empty(t, fn.Problem)
+	assert.Equal(t, "ok", fn.Description)
+	fn, _ = findDynFnByName(items, "missing")
+	assert.Equal(t, DynFnProblemNotExported, fn.Problem)
+	fn, _ = findDynFnByName(items, "badsig")
+	assert.Equal(t, DynFnProblemInvalidSignature, fn.Problem)
+	assert.Len(t, fn.SignatureErrors, 1)
+	fn, _ = findDynFnByName(items, "extra")
+	assert.Equal(t, DynFnProblemUndocumented, fn.Problem)
+}
+
+func TestMergeDynlibFnsUnreadableDynlib(t *testing.T) {
+	meta := []dynlibFnMeta{{Name: "documented"}}
+	items := mergeDynlibFns("/opt/lib.so", meta, []string{}, errors.New("not an ELF"))
+	assert.Len(t, items, 1)
+	assert.Equal(t, DynFnProblemDynlibUnreadable, items[0].Problem)
+}
+
+func TestReadDynlibSidecar(t *testing.T) {
+	dynlibPath := filepath.Join(t.TempDir(), "libfoo.so")
+
+	// without a sidecar file, known CNC functions are used
+	meta, err := readDynlibSidecar(dynlibPath)
+	assert.NoError(t, err)
+	assert.Equal(t, knownDynlibFns, meta)
+
+	assert.NoError(t, os.WriteFile(
+		dynlibPath+".json",
+		[]byte(`[{"name": "foo", "args": [{"name": "sep", "kind": "char"}], "description": "Foo"}]`),
+		0644,
+	))
+	meta, err = readDynlibSidecar(dynlibPath)
+	assert.NoError(t, err)
+	assert.Len(t, meta, 1)
+	assert.Equal(t, "foo", meta[0].Name)
+	assert.Equal(t, "sep", meta[0].Args[0].Name)
+
+	assert.NoError(t, os.WriteFile(dynlibPath+".json", []byte(`[{"description": "no name"}]`), 0644))
+	_, err = readDynlibSidecar(dynlibPath)
+	assert.ErrorContains(t, err, "missing name")
+
+	assert.NoError(t, os.WriteFile(dynlibPath+".json", []byte(`{`), 0644))
+	_, err = readDynlibSidecar(dynlibPath)
+	assert.Error(t, err)
+}
+
+func TestLoadDynFnCatalogue(t *testing.T) {
+	cat, err := LoadDynFnCatalogue(&corpus.CorporaSetup{})
+	assert.NoError(t, err)
+	assert.Len(t, cat.All(), len(dynFnList))
+	assert.Empty(t, cat.Problems())
+
+	dynlibPath := filepath.Join(t.TempDir(), "libfoo.so")
+	assert.NoError(t, os.WriteFile(dynlibPath, []byte("not an ELF file"), 0644))
+	cat, err = LoadDynFnCatalogue(&corpus.CorporaSetup{ManateeDynlibPath: dynlibPath})
+	assert.NoError(t, err)
+	assert.Len(t, cat.All(), len(dynFnList)+len(knownDynlibFns))
+	fn, ok := cat.Find(knownDynlibFns[0].Name, dynlibPath)
+	assert.True(t, ok)
+	assert.Equal(t, DynFnProblemDynlibUnreadable, fn.Problem)
+	_, ok = cat.Find(knownDynlibFns[0].Name, "internal")
+	assert.False(t, ok)
+	assert.Len(t, cat.Problems(), 1)
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"masm/v3/registry/parser"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// writeRegistryFile stores a registry document into targetPath.
+// The file is first written to stagingDir and then moved to
+// its final location so readers (Manatee, KonText) never see
+// a partially written registry. The serialized data are parsed
+// once more before writing to make sure the result is valid.
+func writeRegistryFile(doc *parser.Document, targetPath, stagingDir string) error {
+	if stagingDir == "" {
+		return fmt.Errorf("failed to write registry %s: registryTmpDir not configured", targetPath)
+	}
+	data, err := parser.SerializeToString(doc)
+	if err != nil {
+		return fmt.Errorf("failed to write registry %s: %w", targetPath, err)
+	}
+	if _, err := parser.ParseRegistry(targetPath, []byte(data)); err != nil {
+		return fmt.Errorf("failed to write registry %s, serialized data not valid: %w", targetPath, err)
+	}
+	fileMode := os.FileMode(0644)
+	if finfo, err := os.Stat(targetPath); err == nil {
+		fileMode = finfo.Mode().Perm()
+	}
+	staged, err := writeTempFile(stagingDir, filepath.Base(targetPath), data, fileMode)
+	if err != nil {
+		return fmt.Errorf("failed to write registry %s: %w", targetPath, err)
+	}
+	err = os.Rename(staged, targetPath)
+	if errors.Is(err, syscall.EXDEV) {
+		// staging dir is on a different device so we have to copy
+		// the file next to the target first to keep the final rename atomic
+		err = moveAcrossDevices(staged, targetPath, fileMode)
+	}
+	if err != nil {
+		os.Remove(staged)
+		return fmt.Errorf("failed to write registry %s: %w", targetPath, err)
+	}
+	return nil
+}
+
+func writeTempFile(dir, baseName, data string, fileMode os.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(dir, "."+baseName+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.WriteString(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), fileMode); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+func moveAcrossDevices(srcPath, targetPath string, fileMode os.FileMode) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	tmp, err := writeTempFile(filepath.Dir(targetPath), filepath.Base(targetPath), string(data), fileMode)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, targetPath); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(srcPath)
+}
@@ -0,0 +1,562 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/general/collections"
+	"masm/v3/mango"
+	"masm/v3/registry/parser"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// tagsetGuessMinCoverage specifies a minimum ratio of tag values
+	// matched by a tagset to consider the tagset a valid guess
+	tagsetGuessMinCoverage = 0.9
+
+	// attrValuesSampleSize specifies how many values from an attribute
+	// lexicon are inspected when inferring attribute properties
+	attrValuesSampleSize = 5000
+
+	// verticalSampleTokens specifies how many tokens from the beginning
+	// of a vertical file are compared with indexed attribute values
+	// to find out the order of attributes
+	verticalSampleTokens = 1000
+
+	dfltEncoding = "UTF-8"
+)
+
+var (
+	tagAttrCandidates = []string{"tag", "pos", "ctag", "upos"}
+)
+
+// GenerateArgs specifies a data directory (and optionally a vertical
+// file) a registry draft should be generated for.
+type GenerateArgs struct {
+	DataPath string `json:"path"`
+	Vertical string `json:"vertical"`
+}
+
+// TagsetGuess describes a tagset inferred from tag attribute values
+type TagsetGuess struct {
+	PosID    string  `json:"posId"`
+	Attr     string  `json:"attr"`
+	Coverage float64 `json:"coverage"`
+}
+
+// GeneratedRegistry is a draft registry produced from indexed data
+type GeneratedRegistry struct {
+	Document *parser.Document `json:"document"`
+	Source   string           `json:"source"`
+	Tagset   *TagsetGuess     `json:"tagset"`
+
+	// Vertical contains results of the vertical file inspection
+	// (only the beginning of the file is inspected)
+	Vertical *corpus.VerticalStats `json:"vertical,omitempty"`
+
+	// AttrOrderFromVertical is set in case the order of positional
+	// attributes was derived from the vertical file columns
+	AttrOrderFromVertical bool `json:"attrOrderFromVertical"`
+}
+
+type indexedData struct {
+	attrs   []string
+	structs map[string][]string
+}
+
+func (d *indexedData) structNames() []string {
+	ans := make([]string, 0, len(d.structs))
+	for k := range d.structs {
+		ans = append(ans, k)
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+// findIndexedData lists attributes and structures based on index files
+// found in a data directory. Positional attributes are sorted
+// alphabetically except for "word" which goes first.
+func findIndexedData(dataPath string) (*indexedData, error) {
+	files, err := fs.ListFilesInDir(dataPath, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data directory %s: %w", dataPath, err)
+	}
+	ans := &indexedData{
+		attrs:   make([]string, 0, 10),
+		structs: make(map[string][]string),
+	}
+	structAttrs := make([]string, 0, 30)
+	files.ForEach(func(info os.FileInfo, _ int) bool {
+		name := info.Name()
+		if strings.HasSuffix(name, ".rng") {
+			st := strings.TrimSuffix(name, ".rng")
+			if _, ok := ans.structs[st]; !ok {
+				ans.structs[st] = []string{}
+			}
+
+		} else if strings.HasSuffix(name, ".lex") {
+			attr := strings.TrimSuffix(name, ".lex")
+			if strings.Contains(attr, ".") {
+				structAttrs = append(structAttrs, attr)
+
+			} else {
+				ans.attrs = append(ans.attrs, attr)
+			}
+		}
+		return true
+	})
+	for _, sattr := range structAttrs {
+		tmp := strings.SplitN(sattr, ".", 2)
+		if _, ok := ans.structs[tmp[0]]; ok {
+			ans.structs[tmp[0]] = append(ans.structs[tmp[0]], tmp[1])
+		}
+	}
+	for _, v := range ans.structs {
+		sort.Strings(v)
+	}
+	sort.Slice(ans.attrs, func(i, j int) bool {
+		if ans.attrs[i] == "word" || ans.attrs[j] == "word" {
+			return ans.attrs[i] == "word"
+		}
+		return ans.attrs[i] < ans.attrs[j]
+	})
+	return ans, nil
+}
+
+func newPropEntry(key, value string) *parser.Entry {
+	return &parser.Entry{Property: &parser.Property{Key: key, Value: value, Quoted: true}}
+}
+
+func newCommentEntry(text string) *parser.Entry {
+	return &parser.Entry{Comment: &parser.Comment{Text: " " + text}}
+}
+
+// guessTagset finds a tagset from tagsets matching
+// the most of provided tag values.
+func guessTagset(attr string, values []string, tagsets []Pos) *TagsetGuess {
+	if len(values) == 0 {
+		return nil
+	}
+	var ans *TagsetGuess
+	for _, pos := range tagsets {
+		patterns := make([]*regexp.Regexp, 0, len(pos.Values))
+		for _, v := range pos.Values {
+			// Manatee patterns always match whole values
+			ptn, err := regexp.Compile("^(?:" + v.TagSrchPattern + ")$")
+			if err != nil {
+				log.Warn().Err(err).Str("posId", pos.ID).Msg("skipping invalid tagset pattern")
+				continue
+			}
+			patterns = append(patterns, ptn)
+		}
+		var numMatching int
+		for _, value := range values {
+			for _, ptn := range patterns {
+				if ptn.MatchString(value) {
+					numMatching++
+					break
+				}
+			}
+		}
+		coverage := float64(numMatching) / float64(len(values))
+		if coverage >= tagsetGuessMinCoverage && (ans == nil || coverage > ans.Coverage) {
+			ans = &TagsetGuess{PosID: pos.ID, Attr: attr, Coverage: coverage}
+		}
+	}
+	return ans
+}
+
+// orderAttrsByColumns finds out which vertical columns the attributes
+// correspond to by comparing sampled tokens with attribute values
+// at the same positions (valuesOf should return values at positions
+// 0, ..., len(sample) - 1). An attribute is matched with a column
+// in case more than a half of the values is equal. Matched attributes
+// are returned in the order of the columns followed by unmatched ones
+// (in the original order). The second returned value is true if all
+// the attributes have been matched.
+func orderAttrsByColumns(
+	attrs []string,
+	sample [][]string,
+	valuesOf func(attr string) ([]string, error),
+) ([]string, bool) {
+	if len(sample) == 0 {
+		return attrs, false
+	}
+	type match struct {
+		attrIdx int
+		col     int
+		score   int
+	}
+	matches := make([]match, 0, len(attrs)*len(attrs))
+	for i, attr := range attrs {
+		values, err := valuesOf(attr)
+		if err != nil {
+			log.Warn().Err(err).Str("attr", attr).Msg("failed to get attribute values, skipping")
+			continue
+		}
+		scores := make(map[int]int)
+		for pos := 0; pos < len(values) && pos < len(sample); pos++ {
+			for col, v := range sample[pos] {
+				if v == values[pos] {
+					scores[col]++
+				}
+			}
+		}
+		for col, score := range scores {
+			if 2*score > len(sample) {
+				matches = append(matches, match{attrIdx: i, col: col, score: score})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		if matches[i].col != matches[j].col {
+			return matches[i].col < matches[j].col
+		}
+		return matches[i].attrIdx < matches[j].attrIdx
+	})
+	attrCols := make(map[int]int)
+	usedCols := make(map[int]bool)
+	for _, m := range matches {
+		if _, ok := attrCols[m.attrIdx]; ok || usedCols[m.col] {
+			continue
+		}
+		attrCols[m.attrIdx] = m.col
+		usedCols[m.col] = true
+	}
+	matched := make([]int, 0, len(attrCols))
+	unmatched := make([]string, 0, len(attrs))
+	for i, attr := range attrs {
+		if _, ok := attrCols[i]; ok {
+			matched = append(matched, i)
+
+		} else {
+			unmatched = append(unmatched, attr)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return attrCols[matched[i]] < attrCols[matched[j]]
+	})
+	ans := make([]string, 0, len(attrs))
+	for _, i := range matched {
+		ans = append(ans, attrs[i])
+	}
+	return append(ans, unmatched...), len(unmatched) == 0
+}
+
+// generator produces a registry draft. It uses a temporary
+// minimal registry to open the data via Manatee.
+type generator struct {
+	conf     *corpus.CorporaSetup
+	tagsets  *TagsetCatalogue
+	corpusID string
+	args     GenerateArgs
+	data     *indexedData
+	corp     *mango.GoCorpus
+	vertical *corpus.VerticalStats
+}
+
+// writeTmpRegistry writes a minimal registry describing the indexed
+// data to RegistryTmpDir and returns its path
+func (g *generator) writeTmpRegistry() (string, error) {
+	doc := &parser.Document{Entries: []*parser.Entry{newPropEntry("PATH", g.args.DataPath)}}
+	for _, attr := range g.data.attrs {
+		doc.Entries = append(doc.Entries, &parser.Entry{Attribute: &parser.Attribute{Name: attr}})
+	}
+	for _, st := range g.data.structNames() {
+		s := &parser.Structure{Name: st}
+		for _, attr := range g.data.structs[st] {
+			s.Entries = append(s.Entries, &parser.Entry{Attribute: &parser.Attribute{Name: attr}})
+		}
+		doc.Entries = append(doc.Entries, &parser.Entry{Structure: s})
+	}
+	src, err := parser.SerializeToString(doc)
+	if err != nil {
+		return "", err
+	}
+	// corpus ID may contain a sub-directory
+	return writeTempFile(g.conf.RegistryTmpDir, filepath.Base(g.corpusID), src, 0644)
+}
+
+func (g *generator) openData() error {
+	tmpReg, err := g.writeTmpRegistry()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpReg)
+	g.corp, err = mango.OpenCorpus(tmpReg)
+	return err
+}
+
+// isMultivalue tests whether sampled values contain
+// the default multi-value separator
+func (g *generator) isMultivalue(attr string) bool {
+	values, err := mango.GetAttrValues(g.corp, attr, attrValuesSampleSize)
+	if err != nil {
+		log.Warn().Err(err).Str("attr", attr).Msg("failed to get attribute values")
+		return false
+	}
+	for _, v := range values {
+		if strings.Contains(v, cncMultisep.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) multivalueEntries() []*parser.Entry {
+	return []*parser.Entry{
+		{Property: &parser.Property{Key: "MULTIVALUE", Value: boolValueYes.Value}},
+		newPropEntry("MULTISEP", cncMultisep.Value),
+	}
+}
+
+// inspectVertical parses the beginning of the vertical file
+// to obtain a token sample and used structures
+func (g *generator) inspectVertical(ctx context.Context) error {
+	f, err := os.Open(g.args.Vertical)
+	if err != nil {
+		return fmt.Errorf("%w: %s", corpus.ErrVerticalNotAvailable, err)
+	}
+	defer f.Close()
+	vert, err := corpus.NewVerticalReader(f)
+	if err != nil {
+		return err
+	}
+	defer vert.Close()
+	conf := corpus.VerticalParserConf{
+		Structs:      g.data.structNames(),
+		StructAttrs:  make([]string, 0, 20),
+		MaxTokens:    verticalSampleTokens,
+		SampleTokens: verticalSampleTokens,
+	}
+	for _, st := range conf.Structs {
+		for _, attr := range g.data.structs[st] {
+			conf.StructAttrs = append(conf.StructAttrs, st+"."+attr)
+		}
+	}
+	g.vertical, err = corpus.ParseVertical(ctx, vert, conf)
+	if err != nil {
+		return fmt.Errorf("failed to read vertical %s: %w", g.args.Vertical, err)
+	}
+	g.vertical.Path = g.args.Vertical
+	return nil
+}
+
+// verticalNotes describes vertical file contents
+// not covered by the indexed data
+func (g *generator) verticalNotes() []*parser.Entry {
+	ans := make([]*parser.Entry, 0, 5)
+	if len(g.vertical.TokenSample) > 0 {
+		numCols := len(g.vertical.TokenSample[0])
+		if numCols != len(g.data.attrs) {
+			ans = append(
+				ans,
+				newCommentEntry(fmt.Sprintf(
+					"the vertical file has %d columns but %d attributes are indexed",
+					numCols, len(g.data.attrs))),
+			)
+		}
+	}
+	for _, st := range g.vertical.Structures {
+		if !st.InRegistry {
+			ans = append(
+				ans,
+				newCommentEntry(fmt.Sprintf("structure %s found in the vertical file is not indexed", st.Name)),
+			)
+
+		} else if len(st.AttrsNotInRegistry) > 0 {
+			ans = append(
+				ans,
+				newCommentEntry(fmt.Sprintf(
+					"attributes of structure %s found in the vertical file are not indexed: %s",
+					st.Name, strings.Join(st.AttrsNotInRegistry, ", "))),
+			)
+		}
+	}
+	return ans
+}
+
+func (g *generator) lowercaseAttr(name, fromAttr string) *parser.Entry {
+	fn, _ := findInternalDynFn("utf8lowercase")
+	funtype, _ := fn.FuntypeFor(0)
+	return &parser.Entry{
+		Attribute: &parser.Attribute{
+			Name: name,
+			Entries: []*parser.Entry{
+				{Property: &parser.Property{Key: "DYNAMIC", Value: fn.Name}},
+				{Property: &parser.Property{Key: "DYNLIB", Value: fn.Dynlib}},
+				{Property: &parser.Property{Key: "FUNTYPE", Value: funtype}},
+				{Property: &parser.Property{Key: "FROMATTR", Value: fromAttr}},
+				{Property: &parser.Property{Key: "DYNTYPE", Value: "index"}},
+				{Property: &parser.Property{Key: "TRANSQUERY", Value: boolValueYes.Value}},
+			},
+		},
+	}
+}
+
+func (g *generator) run(ctx context.Context) (*GeneratedRegistry, error) {
+	var err error
+	g.data, err = findIndexedData(g.args.DataPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.data.attrs) == 0 {
+		return nil, fmt.Errorf("%w: no indexed attributes found in %s", corpus.ErrInvalidArgs, g.args.DataPath)
+	}
+	if err := g.openData(); err != nil {
+		return nil, fmt.Errorf("failed to open data %s: %w", g.args.DataPath, err)
+	}
+	defer mango.CloseCorpus(g.corp)
+
+	ans := &GeneratedRegistry{Document: &parser.Document{}}
+	defaultAttr := g.data.attrs[0]
+	if g.args.Vertical != "" {
+		if err := g.inspectVertical(ctx); err != nil {
+			return nil, err
+		}
+		ans.Vertical = g.vertical
+		g.data.attrs, ans.AttrOrderFromVertical = orderAttrsByColumns(
+			g.data.attrs,
+			g.vertical.TokenSample,
+			func(attr string) ([]string, error) {
+				return mango.GetAttrValuesAt(g.corp, attr, 0, int64(len(g.vertical.TokenSample)))
+			},
+		)
+	}
+	doc := ans.Document
+	doc.Entries = append(
+		doc.Entries,
+		newCommentEntry(fmt.Sprintf("registry draft generated from %s", g.args.DataPath)),
+		newPropEntry("NAME", g.corpusID),
+		newPropEntry("PATH", g.args.DataPath),
+	)
+	if g.args.Vertical != "" {
+		doc.Entries = append(doc.Entries, newPropEntry("VERTICAL", g.args.Vertical))
+	}
+	doc.Entries = append(
+		doc.Entries,
+		newPropEntry("ENCODING", dfltEncoding),
+		&parser.Entry{Property: &parser.Property{Key: "DEFAULTATTR", Value: defaultAttr}},
+	)
+	if g.vertical != nil {
+		doc.Entries = append(doc.Entries, g.verticalNotes()...)
+	}
+	if !ans.AttrOrderFromVertical {
+		doc.Entries = append(
+			doc.Entries,
+			newCommentEntry("please make sure the order of attributes matches the vertical file columns"),
+		)
+	}
+	attrs := collections.NewSet(g.data.attrs...)
+	for _, attr := range g.data.attrs {
+		entry := &parser.Entry{Attribute: &parser.Attribute{Name: attr, Entries: []*parser.Entry{}}}
+		if g.isMultivalue(attr) {
+			entry.Attribute.Entries = append(entry.Attribute.Entries, g.multivalueEntries()...)
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	for _, pair := range [][2]string{{"lc", "word"}, {"lemma_lc", "lemma"}} {
+		if attrs.Contains(pair[1]) && !attrs.Contains(pair[0]) {
+			doc.Entries = append(doc.Entries, g.lowercaseAttr(pair[0], pair[1]))
+		}
+	}
+	for _, st := range g.data.structNames() {
+		s := &parser.Structure{Name: st, Entries: []*parser.Entry{}}
+		for _, attr := range g.data.structs[st] {
+			a := &parser.Attribute{Name: attr, Entries: []*parser.Entry{}}
+			if g.isMultivalue(st + "." + attr) {
+				a.Entries = append(a.Entries, g.multivalueEntries()...)
+			}
+			s.Entries = append(s.Entries, &parser.Entry{Attribute: a})
+		}
+		doc.Entries = append(doc.Entries, &parser.Entry{Structure: s})
+	}
+
+	for _, tagAttr := range tagAttrCandidates {
+		if !attrs.Contains(tagAttr) {
+			continue
+		}
+		values, err := mango.GetAttrValues(g.corp, tagAttr, attrValuesSampleSize)
+		if err != nil {
+			log.Warn().Err(err).Str("attr", tagAttr).Msg("failed to get tag values")
+			continue
+		}
+		ans.Tagset = guessTagset(tagAttr, values, g.tagsets.List())
+		if ans.Tagset != nil {
+			break
+		}
+	}
+	if ans.Tagset != nil {
+		pos, _ := g.tagsets.Get(ans.Tagset.PosID)
+		doc.Entries = append(
+			doc.Entries,
+			newCommentEntry(fmt.Sprintf("WPOSLIST guessed from attribute %s: %s", ans.Tagset.Attr, pos.Name)),
+			newPropEntry("WPOSLIST", pos.ExportWposlist()),
+		)
+	}
+	ans.Source, err = parser.SerializeToString(doc)
+	if err != nil {
+		return nil, err
+	}
+	return ans, nil
+}
+
+// GenerateRegistry produces a registry draft based on data indexed
+// in args.DataPath. Attribute properties are inferred from their values,
+// WPOSLIST is chosen by matching tag values against known tagsets.
+// In case args.Vertical is set, the order of attributes is derived
+// from the vertical file columns.
+func GenerateRegistry(
+	ctx context.Context,
+	corpusID string,
+	args GenerateArgs,
+	conf *corpus.CorporaSetup,
+	tagsets *TagsetCatalogue,
+) (*GeneratedRegistry, error) {
+	if conf.RegistryTmpDir == "" {
+		return nil, fmt.Errorf("failed to generate registry: registryTmpDir not configured")
+	}
+	if !fs.PathExists(args.DataPath) {
+		return nil, fmt.Errorf("failed to generate registry: data directory %s %w", args.DataPath, corpus.ErrNotFound)
+	}
+	isDir, err := fs.IsDir(args.DataPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate registry: %w", err)
+	}
+	if !isDir {
+		return nil, fmt.Errorf(
+			"%w: failed to generate registry: %s is not a directory", corpus.ErrInvalidArgs, args.DataPath)
+	}
+	args.DataPath = filepath.Clean(args.DataPath) + "/"
+	g := &generator{conf: conf, tagsets: tagsets, corpusID: corpusID, args: args}
+	return g.run(ctx)
+}
@@ -0,0 +1,238 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"masm/v3/corpus"
+	"masm/v3/registry/parser"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGeneratorVertical = `<doc id="1" genre="news">
+<s>
+The	the	DT
+cats	cat	NNS
+sleep	sleep	VBP
+</s>
+<note/>
+</doc>
+`
+
+func TestFindIndexedData(t *testing.T) {
+	dir := t.TempDir()
+	createIndexFiles(t, dir, map[string][]string{
+		"tag":     {".lex"},
+		"lemma":   {".lex"},
+		"word":    {".lex"},
+		"doc":     {".rng"},
+		"doc.id":  {".lex"},
+		"s":       {".rng"},
+		"foo.bar": {".lex"},
+	})
+	data, err := findIndexedData(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"word", "lemma", "tag"}, data.attrs)
+	assert.Equal(t, []string{"doc", "s"}, data.structNames())
+	assert.Equal(t, []string{"id"}, data.structs["doc"])
+	assert.Equal(t, []string{}, data.structs["s"])
+}
+
+func TestFindIndexedDataMissingDir(t *testing.T) {
+	_, err := findIndexedData(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestGuessTagset(t *testing.T) {
+	tagsets := []Pos{
+		{ID: "short", Values: []PosItem{{TagSrchPattern: "N.*"}, {TagSrchPattern: "V.*"}}},
+		{ID: "exact", Values: []PosItem{{TagSrchPattern: "NN|NNS|VBP"}, {TagSrchPattern: "DT"}}},
+		{ID: "broken", Values: []PosItem{{TagSrchPattern: "("}}},
+	}
+	ans := guessTagset("tag", []string{"DT", "NN", "NNS", "VBP"}, tagsets)
+	assert.Equal(t, &TagsetGuess{PosID: "exact", Attr: "tag", Coverage: 1}, ans)
+	assert.Nil(t, guessTagset("tag", []string{"DT", "X", "Y", "NN"}, tagsets))
+	assert.Nil(t, guessTagset("tag", []string{}, tagsets))
+}
+
+func TestOrderAttrsByColumns(t *testing.T) {
+	sample := [][]string{
+		{"The", "the", "DT"},
+		{"cats", "cat", "NNS"},
+		{"sleep", "sleep", "VBP"},
+	}
+	values := map[string][]string{
+		"word":  {"The", "cats", "sleep"},
+		"lemma": {"the", "cat", "sleep"},
+		"tag":   {"DT", "NNS", "VBP"},
+		"foo":   {"x", "y", "sleep"},
+	}
+	valuesOf := func(attr string) ([]string, error) {
+		if v, ok := values[attr]; ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("unknown attribute %s", attr)
+	}
+
+	ans, ok := orderAttrsByColumns([]string{"lemma", "tag", "word"}, sample, valuesOf)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"word", "lemma", "tag"}, ans)
+
+	ans, ok = orderAttrsByColumns([]string{"foo", "tag", "missing", "word"}, sample, valuesOf)
+	assert.False(t, ok)
+	assert.Equal(t, []string{"word", "tag", "foo", "missing"}, ans)
+
+	ans, ok = orderAttrsByColumns([]string{"tag", "word"}, [][]string{}, valuesOf)
+	assert.False(t, ok)
+	assert.Equal(t, []string{"tag", "word"}, ans)
+}
+
+func TestOrderAttrsByColumnsAmbiguous(t *testing.T) {
+	// "lemma" matches both the first and the second column,
+	// "word" matches the first column better
+	sample := [][]string{{"cat", "cat"}, {"Dogs", "dog"}, {"sleep", "sleep"}}
+	values := map[string][]string{
+		"word":  {"cat", "Dogs", "sleep"},
+		"lemma": {"cat", "dog", "sleep"},
+	}
+	ans, ok := orderAttrsByColumns(
+		[]string{"lemma", "word"},
+		sample,
+		func(attr string) ([]string, error) { return values[attr], nil },
+	)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"word", "lemma"}, ans)
+}
+
+func TestLowercaseAttrUsesDyntype(t *testing.T) {
+	g := &generator{}
+	entry := g.lowercaseAttr("lc", "word")
+	assert.Equal(t, "lc", entry.Attribute.Name)
+	props := make(map[string]string)
+	for _, e := range entry.Attribute.Entries {
+		props[e.Property.Key] = e.Property.Value
+	}
+	assert.Equal(t, "index", props["DYNTYPE"])
+	assert.NotContains(t, props, "TYPE")
+	assert.Equal(t, "word", props["FROMATTR"])
+	assert.Equal(t, "utf8lowercase", props["DYNAMIC"])
+}
+
+func writeGzipFile(t *testing.T, path, data string) {
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	_, err = gz.Write([]byte(data))
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+}
+
+func TestGeneratorInspectVertical(t *testing.T) {
+	vertPath := filepath.Join(t.TempDir(), "vertical.gz")
+	writeGzipFile(t, vertPath, testGeneratorVertical)
+	g := &generator{
+		args: GenerateArgs{Vertical: vertPath},
+		data: &indexedData{
+			attrs:   []string{"word", "lemma"},
+			structs: map[string][]string{"doc": {"id"}, "s": {}},
+		},
+	}
+	assert.NoError(t, g.inspectVertical(context.Background()))
+	assert.Equal(t, int64(3), g.vertical.NumTokens)
+	assert.Equal(t, []string{"cats", "cat", "NNS"}, g.vertical.TokenSample[1])
+
+	notes := g.verticalNotes()
+	texts := make([]string, len(notes))
+	for i, n := range notes {
+		texts[i] = n.Comment.Text
+	}
+	assert.Equal(
+		t,
+		[]string{
+			" the vertical file has 3 columns but 2 attributes are indexed",
+			" attributes of structure doc found in the vertical file are not indexed: genre",
+			" structure note found in the vertical file is not indexed",
+		},
+		texts,
+	)
+	_, err := parser.SerializeToString(&parser.Document{Entries: notes})
+	assert.NoError(t, err)
+}
+
+func TestGeneratorInspectVerticalMissing(t *testing.T) {
+	g := &generator{
+		args: GenerateArgs{Vertical: filepath.Join(t.TempDir(), "missing")},
+		data: &indexedData{attrs: []string{"word"}, structs: map[string][]string{}},
+	}
+	assert.Error(t, g.inspectVertical(context.Background()))
+}
+
+func TestGeneratorWriteTmpRegistry(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, corpusID := range []string{"syn2020", "sub/syn2020"} {
+		g := &generator{
+			conf:     &corpus.CorporaSetup{RegistryTmpDir: tmpDir},
+			corpusID: corpusID,
+			args:     GenerateArgs{DataPath: "/data/syn2020/"},
+			data: &indexedData{
+				attrs:   []string{"word", "lemma"},
+				structs: map[string][]string{"doc": {"id"}},
+			},
+		}
+		tmpReg, err := g.writeTmpRegistry()
+		if assert.NoError(t, err, corpusID) {
+			assert.Equal(t, tmpDir, filepath.Dir(tmpReg), corpusID)
+			doc, err := parser.ParseRegistryFile(tmpReg)
+			assert.NoError(t, err, corpusID)
+			path, _ := doc.Prop("PATH")
+			assert.Equal(t, "/data/syn2020/", path)
+			assert.NotNil(t, doc.Structure("doc").Attribute("id"))
+			assert.NoError(t, os.Remove(tmpReg))
+		}
+	}
+}
+
+func TestGenerateRegistryDataPathErrors(t *testing.T) {
+	conf := &corpus.CorporaSetup{RegistryTmpDir: t.TempDir()}
+	dataDir := t.TempDir()
+	dataFile := filepath.Join(dataDir, "word.lex")
+	assert.NoError(t, os.WriteFile(dataFile, []byte{}, 0644))
+
+	tests := []struct {
+		path string
+		err  error
+	}{
+		{filepath.Join(dataDir, "missing"), corpus.ErrNotFound},
+		{dataFile, corpus.ErrInvalidArgs},
+		{t.TempDir(), corpus.ErrInvalidArgs},
+	}
+	for _, tst := range tests {
+		_, err := GenerateRegistry(
+			context.Background(), "sub/corp", GenerateArgs{DataPath: tst.path}, conf, nil)
+		assert.ErrorIs(t, err, tst.err, tst.path)
+	}
+}
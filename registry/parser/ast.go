@@ -0,0 +1,236 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	KeywordAttribute = "ATTRIBUTE"
+	KeywordStructure = "STRUCTURE"
+)
+
+// Comment is a registry comment line. The Text
+// does not contain the leading '#' character.
+type Comment struct {
+	Text string `json:"text"`
+}
+
+// Property is a single "KEY value" registry item.
+// Values are kept exactly as written in the registry
+// (i.e. escape sequences inside quoted values are
+// not interpreted).
+type Property struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Quoted bool   `json:"quoted"`
+}
+
+// Attribute represents a positional attribute or
+// a structural attribute (in case it is nested in
+// a Structure).
+type Attribute struct {
+	Name    string   `json:"name"`
+	Entries []*Entry `json:"entries"`
+}
+
+// Prop returns a value of a property directly
+// attached to the attribute.
+func (a *Attribute) Prop(key string) (string, bool) {
+	return findProp(a.Entries, key)
+}
+
+// Structure represents a registry STRUCTURE section
+type Structure struct {
+	Name    string   `json:"name"`
+	Entries []*Entry `json:"entries"`
+}
+
+// Prop returns a value of a property directly
+// attached to the structure.
+func (s *Structure) Prop(key string) (string, bool) {
+	return findProp(s.Entries, key)
+}
+
+// Attributes returns all the structural attributes
+// in the order they are defined
+func (s *Structure) Attributes() []*Attribute {
+	return findAttributes(s.Entries)
+}
+
+// Attribute returns a structural attribute of a provided
+// name or nil if nothing is found.
+func (s *Structure) Attribute(name string) *Attribute {
+	for _, a := range s.Attributes() {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Entry is a single item of a registry document or of
+// an attribute/structure body. Exactly one of the fields
+// is expected to be set.
+type Entry struct {
+	Comment   *Comment   `json:"comment,omitempty"`
+	Property  *Property  `json:"property,omitempty"`
+	Attribute *Attribute `json:"attribute,omitempty"`
+	Structure *Structure `json:"structure,omitempty"`
+}
+
+func (e *Entry) String() string {
+	switch {
+	case e.Comment != nil:
+		return "#" + e.Comment.Text
+	case e.Property != nil:
+		return fmt.Sprintf("%s %s", e.Property.Key, e.Property.Value)
+	case e.Attribute != nil:
+		return fmt.Sprintf("%s %s", KeywordAttribute, e.Attribute.Name)
+	case e.Structure != nil:
+		return fmt.Sprintf("%s %s", KeywordStructure, e.Structure.Name)
+	}
+	return "<empty entry>"
+}
+
+// Document is a parsed registry file
+type Document struct {
+	Entries []*Entry `json:"entries"`
+}
+
+// Prop returns a value of a top-level registry property
+func (doc *Document) Prop(key string) (string, bool) {
+	return findProp(doc.Entries, key)
+}
+
+// Attributes returns all the positional attributes
+// in the order they are defined
+func (doc *Document) Attributes() []*Attribute {
+	return findAttributes(doc.Entries)
+}
+
+// Attribute returns a positional attribute of a provided
+// name or nil if nothing is found.
+func (doc *Document) Attribute(name string) *Attribute {
+	for _, a := range doc.Attributes() {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Structures returns all the structures in the order
+// they are defined
+func (doc *Document) Structures() []*Structure {
+	ans := make([]*Structure, 0, len(doc.Entries))
+	for _, e := range doc.Entries {
+		if e.Structure != nil {
+			ans = append(ans, e.Structure)
+		}
+	}
+	return ans
+}
+
+// Structure returns a structure of a provided name
+// or nil if nothing is found.
+func (doc *Document) Structure(name string) *Structure {
+	for _, s := range doc.Structures() {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func findProp(entries []*Entry, key string) (string, bool) {
+	for _, e := range entries {
+		if e.Property != nil && e.Property.Key == key {
+			return e.Property.Value, true
+		}
+	}
+	return "", false
+}
+
+func findAttributes(entries []*Entry) []*Attribute {
+	ans := make([]*Attribute, 0, len(entries))
+	for _, e := range entries {
+		if e.Attribute != nil {
+			ans = append(ans, e.Attribute)
+		}
+	}
+	return ans
+}
+
+// ---------- constructors used by grammar actions
+
+func toEntries(v any) []*Entry {
+	items, ok := v.([]any)
+	if !ok {
+		return []*Entry{}
+	}
+	ans := make([]*Entry, 0, len(items))
+	for _, item := range items {
+		if e, ok := item.(*Entry); ok {
+			ans = append(ans, e)
+		}
+	}
+	return ans
+}
+
+func newDocument(entries any) (*Document, error) {
+	return &Document{Entries: toEntries(entries)}, nil
+}
+
+func newComment(text []byte) (*Entry, error) {
+	return &Entry{
+		Comment: &Comment{Text: strings.TrimSuffix(string(text[1:]), "\r")},
+	}, nil
+}
+
+func newProperty(key, value any) (*Entry, error) {
+	prop, ok := value.(*Property)
+	if !ok {
+		return nil, fmt.Errorf("invalid value of property %v", key)
+	}
+	prop.Key = key.(string)
+	return &Entry{Property: prop}, nil
+}
+
+func newQuotedValue(text []byte) (*Property, error) {
+	return &Property{Value: string(text[1 : len(text)-1]), Quoted: true}, nil
+}
+
+func newBareValue(text []byte) (*Property, error) {
+	return &Property{Value: string(text)}, nil
+}
+
+func newAttribute(name, body any) (*Entry, error) {
+	return &Entry{
+		Attribute: &Attribute{Name: name.(string), Entries: toEntries(body)},
+	}, nil
+}
+
+func newStructure(name, body any) (*Entry, error) {
+	return &Entry{
+		Structure: &Structure{Name: name.(string), Entries: toEntries(body)},
+	}, nil
+}
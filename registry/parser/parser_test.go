@@ -0,0 +1,167 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBasicRegistry(t *testing.T) {
+	doc, err := ParseRegistryFile(filepath.Join("testdata", "susanne"))
+	assert.NoError(t, err)
+
+	name, ok := doc.Prop("NAME")
+	assert.True(t, ok)
+	assert.Equal(t, "Susanne", name)
+	info, _ := doc.Prop("INFO")
+	assert.Equal(t, `Susanne corpus, see \"http://www.grsampson.net/RSue.html\"`, info)
+	assert.Equal(t, " Susanne corpus - a small English corpus", doc.Entries[0].Comment.Text)
+
+	attrs := doc.Attributes()
+	assert.Len(t, attrs, 4)
+	assert.Equal(t, "word", attrs[0].Name)
+	assert.Empty(t, attrs[0].Entries)
+	dyn, _ := doc.Attribute("lc").Prop("DYNAMIC")
+	assert.Equal(t, "utf8lowercase", dyn)
+
+	structs := doc.Structures()
+	assert.Len(t, structs, 3)
+	assert.Len(t, doc.Structure("doc").Attributes(), 4)
+	assert.NotNil(t, doc.Structure("doc").Attribute("wordcount"))
+	assert.Nil(t, doc.Structure("s").Attribute("id"))
+}
+
+func TestParseUnusualFormatting(t *testing.T) {
+	doc, err := ParseRegistryFile(filepath.Join("testdata", "syn_mixed"))
+	assert.NoError(t, err)
+
+	name, _ := doc.Prop("NAME")
+	assert.Equal(t, "SYN v9", name)
+	lemma := doc.Attribute("lemma")
+	assert.Len(t, lemma.Entries, 3)
+	assert.Equal(t, " a comment inside an attribute", lemma.Entries[2].Comment.Text)
+	mv, _ := doc.Attribute("tag").Prop("MULTIVALUE")
+	assert.Equal(t, "n", mv)
+	g := doc.Structure("g")
+	disp, _ := g.Prop("DISPLAYBEGIN")
+	assert.Equal(t, "_EMPTY_", disp)
+	author := doc.Structure("doc").Attribute("author")
+	sep, _ := author.Prop("MULTISEP")
+	assert.Equal(t, ";", sep)
+	dflt, ok := doc.Structure("doc").Prop("DEFAULTVALUE")
+	assert.True(t, ok)
+	assert.Equal(t, "", dflt)
+}
+
+func TestParseErrors(t *testing.T) {
+	items := []struct {
+		src  string
+		line int
+		col  int
+	}{
+		{"ATTRIBUTE word {\n  LABEL \"w\"\n", 3, 1},
+		{"NAME \"foo\nPATH /x", 1, 10},
+		{"}\n", 1, 1},
+		{"NAME", 1, 5},
+		{"STRUCTURE doc {\n  STRUCTURE s\n}", 2, 12},
+		{"ATTRIBUTE lc {\n  ATTRIBUTE x\n}", 2, 12},
+	}
+	for _, item := range items {
+		_, err := ParseRegistry("test", []byte(item.src))
+		perr, ok := err.(*ParseError)
+		if assert.True(t, ok, "expected parse error for %q", item.src) {
+			assert.Equal(t, item.line, perr.Line, "line of error in %q", item.src)
+			assert.Equal(t, item.col, perr.Col, "column of error in %q", item.src)
+		}
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	doc, err := ParseRegistry("test", []byte("\n\n  \n"))
+	assert.NoError(t, err)
+	assert.Empty(t, doc.Entries)
+}
+
+func TestParseQuotedValues(t *testing.T) {
+	doc, err := ParseRegistry("test", []byte(
+		"INFO \"a \\\"quoted\\\" # text\"\nLABEL \"\"\nPATH /corpora/data/syn # comment\n"))
+	assert.NoError(t, err)
+	assert.Len(t, doc.Entries, 4)
+	assert.Equal(t, &Property{Key: "INFO", Value: `a \"quoted\" # text`, Quoted: true}, doc.Entries[0].Property)
+	assert.Equal(t, &Property{Key: "LABEL", Value: "", Quoted: true}, doc.Entries[1].Property)
+	assert.Equal(t, &Property{Key: "PATH", Value: "/corpora/data/syn"}, doc.Entries[2].Property)
+	assert.Equal(t, " comment", doc.Entries[3].Comment.Text)
+}
+
+func TestParseNestedStructAttrs(t *testing.T) {
+	doc, err := ParseRegistry("test", []byte(`
+STRUCTURE doc {
+	# document metadata
+	ATTRIBUTE id
+	ATTRIBUTE title {
+		LABEL "Title"
+		MULTIVALUE y
+	}
+	DISPLAYTAG 0
+}
+STRUCTURE s
+`))
+	assert.NoError(t, err)
+	doc2 := doc.Structure("doc")
+	assert.Len(t, doc2.Entries, 4)
+	assert.Equal(t, " document metadata", doc2.Entries[0].Comment.Text)
+	assert.Empty(t, doc2.Attribute("id").Entries)
+	label, _ := doc2.Attribute("title").Prop("LABEL")
+	assert.Equal(t, "Title", label)
+	disp, _ := doc2.Prop("DISPLAYTAG")
+	assert.Equal(t, "0", disp)
+	assert.Empty(t, doc.Structure("s").Entries)
+	// structural attributes are not positional ones
+	assert.Empty(t, doc.Attributes())
+}
+
+func TestParseKeywordLikeKeys(t *testing.T) {
+	// keys only starting with a keyword are regular properties
+	doc, err := ParseRegistry("test", []byte("ATTRIBUTES_ORDER x\nSTRUCTURELIST doc,s\n"))
+	assert.NoError(t, err)
+	v, ok := doc.Prop("ATTRIBUTES_ORDER")
+	assert.True(t, ok)
+	assert.Equal(t, "x", v)
+	v, _ = doc.Prop("STRUCTURELIST")
+	assert.Equal(t, "doc,s", v)
+}
+
+func TestParseNonUTF8(t *testing.T) {
+	// "LABEL český" encoded in ISO-8859-2
+	doc, err := ParseRegistry("test", []byte("ENCODING iso8859-2\nLABEL \"\xe8esk\xfd\"\n"))
+	assert.NoError(t, err)
+	v, ok := doc.Prop("LABEL")
+	assert.True(t, ok)
+	assert.Equal(t, "\xe8esk\xfd", v)
+}
+
+func TestParseFileNotFound(t *testing.T) {
+	_, err := ParseRegistryFile(filepath.Join("testdata", "nonexistent"))
+	assert.Error(t, err)
+	_, ok := err.(*ParseError)
+	assert.False(t, ok)
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ParseError describes a syntax error including its
+// position within a parsed source.
+type ParseError struct {
+	Filename string
+	Line     int
+	Col      int
+	Msg      string
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", err.Filename, err.Line, err.Col, err.Msg)
+}
+
+// newParseError converts the first error reported by the generated
+// parser into a ParseError. The line and column are derived from
+// the byte offset as the generated parser counts a newline character
+// as a part of the following line.
+func newParseError(name string, data []byte, err error) error {
+	var errs errList
+	if !errors.As(err, &errs) || len(errs) == 0 {
+		return err
+	}
+	var perr *parserError
+	if !errors.As(errs[0], &perr) {
+		return err
+	}
+	ans := &ParseError{Filename: name, Line: 1, Col: 1, Msg: perr.Inner.Error()}
+	for _, c := range string(data[:min(perr.pos.offset, len(data))]) {
+		if c == '\n' {
+			ans.Line++
+			ans.Col = 1
+		} else {
+			ans.Col++
+		}
+	}
+	return ans
+}
+
+// ParseRegistry parses registry data and returns its
+// structured representation. The name argument is used
+// only for error reporting.
+func ParseRegistry(name string, data []byte) (*Document, error) {
+	// registries are not required to be UTF-8 encoded
+	// (see the ENCODING property)
+	ans, err := Parse(name, data, AllowInvalidUTF8(true))
+	if err != nil {
+		return nil, newParseError(name, data, err)
+	}
+	doc, ok := ans.(*Document)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse registry %s: unexpected parser result", name)
+	}
+	return doc, nil
+}
+
+// ParseRegistryFile parses a registry file
+func ParseRegistryFile(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRegistry(path, data)
+}
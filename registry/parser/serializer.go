@@ -0,0 +1,100 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	indentUnit = "    "
+)
+
+type serializer struct {
+	w   io.Writer
+	err error
+}
+
+func (s *serializer) writeLine(indent int, line string) {
+	if s.err != nil {
+		return
+	}
+	_, s.err = fmt.Fprintf(s.w, "%s%s\n", strings.Repeat(indentUnit, indent), line)
+}
+
+func (s *serializer) writeEntries(entries []*Entry, indent int) {
+	for i, e := range entries {
+		switch {
+		case e.Comment != nil:
+			s.writeLine(indent, "#"+e.Comment.Text)
+		case e.Property != nil:
+			s.writeLine(indent, e.Property.Key+" "+e.Property.serializedValue())
+		case e.Attribute != nil:
+			if indent == 0 && i > 0 && entries[i-1].Attribute == nil {
+				s.writeLine(0, "")
+			}
+			s.writeBlock(indent, KeywordAttribute, e.Attribute.Name, e.Attribute.Entries)
+		case e.Structure != nil:
+			if indent == 0 && i > 0 && entries[i-1].Structure == nil {
+				s.writeLine(0, "")
+			}
+			s.writeBlock(indent, KeywordStructure, e.Structure.Name, e.Structure.Entries)
+		}
+	}
+}
+
+func (s *serializer) writeBlock(indent int, keyword, name string, entries []*Entry) {
+	if len(entries) == 0 {
+		s.writeLine(indent, keyword+" "+name)
+		return
+	}
+	s.writeLine(indent, keyword+" "+name+" {")
+	s.writeEntries(entries, indent+1)
+	s.writeLine(indent, "}")
+}
+
+func (p *Property) serializedValue() string {
+	if p.Quoted {
+		return "\"" + p.Value + "\""
+	}
+	return p.Value
+}
+
+// Serialize writes the document in the Manatee registry
+// format. The order of all the entries (including comments)
+// is preserved, groups of top-level attributes and structures
+// are separated by an empty line. The document should be validated first
+// (see Validate) as the function does not check whether
+// the result is parseable.
+func Serialize(doc *Document, w io.Writer) error {
+	s := &serializer{w: w}
+	s.writeEntries(doc.Entries, 0)
+	return s.err
+}
+
+// SerializeToString is a convenience variant of Serialize
+func SerializeToString(doc *Document) (string, error) {
+	var buff strings.Builder
+	if err := Serialize(doc, &buff); err != nil {
+		return "", err
+	}
+	return buff.String(), nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundTrip(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, files)
+	for _, file := range files {
+		doc1, err := ParseRegistryFile(file)
+		if !assert.NoError(t, err, file) {
+			continue
+		}
+		assert.NoError(t, doc1.Validate(), file)
+		src, err := SerializeToString(doc1)
+		assert.NoError(t, err, file)
+		doc2, err := ParseRegistry(file, []byte(src))
+		if assert.NoError(t, err, file) {
+			assert.Equal(t, doc1, doc2, file)
+		}
+		// serialization must be stable
+		src2, err := SerializeToString(doc2)
+		assert.NoError(t, err, file)
+		assert.Equal(t, src, src2, file)
+	}
+}
+
+func TestRoundTripViaJSON(t *testing.T) {
+	doc1, err := ParseRegistryFile(filepath.Join("testdata", "syn_mixed"))
+	assert.NoError(t, err)
+	data, err := json.Marshal(doc1)
+	assert.NoError(t, err)
+	var doc2 Document
+	assert.NoError(t, json.Unmarshal(data, &doc2))
+	assert.NoError(t, doc2.Validate())
+	src, err := SerializeToString(&doc2)
+	assert.NoError(t, err)
+	doc3, err := ParseRegistry("json", []byte(src))
+	assert.NoError(t, err)
+	assert.Equal(t, doc1, doc3)
+}
+
+func TestSerializeKeepsFormatting(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("testdata", "susanne"))
+	assert.NoError(t, err)
+	doc, err := ParseRegistry("susanne", src)
+	assert.NoError(t, err)
+	ans, err := SerializeToString(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, string(src), ans)
+}
+
+func TestValidate(t *testing.T) {
+	doc := &Document{
+		Entries: []*Entry{
+			{Property: &Property{Key: "NAME", Value: "Foo bar"}},
+			{Property: &Property{Key: "INFO", Value: `a "quoted" value`, Quoted: true}},
+			{Property: &Property{Key: "1PATH", Value: "/x"}},
+			{Comment: &Comment{Text: "multi\nline"}},
+			{},
+			{Attribute: &Attribute{
+				Name: "word",
+				Entries: []*Entry{
+					{Attribute: &Attribute{Name: "nested"}},
+				},
+			}},
+			{Structure: &Structure{
+				Name: "doc",
+				Entries: []*Entry{
+					{Structure: &Structure{Name: "s"}},
+					{Attribute: &Attribute{Name: "id"}},
+				},
+			}},
+			{Property: &Property{Key: "STRUCTURE", Value: "p"}},
+		},
+	}
+	err := doc.Validate()
+	verrs, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+	paths := make([]string, len(verrs))
+	for i, verr := range verrs {
+		paths[i] = verr.Path
+	}
+	assert.Equal(
+		t,
+		[]string{
+			"entries[0]",
+			"entries[1]",
+			"entries[2]",
+			"entries[3]",
+			"entries[4]",
+			"entries[5].word[0]",
+			"entries[6].doc[0]",
+			"entries[7]",
+		},
+		paths,
+	)
+}
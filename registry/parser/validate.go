@@ -0,0 +1,193 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError describes a problem found in a document
+// which would prevent it from being serialized into a valid
+// registry file.
+type ValidationError struct {
+	Path string `json:"path"`
+	Msg  string `json:"message"`
+}
+
+func (err ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Path, err.Msg)
+}
+
+// ValidationErrors is a list of all the problems found
+// in a document
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+type scope int
+
+const (
+	scopeTop scope = iota
+	scopeStructure
+	scopeAttribute
+)
+
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) addError(path, msg string, args ...any) {
+	v.errs = append(v.errs, ValidationError{Path: path, Msg: fmt.Sprintf(msg, args...)})
+}
+
+func (v *validator) validateEntries(entries []*Entry, sc scope, path string) {
+	for i, e := range entries {
+		entryPath := fmt.Sprintf("%s[%d]", path, i)
+		if e == nil {
+			v.addError(entryPath, "empty entry")
+			continue
+		}
+		numSet := 0
+		for _, isSet := range []bool{
+			e.Comment != nil, e.Property != nil, e.Attribute != nil, e.Structure != nil} {
+			if isSet {
+				numSet++
+			}
+		}
+		if numSet != 1 {
+			v.addError(entryPath, "entry must contain exactly one of comment, property, attribute, structure")
+			continue
+		}
+		switch {
+		case e.Comment != nil:
+			if strings.ContainsAny(e.Comment.Text, "\r\n") {
+				v.addError(entryPath, "comment must not contain line breaks")
+			}
+		case e.Property != nil:
+			v.validateProperty(e.Property, entryPath)
+		case e.Attribute != nil:
+			if sc == scopeAttribute {
+				v.addError(entryPath, "attribute cannot be nested in another attribute")
+			}
+			if !isValidIdentifier(e.Attribute.Name) {
+				v.addError(entryPath, "invalid attribute name '%s'", e.Attribute.Name)
+			}
+			v.validateEntries(
+				e.Attribute.Entries, scopeAttribute, fmt.Sprintf("%s.%s", entryPath, e.Attribute.Name))
+		case e.Structure != nil:
+			if sc != scopeTop {
+				v.addError(entryPath, "structure can be defined only at the top level")
+			}
+			if !isValidIdentifier(e.Structure.Name) {
+				v.addError(entryPath, "invalid structure name '%s'", e.Structure.Name)
+			}
+			v.validateEntries(
+				e.Structure.Entries, scopeStructure, fmt.Sprintf("%s.%s", entryPath, e.Structure.Name))
+		}
+	}
+}
+
+func (v *validator) validateProperty(prop *Property, path string) {
+	if !isValidKey(prop.Key) {
+		v.addError(path, "invalid property name '%s'", prop.Key)
+	}
+	if prop.Key == KeywordAttribute || prop.Key == KeywordStructure {
+		v.addError(path, "%s must be defined as an attribute/structure, not as a property", prop.Key)
+	}
+	if prop.Quoted {
+		if strings.ContainsAny(prop.Value, "\r\n") {
+			v.addError(path, "value of %s must not contain line breaks", prop.Key)
+			return
+		}
+		for i := 0; i < len(prop.Value); i++ {
+			if prop.Value[i] == '\\' {
+				i++
+				if i == len(prop.Value) {
+					v.addError(path, "value of %s ends with an unfinished escape sequence", prop.Key)
+				}
+
+			} else if prop.Value[i] == '"' {
+				v.addError(path, "value of %s contains unescaped quotes", prop.Key)
+				return
+			}
+		}
+
+	} else if !isValidIdentifier(prop.Value) {
+		v.addError(path, "value of %s must be quoted", prop.Key)
+	}
+}
+
+// isKeyStart, isKeyChar and isBareChar mirror
+// the Key and BareValue rules of grammar.peg
+
+func isKeyStart(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '_'
+}
+
+func isKeyChar(c byte) bool {
+	return isKeyStart(c) || c >= '0' && c <= '9'
+}
+
+func isBareChar(c byte) bool {
+	return c != ' ' && c != '\t' && c != '\r' && c != '\n' &&
+		c != '{' && c != '}' && c != '"' && c != '#'
+}
+
+func isValidKey(s string) bool {
+	if s == "" || !isKeyStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isKeyChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isValidIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isBareChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate tests whether the document can be serialized into
+// a valid registry file. In case of problems, ValidationErrors
+// is returned.
+func (doc *Document) Validate() error {
+	v := &validator{}
+	v.validateEntries(doc.Entries, scopeTop, "entries")
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
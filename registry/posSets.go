@@ -20,12 +20,25 @@ package registry
 
 import (
 	"encoding/json"
+	"fmt"
+	"regexp"
 	"strings"
 )
 
 type PosItem struct {
-	Label          string `json:"label"`
-	TagSrchPattern string `json:"tagSrchPattern"`
+	Label          string            `json:"label" yaml:"label"`
+	Labels         map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	TagSrchPattern string            `json:"tagSrchPattern" yaml:"tagSrchPattern"`
+}
+
+// LocalizedLabel returns a label in a specified language.
+// In case there is no such translation, the default label
+// is returned.
+func (pi PosItem) LocalizedLabel(lang string) string {
+	if v, ok := pi.Labels[lang]; ok && v != "" {
+		return v
+	}
+	return pi.Label
 }
 
 type PosSimple struct {
@@ -33,90 +46,193 @@ type PosSimple struct {
 	Name string `json:"name"`
 }
 
+// Pos describes a tagset along with patterns needed to
+// search for individual parts of speech.
+// Values are used for WPOSLIST, LemmaValues (if defined)
+// are used for LPOSLIST.
 type Pos struct {
-	ID          string
-	Name        string
-	Description string
-	Values      []PosItem
+	ID           string            `json:"id" yaml:"id"`
+	Name         string            `json:"name" yaml:"name"`
+	Names        map[string]string `json:"names,omitempty" yaml:"names,omitempty"`
+	Description  string            `json:"description" yaml:"description"`
+	Descriptions map[string]string `json:"descriptions,omitempty" yaml:"descriptions,omitempty"`
+	Values       []PosItem         `json:"values" yaml:"values"`
+	LemmaValues  []PosItem         `json:"lemmaValues,omitempty" yaml:"lemmaValues,omitempty"`
 }
 
-func (p *Pos) ExportWposlist() string {
-	ans := make([]string, len(p.Values)*2+1)
+func exportPosList(items []PosItem) string {
+	ans := make([]string, len(items)*2+1)
 	ans[0] = ""
-	for i, v := range p.Values {
+	for i, v := range items {
 		ans[2*i+1] = v.Label
 		ans[2*i+2] = v.TagSrchPattern
 	}
 	return strings.Join(ans, ",")
 }
 
+func (p *Pos) ExportWposlist() string {
+	return exportPosList(p.Values)
+}
+
+// ExportLposlist produces a value for the LPOSLIST registry
+// property. In case there are no lemma-specific values,
+// the WPOSLIST ones are used.
+func (p *Pos) ExportLposlist() string {
+	if len(p.LemmaValues) > 0 {
+		return exportPosList(p.LemmaValues)
+	}
+	return exportPosList(p.Values)
+}
+
+// Localized returns a copy of the tagset with names, descriptions
+// and labels translated to a specified language (where available).
+func (p Pos) Localized(lang string) Pos {
+	localize := func(items []PosItem) []PosItem {
+		ans := make([]PosItem, len(items))
+		for i, v := range items {
+			ans[i] = v
+			ans[i].Label = v.LocalizedLabel(lang)
+		}
+		return ans
+	}
+	ans := p
+	if v, ok := p.Names[lang]; ok && v != "" {
+		ans.Name = v
+	}
+	if v, ok := p.Descriptions[lang]; ok && v != "" {
+		ans.Description = v
+	}
+	ans.Values = localize(p.Values)
+	if len(p.LemmaValues) > 0 {
+		ans.LemmaValues = localize(p.LemmaValues)
+	}
+	return ans
+}
+
+// Validate tests whether the tagset can be exported
+// to valid WPOSLIST/LPOSLIST values.
+func (p *Pos) Validate() error {
+	if !posIDRegexp.MatchString(p.ID) {
+		return fmt.Errorf("invalid tagset ID '%s'", p.ID)
+	}
+	if p.Name == "" {
+		return fmt.Errorf("missing name of tagset %s", p.ID)
+	}
+	if len(p.Values) == 0 {
+		return fmt.Errorf("tagset %s has no values", p.ID)
+	}
+	for _, items := range [][]PosItem{p.Values, p.LemmaValues} {
+		for _, v := range items {
+			labels := []string{v.Label}
+			for _, lab := range v.Labels {
+				labels = append(labels, lab)
+			}
+			for _, lab := range labels {
+				if lab == "" || strings.Contains(lab, ",") {
+					return fmt.Errorf("invalid label '%s' in tagset %s", lab, p.ID)
+				}
+			}
+			if strings.Contains(v.TagSrchPattern, ",") {
+				return fmt.Errorf(
+					"pattern '%s' in tagset %s must not contain ','", v.TagSrchPattern, p.ID)
+			}
+			if _, err := regexp.Compile(v.TagSrchPattern); err != nil {
+				return fmt.Errorf(
+					"invalid pattern '%s' in tagset %s: %w", v.TagSrchPattern, p.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
 func (p Pos) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
-		ID          string    `json:"id"`
-		Name        string    `json:"name"`
-		Description string    `json:"description"`
-		Values      []PosItem `json:"values"`
-		Wposlist    string    `json:"wposlist"`
+		ID           string            `json:"id"`
+		Name         string            `json:"name"`
+		Names        map[string]string `json:"names,omitempty"`
+		Description  string            `json:"description"`
+		Descriptions map[string]string `json:"descriptions,omitempty"`
+		Values       []PosItem         `json:"values"`
+		LemmaValues  []PosItem         `json:"lemmaValues,omitempty"`
+		Wposlist     string            `json:"wposlist"`
+		Lposlist     string            `json:"lposlist"`
 	}{
-		ID:          p.ID,
-		Name:        p.Name,
-		Description: p.Description,
-		Values:      p.Values,
-		Wposlist:    p.ExportWposlist(),
+		ID:           p.ID,
+		Name:         p.Name,
+		Names:        p.Names,
+		Description:  p.Description,
+		Descriptions: p.Descriptions,
+		Values:       p.Values,
+		LemmaValues:  p.LemmaValues,
+		Wposlist:     p.ExportWposlist(),
+		Lposlist:     p.ExportLposlist(),
 	})
 }
 
 var (
-	posList []Pos = []Pos{
+	posIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+	// builtinPosList contains tagsets which are always available
+	// (see TagsetCatalogue.Load). Files in the tagset directory add
+	// new tagsets or override the builtin ones with the same ID.
+	// Deleting an override restores the builtin tagset. The builtin
+	// tagsets themselves are read-only.
+	builtinPosList []Pos = []Pos{
 		{
-			ID:   "pp_tagset",
-			Name: "Prague positional tagset",
+			ID:          "pp_tagset",
+			Name:        "Prague positional tagset",
+			Names:       map[string]string{"cs": "Pražský poziční tagset", "en": "Prague positional tagset"},
+			Description: "Part of speech is encoded by the first position of a tag",
 			Values: []PosItem{
-				{"podstatné jméno", "N.*"},
-				{"přídavné jméno", "A.*"},
-				{"zájmeno", "P.*"},
-				{"číslovka", "C.*"},
-				{"sloveso", "V.*"},
-				{"příslovce", "D.*"},
-				{"předložka", "R.*"},
-				{"spojka", "J.*"},
-				{"částice", "T.*"},
-				{"citoslovce", "I.*"},
-				{"interpunkce", "Z.*"},
-				{"neznámý", "X.*"},
+				{Label: "podstatné jméno", Labels: map[string]string{"cs": "podstatné jméno", "en": "noun"}, TagSrchPattern: "N.*"},
+				{Label: "přídavné jméno", Labels: map[string]string{"cs": "přídavné jméno", "en": "adjective"}, TagSrchPattern: "A.*"},
+				{Label: "zájmeno", Labels: map[string]string{"cs": "zájmeno", "en": "pronoun"}, TagSrchPattern: "P.*"},
+				{Label: "číslovka", Labels: map[string]string{"cs": "číslovka", "en": "numeral"}, TagSrchPattern: "C.*"},
+				{Label: "sloveso", Labels: map[string]string{"cs": "sloveso", "en": "verb"}, TagSrchPattern: "V.*"},
+				{Label: "příslovce", Labels: map[string]string{"cs": "příslovce", "en": "adverb"}, TagSrchPattern: "D.*"},
+				{Label: "předložka", Labels: map[string]string{"cs": "předložka", "en": "preposition"}, TagSrchPattern: "R.*"},
+				{Label: "spojka", Labels: map[string]string{"cs": "spojka", "en": "conjunction"}, TagSrchPattern: "J.*"},
+				{Label: "částice", Labels: map[string]string{"cs": "částice", "en": "particle"}, TagSrchPattern: "T.*"},
+				{Label: "citoslovce", Labels: map[string]string{"cs": "citoslovce", "en": "interjection"}, TagSrchPattern: "I.*"},
+				{Label: "interpunkce", Labels: map[string]string{"cs": "interpunkce", "en": "punctuation"}, TagSrchPattern: "Z.*"},
+				{Label: "neznámý", Labels: map[string]string{"cs": "neznámý", "en": "unknown"}, TagSrchPattern: "X.*"},
 			},
 		},
 		{
-			ID:   "bnc",
-			Name: "BNC tagset",
+			ID:          "bnc",
+			Name:        "BNC tagset",
+			Names:       map[string]string{"cs": "Tagset BNC", "en": "BNC tagset"},
+			Description: "CLAWS5 tagset used by the British National Corpus",
 			Values: []PosItem{
-				{"adjective", "AJ."},
-				{"adverb", "AV."},
-				{"conjunction", "CJ."},
-				{"determiner", "AT0"},
-				{"noun", "NN."},
-				{"noun singular", "NN1"},
-				{"noun plural", "NN2"},
-				{"preposition", "PR."},
-				{"pronoun", "DPS"},
-				{"verb", "VV."},
+				{Label: "adjective", Labels: map[string]string{"cs": "přídavné jméno", "en": "adjective"}, TagSrchPattern: "AJ."},
+				{Label: "adverb", Labels: map[string]string{"cs": "příslovce", "en": "adverb"}, TagSrchPattern: "AV."},
+				{Label: "conjunction", Labels: map[string]string{"cs": "spojka", "en": "conjunction"}, TagSrchPattern: "CJ."},
+				{Label: "determiner", Labels: map[string]string{"cs": "determinátor", "en": "determiner"}, TagSrchPattern: "AT0"},
+				{Label: "noun", Labels: map[string]string{"cs": "podstatné jméno", "en": "noun"}, TagSrchPattern: "NN."},
+				{Label: "noun singular", Labels: map[string]string{"cs": "podstatné jméno (j. č.)", "en": "noun singular"}, TagSrchPattern: "NN1"},
+				{Label: "noun plural", Labels: map[string]string{"cs": "podstatné jméno (mn. č.)", "en": "noun plural"}, TagSrchPattern: "NN2"},
+				{Label: "preposition", Labels: map[string]string{"cs": "předložka", "en": "preposition"}, TagSrchPattern: "PR."},
+				{Label: "pronoun", Labels: map[string]string{"cs": "zájmeno", "en": "pronoun"}, TagSrchPattern: "DPS"},
+				{Label: "verb", Labels: map[string]string{"cs": "sloveso", "en": "verb"}, TagSrchPattern: "VV."},
 			},
 		},
 		{
-			ID:   "rapcor",
-			Name: "PoS from the Rapcor corpus",
+			ID:          "rapcor",
+			Name:        "PoS from the Rapcor corpus",
+			Names:       map[string]string{"cs": "Slovní druhy korpusu Rapcor", "en": "PoS from the Rapcor corpus"},
+			Description: "TreeTagger French tagset as used by the Rapcor corpus",
 			Values: []PosItem{
-				{"adjective", "ADJ"},
-				{"adverb", "ADV"},
-				{"conjunction", "KON"},
-				{"determiner", "DET.*"},
-				{"interjection", "INT"},
-				{"noun", "(NOM|NAM)"},
-				{"numeral", "NUM"},
-				{"preposition", "PRE.*"},
-				{"pronoun", "PRO.*"},
-				{"verb", "VER.*"},
-				{"full stop", "SENT"},
+				{Label: "adjective", Labels: map[string]string{"cs": "přídavné jméno", "en": "adjective"}, TagSrchPattern: "ADJ"},
+				{Label: "adverb", Labels: map[string]string{"cs": "příslovce", "en": "adverb"}, TagSrchPattern: "ADV"},
+				{Label: "conjunction", Labels: map[string]string{"cs": "spojka", "en": "conjunction"}, TagSrchPattern: "KON"},
+				{Label: "determiner", Labels: map[string]string{"cs": "determinátor", "en": "determiner"}, TagSrchPattern: "DET.*"},
+				{Label: "interjection", Labels: map[string]string{"cs": "citoslovce", "en": "interjection"}, TagSrchPattern: "INT"},
+				{Label: "noun", Labels: map[string]string{"cs": "podstatné jméno", "en": "noun"}, TagSrchPattern: "(NOM|NAM)"},
+				{Label: "numeral", Labels: map[string]string{"cs": "číslovka", "en": "numeral"}, TagSrchPattern: "NUM"},
+				{Label: "preposition", Labels: map[string]string{"cs": "předložka", "en": "preposition"}, TagSrchPattern: "PRE.*"},
+				{Label: "pronoun", Labels: map[string]string{"cs": "zájmeno", "en": "pronoun"}, TagSrchPattern: "PRO.*"},
+				{Label: "verb", Labels: map[string]string{"cs": "sloveso", "en": "verb"}, TagSrchPattern: "VER.*"},
+				{Label: "full stop", Labels: map[string]string{"cs": "tečka", "en": "full stop"}, TagSrchPattern: "SENT"},
 			},
 		},
 	}
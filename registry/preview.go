@@ -0,0 +1,179 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/mango"
+)
+
+const (
+	defaultPreviewLimit = 100
+	maxPreviewLimit     = 10000
+
+	PreviewEngineGo      = "go"
+	PreviewEngineManatee = "manatee"
+)
+
+var ErrPreviewArgs = fmt.Errorf("%w: invalid preview arguments", corpus.ErrInvalidRequest)
+
+// PreviewArgs specifies a dynamic function, its arguments (ARG1, ARG2)
+// and source values. The values are either provided directly or taken
+// from an attribute (fromAttr) of a corpus.
+type PreviewArgs struct {
+	Function string   `json:"function"`
+	Dynlib   string   `json:"dynlib"`
+	Args     []string `json:"args"`
+	Values   []string `json:"values"`
+	Corpus   string   `json:"corpus"`
+	FromAttr string   `json:"fromAttr"`
+	Limit    int      `json:"limit"`
+}
+
+// PreviewItem is a result of applying a dynamic function to a value.
+// For functions with a Go implementation, ManateeResult contains
+// the result produced by Manatee (if available) so possible
+// differences can be spotted.
+type PreviewItem struct {
+	Value         string  `json:"value"`
+	Result        string  `json:"result"`
+	ManateeResult *string `json:"manateeResult,omitempty"`
+	Mismatch      bool    `json:"mismatch,omitempty"`
+	Error         string  `json:"error,omitempty"`
+}
+
+type PreviewResult struct {
+	Function      string        `json:"function"`
+	Dynlib        string        `json:"dynlib"`
+	Funtype       string        `json:"funtype"`
+	Engine        string        `json:"engine"`
+	Items         []PreviewItem `json:"items"`
+	NumMismatches int           `json:"numMismatches"`
+	ManateeError  string        `json:"manateeError,omitempty"`
+}
+
+func previewSourceValues(args PreviewArgs, pool *corpus.CorpusPool) ([]string, error) {
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultPreviewLimit
+
+	} else if limit > maxPreviewLimit {
+		limit = maxPreviewLimit
+	}
+	if args.Corpus == "" {
+		if len(args.Values) > limit {
+			return args.Values[:limit], nil
+		}
+		return args.Values, nil
+	}
+	if args.FromAttr == "" {
+		return nil, fmt.Errorf("%w: corpus specified without fromAttr", ErrPreviewArgs)
+	}
+	handle, err := pool.Acquire(args.Corpus)
+	if err != nil {
+		return nil, err
+	}
+	defer handle.Release()
+	return mango.GetAttrValues(handle.Corpus(), args.FromAttr, limit)
+}
+
+// PreviewDynFn shows what a DYNAMIC attribute would produce.
+// Internal functions are evaluated by their Go implementations
+// and checked against Manatee, dynlib functions are called
+// via Manatee directly. Corpora (if requested) are obtained
+// from the pool.
+func PreviewDynFn(args PreviewArgs, dynFns *DynFnCatalogue, pool *corpus.CorpusPool) (*PreviewResult, error) {
+	if args.Dynlib == "" {
+		args.Dynlib = dynlibInternal
+	}
+	fn, ok := dynFns.Find(args.Function, args.Dynlib)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: function %s not found in dynlib %s", ErrPreviewArgs, args.Function, args.Dynlib)
+	}
+	if fn.Problem == DynFnProblemUndocumented || fn.Problem == DynFnProblemInvalidSignature {
+		return nil, fmt.Errorf(
+			"%w: function %s has no valid signature description", ErrPreviewArgs, fn.Name)
+	}
+	funtype, err := fn.FuntypeFor(len(args.Args))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrPreviewArgs, err)
+	}
+	if callErrs := fn.ValidateCall(funtype, args.Args); len(callErrs) > 0 {
+		return nil, callErrs
+	}
+	values, err := previewSourceValues(args, pool)
+	if err != nil {
+		return nil, err
+	}
+	ans := &PreviewResult{
+		Function: fn.Name,
+		Dynlib:   fn.Dynlib,
+		Funtype:  funtype,
+		Engine:   PreviewEngineManatee,
+		Items:    make([]PreviewItem, len(values)),
+	}
+	goImpl, hasGoImpl := internalDynFnImpl[fn.Name]
+	hasGoImpl = hasGoImpl && fn.Dynlib == dynlibInternal
+	if hasGoImpl {
+		ans.Engine = PreviewEngineGo
+	}
+	manateeFn, err := mango.CreateDynFun(funtype, fn.Dynlib, fn.Name, args.Args)
+	if err != nil {
+		if !hasGoImpl {
+			return nil, fmt.Errorf("failed to create function %s: %w", fn.Name, err)
+		}
+		ans.ManateeError = err.Error()
+
+	} else {
+		defer manateeFn.Close()
+	}
+
+	for i, value := range values {
+		item := &ans.Items[i]
+		item.Value = value
+		var manateeResult *string
+		if manateeFn != nil {
+			res, err := manateeFn.Apply(value)
+			if err != nil {
+				item.Error = err.Error()
+
+			} else {
+				manateeResult = &res
+			}
+		}
+		if !hasGoImpl {
+			if manateeResult != nil {
+				item.Result = *manateeResult
+			}
+			continue
+		}
+		item.Result, err = goImpl(value, args.Args)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrPreviewArgs, err)
+		}
+		item.ManateeResult = manateeResult
+		if manateeResult != nil && *manateeResult != item.Result {
+			item.Mismatch = true
+			ans.NumMismatches++
+		}
+	}
+	return ans, nil
+}
@@ -0,0 +1,295 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"masm/v3/corpus"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrTagsetNotFound = fmt.Errorf("tagset %w", corpus.ErrNotFound)
+	ErrTagsetExists   = fmt.Errorf("tagset %w", corpus.ErrAlreadyExists)
+	ErrTagsetReadOnly = fmt.Errorf("%w: tagset cannot be modified", corpus.ErrForbidden)
+)
+
+const (
+	tagsetFormatJSON = "json"
+	tagsetFormatYAML = "yaml"
+)
+
+type tagsetRecord struct {
+	pos Pos
+
+	// srcPath is empty for builtin tagsets which are not
+	// overridden by a file
+	srcPath string
+}
+
+func (rec *tagsetRecord) isBuiltin() bool {
+	return rec.srcPath == ""
+}
+
+// TagsetCatalogue contains all the tagsets available for WPOSLIST
+// and LPOSLIST. Builtin tagsets are always present, tagsets
+// loaded from the configured directory (one tagset per *.json,
+// *.yaml or *.yml file) are added to them and may also override
+// the builtin ones. Without a configured directory, the catalogue
+// is read-only.
+type TagsetCatalogue struct {
+	mu      sync.RWMutex
+	dirPath string
+	items   map[string]*tagsetRecord
+}
+
+func tagsetFileFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return tagsetFormatJSON
+	case ".yaml", ".yml":
+		return tagsetFormatYAML
+	}
+	return ""
+}
+
+func loadTagsetFile(path string) (Pos, error) {
+	var ans Pos
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ans, err
+	}
+	switch tagsetFileFormat(path) {
+	case tagsetFormatJSON:
+		err = json.Unmarshal(data, &ans)
+	case tagsetFormatYAML:
+		err = yaml.Unmarshal(data, &ans)
+	default:
+		err = fmt.Errorf("unsupported tagset file type")
+	}
+	if err != nil {
+		return ans, err
+	}
+	if ans.ID == "" {
+		ans.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return ans, ans.Validate()
+}
+
+func encodeTagset(pos Pos, format string) ([]byte, error) {
+	if format == tagsetFormatYAML {
+		return yaml.Marshal(pos)
+	}
+	// we cannot use Pos.MarshalJSON here as it adds
+	// exported WPOSLIST/LPOSLIST values
+	type storedPos Pos
+	return json.MarshalIndent(storedPos(pos), "", "    ")
+}
+
+// Load (re)reads tagsets from the configured directory. In case
+// of an error, the currently loaded tagsets are kept untouched.
+func (tc *TagsetCatalogue) Load() error {
+	items := make(map[string]*tagsetRecord)
+	for _, v := range builtinPosList {
+		items[v.ID] = &tagsetRecord{pos: v}
+	}
+	if tc.dirPath != "" {
+		entries, err := os.ReadDir(tc.dirPath)
+		if err != nil {
+			return fmt.Errorf("failed to load tagsets: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") ||
+				tagsetFileFormat(entry.Name()) == "" {
+				continue
+			}
+			path := filepath.Join(tc.dirPath, entry.Name())
+			pos, err := loadTagsetFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to load tagset file %s: %w", path, err)
+			}
+			if curr, ok := items[pos.ID]; ok && !curr.isBuiltin() {
+				return fmt.Errorf(
+					"failed to load tagset file %s: tagset %s already defined in %s",
+					path, pos.ID, curr.srcPath)
+			}
+			items[pos.ID] = &tagsetRecord{pos: pos, srcPath: path}
+		}
+	}
+	tc.mu.Lock()
+	tc.items = items
+	tc.mu.Unlock()
+	log.Info().
+		Str("dirPath", tc.dirPath).
+		Int("numTagsets", len(items)).
+		Msg("loaded tagsets")
+	return nil
+}
+
+// List returns all the tagsets. Builtin tagsets go first
+// (in their original order), the rest is sorted by ID.
+func (tc *TagsetCatalogue) List() []Pos {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	ans := make([]Pos, 0, len(tc.items))
+	for _, v := range builtinPosList {
+		ans = append(ans, tc.items[v.ID].pos)
+	}
+	other := make([]Pos, 0, len(tc.items)-len(builtinPosList))
+	for _, v := range tc.items {
+		if _, ok := findBuiltinPos(v.pos.ID); !ok {
+			other = append(other, v.pos)
+		}
+	}
+	sort.Slice(other, func(i, j int) bool {
+		return other[i].ID < other[j].ID
+	})
+	return append(ans, other...)
+}
+
+// Get returns a tagset with a specified ID
+func (tc *TagsetCatalogue) Get(posID string) (Pos, bool) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	if v, ok := tc.items[posID]; ok {
+		return v.pos, true
+	}
+	return Pos{}, false
+}
+
+func (tc *TagsetCatalogue) store(pos Pos, path, format string) error {
+	data, err := encodeTagset(pos, format)
+	if err != nil {
+		return err
+	}
+	fileMode := os.FileMode(0644)
+	if finfo, err := os.Stat(path); err == nil {
+		fileMode = finfo.Mode().Perm()
+	}
+	tmp, err := writeTempFile(tc.dirPath, filepath.Base(path), string(data), fileMode)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Create adds a new tagset and stores it as a JSON file
+// in the tagset directory.
+func (tc *TagsetCatalogue) Create(pos Pos) error {
+	if tc.dirPath == "" {
+		return fmt.Errorf("failed to create tagset %s: %w (no tagset directory configured)", pos.ID, ErrTagsetReadOnly)
+	}
+	if err := pos.Validate(); err != nil {
+		return fmt.Errorf("failed to create tagset: %w", err)
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if _, ok := tc.items[pos.ID]; ok {
+		return fmt.Errorf("failed to create tagset %s: %w", pos.ID, ErrTagsetExists)
+	}
+	path := filepath.Join(tc.dirPath, pos.ID+".json")
+	if err := tc.store(pos, path, tagsetFormatJSON); err != nil {
+		return fmt.Errorf("failed to create tagset %s: %w", pos.ID, err)
+	}
+	tc.items[pos.ID] = &tagsetRecord{pos: pos, srcPath: path}
+	return nil
+}
+
+// Update replaces an existing tagset. The original file (and its format)
+// is preserved. Updating a builtin tagset creates a file overriding it.
+func (tc *TagsetCatalogue) Update(pos Pos) error {
+	if tc.dirPath == "" {
+		return fmt.Errorf("failed to update tagset %s: %w (no tagset directory configured)", pos.ID, ErrTagsetReadOnly)
+	}
+	if err := pos.Validate(); err != nil {
+		return fmt.Errorf("failed to update tagset: %w", err)
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	curr, ok := tc.items[pos.ID]
+	if !ok {
+		return fmt.Errorf("failed to update tagset %s: %w", pos.ID, ErrTagsetNotFound)
+	}
+	path := curr.srcPath
+	if curr.isBuiltin() {
+		path = filepath.Join(tc.dirPath, pos.ID+".json")
+	}
+	if err := tc.store(pos, path, tagsetFileFormat(path)); err != nil {
+		return fmt.Errorf("failed to update tagset %s: %w", pos.ID, err)
+	}
+	tc.items[pos.ID] = &tagsetRecord{pos: pos, srcPath: path}
+	return nil
+}
+
+// Delete removes a tagset along with its file. Builtin tagsets
+// cannot be removed. In case a builtin tagset is overridden by a file,
+// the file is removed and the builtin version becomes active again.
+func (tc *TagsetCatalogue) Delete(posID string) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	curr, ok := tc.items[posID]
+	if !ok {
+		return fmt.Errorf("failed to delete tagset %s: %w", posID, ErrTagsetNotFound)
+	}
+	if curr.isBuiltin() {
+		return fmt.Errorf("failed to delete builtin tagset %s: %w", posID, ErrTagsetReadOnly)
+	}
+	if err := os.Remove(curr.srcPath); err != nil {
+		return fmt.Errorf("failed to delete tagset %s: %w", posID, err)
+	}
+	if builtin, ok := findBuiltinPos(posID); ok {
+		tc.items[posID] = &tagsetRecord{pos: builtin}
+
+	} else {
+		delete(tc.items, posID)
+	}
+	return nil
+}
+
+func findBuiltinPos(posID string) (Pos, bool) {
+	for _, v := range builtinPosList {
+		if v.ID == posID {
+			return v, true
+		}
+	}
+	return Pos{}, false
+}
+
+// NewTagsetCatalogue creates a catalogue and loads tagsets
+// from dirPath (if non-empty).
+func NewTagsetCatalogue(dirPath string) (*TagsetCatalogue, error) {
+	ans := &TagsetCatalogue{dirPath: dirPath}
+	if err := ans.Load(); err != nil {
+		return nil, err
+	}
+	return ans, nil
+}
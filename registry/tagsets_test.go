@@ -0,0 +1,163 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testTagsetJSON = `{"id": "my_tagset", "name": "My tagset",
+"values": [{"label": "noun", "tagSrchPattern": "N.*"}]}`
+
+	testTagsetYAML = `id: my_tagset
+name: My tagset (YAML)
+values:
+  - label: verb
+    tagSrchPattern: V.*
+`
+)
+
+func writeTagsetFile(t *testing.T, dir, name, data string) string {
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	return path
+}
+
+func TestTagsetCatalogueLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeTagsetFile(t, dir, "my_tagset.json", testTagsetJSON)
+	writeTagsetFile(t, dir, "README.txt", "ignored")
+	writeTagsetFile(t, dir, ".hidden.json", "ignored")
+	tc, err := NewTagsetCatalogue(dir)
+	assert.NoError(t, err)
+	pos, ok := tc.Get("my_tagset")
+	assert.True(t, ok)
+	assert.Equal(t, "My tagset", pos.Name)
+	list := tc.List()
+	assert.Len(t, list, len(builtinPosList)+1)
+	assert.Equal(t, builtinPosList[0].ID, list[0].ID)
+	assert.Equal(t, "my_tagset", list[len(list)-1].ID)
+}
+
+func TestTagsetCatalogueLoadConflict(t *testing.T) {
+	dir := t.TempDir()
+	writeTagsetFile(t, dir, "a.json", testTagsetJSON)
+	writeTagsetFile(t, dir, "b.yaml", testTagsetYAML)
+	_, err := NewTagsetCatalogue(dir)
+	assert.ErrorContains(t, err, "tagset my_tagset already defined")
+}
+
+func TestTagsetCatalogueBuiltinOverride(t *testing.T) {
+	dir := t.TempDir()
+	builtinID := builtinPosList[0].ID
+	writeTagsetFile(
+		t, dir, "override.yml",
+		"id: "+builtinID+"\nname: Overridden\nvalues:\n  - label: x\n    tagSrchPattern: X\n",
+	)
+	tc, err := NewTagsetCatalogue(dir)
+	assert.NoError(t, err)
+	pos, ok := tc.Get(builtinID)
+	assert.True(t, ok)
+	assert.Equal(t, "Overridden", pos.Name)
+	assert.Len(t, tc.List(), len(builtinPosList))
+}
+
+func TestTagsetCatalogueDeleteRestoresBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	builtin := builtinPosList[0]
+	tc, err := NewTagsetCatalogue(dir)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, tc.Delete(builtin.ID), ErrTagsetReadOnly)
+
+	modified := builtin
+	modified.Name = "Modified"
+	assert.NoError(t, tc.Update(modified))
+	path := filepath.Join(dir, builtin.ID+".json")
+	assert.FileExists(t, path)
+	pos, _ := tc.Get(builtin.ID)
+	assert.Equal(t, "Modified", pos.Name)
+
+	assert.NoError(t, tc.Delete(builtin.ID))
+	assert.NoFileExists(t, path)
+	pos, ok := tc.Get(builtin.ID)
+	assert.True(t, ok)
+	assert.Equal(t, builtin.Name, pos.Name)
+
+	assert.ErrorIs(t, tc.Delete("not_there"), ErrTagsetNotFound)
+}
+
+func TestTagsetCatalogueCreateUpdateDelete(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := writeTagsetFile(t, dir, "my_tagset.yaml", testTagsetYAML)
+	tc, err := NewTagsetCatalogue(dir)
+	assert.NoError(t, err)
+
+	pos, _ := tc.Get("my_tagset")
+	assert.ErrorIs(t, tc.Create(pos), ErrTagsetExists)
+
+	// the original file format is preserved
+	pos.Name = "Updated"
+	assert.NoError(t, tc.Update(pos))
+	reloaded, err := loadTagsetFile(yamlPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "Updated", reloaded.Name)
+
+	pos.ID = "other_tagset"
+	assert.NoError(t, tc.Create(pos))
+	assert.FileExists(t, filepath.Join(dir, "other_tagset.json"))
+
+	assert.NoError(t, tc.Delete("my_tagset"))
+	_, ok := tc.Get("my_tagset")
+	assert.False(t, ok)
+	assert.NoFileExists(t, yamlPath)
+}
+
+func TestTagsetCatalogueFailedReloadKeepsTagsets(t *testing.T) {
+	dir := t.TempDir()
+	writeTagsetFile(t, dir, "my_tagset.json", testTagsetJSON)
+	tc, err := NewTagsetCatalogue(dir)
+	assert.NoError(t, err)
+
+	writeTagsetFile(t, dir, "broken.json", `{"id": "broken", `)
+	assert.Error(t, tc.Load())
+	_, ok := tc.Get("my_tagset")
+	assert.True(t, ok)
+
+	assert.NoError(t, os.Remove(filepath.Join(dir, "broken.json")))
+	assert.NoError(t, os.Remove(filepath.Join(dir, "my_tagset.json")))
+	assert.NoError(t, tc.Load())
+	_, ok = tc.Get("my_tagset")
+	assert.False(t, ok)
+}
+
+func TestTagsetCatalogueReadOnly(t *testing.T) {
+	tc, err := NewTagsetCatalogue("")
+	assert.NoError(t, err)
+	assert.Len(t, tc.List(), len(builtinPosList))
+	pos := builtinPosList[0]
+	pos.ID = "new_one"
+	assert.ErrorIs(t, tc.Create(pos), ErrTagsetReadOnly)
+	assert.ErrorIs(t, tc.Update(builtinPosList[0]), ErrTagsetReadOnly)
+}
@@ -0,0 +1,340 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/jobs"
+	"masm/v3/mango"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TagsetTestJobType = "tagsetTest"
+
+	defaultTagAttr = "tag"
+
+	// maxTestedTagValues limits number of tag values (taken
+	// from the attribute lexicon) we test patterns against
+	maxTestedTagValues = 100000
+
+	// maxCountedUncoveredValues limits number of count queries
+	// for tag values not covered by any pattern
+	maxCountedUncoveredValues = 100
+
+	// maxCountedOverlaps limits number of count queries
+	// for overlapping pairs of patterns
+	maxCountedOverlaps = 100
+
+	// maxOverlapExamples limits number of tag values listed
+	// for each pair of overlapping patterns
+	maxOverlapExamples = 10
+)
+
+// PatternTestResult describes how a single tagset pattern
+// matches corpus data
+type PatternTestResult struct {
+	Label        string `json:"label"`
+	Pattern      string `json:"pattern"`
+	NumTokens    int64  `json:"numTokens"`
+	NumTagValues int    `json:"numTagValues"`
+	Error        string `json:"error,omitempty"`
+}
+
+// UncoveredTagValue is a tag value not matched by any
+// pattern of a tagset. NumTokens is -1 in case the value
+// has not been counted (see maxCountedUncoveredValues).
+type UncoveredTagValue struct {
+	Value     string `json:"value"`
+	NumTokens int64  `json:"numTokens"`
+}
+
+// PatternOverlap describes two patterns matching
+// the same tag values. NumTokens is -1 in case the overlap
+// has not been counted (see maxCountedOverlaps).
+type PatternOverlap struct {
+	Labels       [2]string `json:"labels"`
+	Patterns     [2]string `json:"patterns"`
+	NumTagValues int       `json:"numTagValues"`
+	Examples     []string  `json:"examples"`
+	NumTokens    int64     `json:"numTokens"`
+}
+
+// TagsetTestReport is a result of testing a tagset against
+// a tag attribute of a corpus. Token counts are obtained via
+// Manatee queries, tag value coverage is evaluated on the attribute
+// lexicon (using Go regular expressions which may slightly differ
+// from Manatee ones in some corner cases).
+type TagsetTestReport struct {
+	PosID              string              `json:"posId"`
+	CorpusID           string              `json:"corpusId"`
+	Attr               string              `json:"attr"`
+	CorpusSize         int64               `json:"corpusSize"`
+	NumTagValues       int                 `json:"numTagValues"`
+	TagValuesTruncated bool                `json:"tagValuesTruncated"`
+	CoveredTokens      int64               `json:"coveredTokens"`
+	Patterns           []PatternTestResult `json:"patterns"`
+	UncoveredValues    []UncoveredTagValue `json:"uncoveredValues"`
+	Overlaps           []PatternOverlap    `json:"overlaps"`
+}
+
+// cqlQuote escapes a string so it can be used within
+// a double-quoted CQL value
+func cqlQuote(s string) string {
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
+
+// tagsetTester evaluates patterns of a tagset against values
+// of a tag attribute. Tokens are counted via the count function
+// (a Manatee query in production).
+type tagsetTester struct {
+	pos    Pos
+	attr   string
+	values []string
+	count  func(query string) (int64, error)
+}
+
+func (tt *tagsetTester) attrQuery(pattern string) string {
+	return fmt.Sprintf(`[%s="%s"]`, tt.attr, cqlQuote(pattern))
+}
+
+// matchPatterns returns indices of values matched by individual
+// patterns and a flag for each value whether it is matched by any
+// of the patterns. Invalid patterns match nothing.
+func (tt *tagsetTester) matchPatterns() ([][]int, []bool) {
+	matches := make([][]int, len(tt.pos.Values))
+	covered := make([]bool, len(tt.values))
+	for i, item := range tt.pos.Values {
+		// Manatee patterns always match whole values
+		ptn, err := regexp.Compile("^(?:" + item.TagSrchPattern + ")$")
+		if err != nil {
+			log.Warn().Err(err).Str("posId", tt.pos.ID).Msg("cannot evaluate pattern coverage")
+			continue
+		}
+		for j, v := range tt.values {
+			if ptn.MatchString(v) {
+				matches[i] = append(matches[i], j)
+				covered[j] = true
+			}
+		}
+	}
+	return matches, covered
+}
+
+// run creates the report. The report contains everything but
+// the corpus information (ID, size).
+func (tt *tagsetTester) run(ctx context.Context, job *jobs.Job) (*TagsetTestReport, error) {
+	report := &TagsetTestReport{
+		PosID:              tt.pos.ID,
+		Attr:               tt.attr,
+		NumTagValues:       len(tt.values),
+		TagValuesTruncated: len(tt.values) >= maxTestedTagValues,
+		Patterns:           make([]PatternTestResult, len(tt.pos.Values)),
+		UncoveredValues:    []UncoveredTagValue{},
+		Overlaps:           []PatternOverlap{},
+	}
+	matches, covered := tt.matchPatterns()
+	numSteps := int64(len(tt.pos.Values) + 2)
+	var err error
+	for i, item := range tt.pos.Values {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		job.SetProgress(int64(i), numSteps, "counting pattern "+item.Label)
+		res := &report.Patterns[i]
+		res.Label = item.Label
+		res.Pattern = item.TagSrchPattern
+		res.NumTagValues = len(matches[i])
+		res.NumTokens, err = tt.count(tt.attrQuery(item.TagSrchPattern))
+		if err != nil {
+			res.Error = err.Error()
+		}
+	}
+
+	patterns := make([]string, len(tt.pos.Values))
+	for i, item := range tt.pos.Values {
+		patterns[i] = item.TagSrchPattern
+	}
+	if len(patterns) > 0 {
+		report.CoveredTokens, err = tt.count(tt.attrQuery(strings.Join(patterns, "|")))
+		if err != nil {
+			log.Warn().Err(err).Str("posId", tt.pos.ID).Msg("failed to count covered tokens")
+		}
+	}
+
+	job.SetProgress(numSteps-2, numSteps, "counting uncovered values")
+	for j, v := range tt.values {
+		if covered[j] {
+			continue
+		}
+		item := UncoveredTagValue{Value: v, NumTokens: -1}
+		if len(report.UncoveredValues) < maxCountedUncoveredValues {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			item.NumTokens, err = tt.count(tt.attrQuery(regexp.QuoteMeta(v)))
+			if err != nil {
+				log.Warn().Err(err).Str("value", v).Msg("failed to count tag value")
+				item.NumTokens = -1
+			}
+		}
+		report.UncoveredValues = append(report.UncoveredValues, item)
+	}
+	sort.SliceStable(report.UncoveredValues, func(i, j int) bool {
+		return report.UncoveredValues[i].NumTokens > report.UncoveredValues[j].NumTokens
+	})
+
+	job.SetProgress(numSteps-1, numSteps, "counting overlaps")
+	for i := 0; i < len(tt.pos.Values); i++ {
+		for j := i + 1; j < len(tt.pos.Values); j++ {
+			shared := intersectSorted(matches[i], matches[j])
+			if len(shared) == 0 {
+				continue
+			}
+			overlap := PatternOverlap{
+				Labels:       [2]string{tt.pos.Values[i].Label, tt.pos.Values[j].Label},
+				Patterns:     [2]string{patterns[i], patterns[j]},
+				NumTagValues: len(shared),
+				Examples:     make([]string, 0, maxOverlapExamples),
+				NumTokens:    -1,
+			}
+			for _, idx := range shared {
+				if len(overlap.Examples) == maxOverlapExamples {
+					break
+				}
+				overlap.Examples = append(overlap.Examples, tt.values[idx])
+			}
+			if len(report.Overlaps) < maxCountedOverlaps {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				overlap.NumTokens, err = tt.count(
+					fmt.Sprintf(
+						`[%s="%s" & %s="%s"]`,
+						tt.attr, cqlQuote(patterns[i]), tt.attr, cqlQuote(patterns[j]),
+					),
+				)
+				if err != nil {
+					log.Warn().Err(err).Str("posId", tt.pos.ID).Msg("failed to count overlapping tokens")
+					overlap.NumTokens = -1
+				}
+			}
+			report.Overlaps = append(report.Overlaps, overlap)
+		}
+	}
+	job.SetProgress(numSteps, numSteps, "done")
+	return report, nil
+}
+
+// TagsetTest tests all patterns of a tagset against a positional
+// attribute (typically `tag`) of a corpus. The test runs as a job.
+type TagsetTest struct {
+	pos        Pos
+	corpusID   string
+	attr       string
+	corpHandle *corpus.CorpusHandle
+	createFn   func(corpus *mango.GoCorpus, query string) (*mango.GoConc, error)
+	deleteFn   func(conc *mango.GoConc)
+}
+
+// countQuery returns size of a concordance of the query. The concordance
+// is freed immediately as we need just its size.
+func (tt *TagsetTest) countQuery(query string) (int64, error) {
+	conc, err := tt.createFn(tt.corpHandle.Corpus(), query)
+	if err != nil {
+		return 0, err
+	}
+	defer tt.deleteFn(conc)
+	return conc.Size(), nil
+}
+
+// Run is a jobs.Func producing *TagsetTestReport
+func (tt *TagsetTest) Run(ctx context.Context, job *jobs.Job) (any, error) {
+	defer tt.Close()
+	corp := tt.corpHandle.Corpus()
+	corpSize, err := mango.GetCorpusSize(corp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to test tagset %s: %w", tt.pos.ID, err)
+	}
+	values, err := mango.GetAttrValues(corp, tt.attr, maxTestedTagValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to test tagset %s: %w", tt.pos.ID, err)
+	}
+	tester := &tagsetTester{pos: tt.pos, attr: tt.attr, values: values, count: tt.countQuery}
+	report, err := tester.run(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	report.CorpusID = tt.corpusID
+	report.CorpusSize = corpSize
+	return report, nil
+}
+
+// Close releases the corpus. It should be called only
+// in case the test is not run.
+func (tt *TagsetTest) Close() {
+	tt.corpHandle.Release()
+}
+
+// NewTagsetTest prepares a test of a tagset against a corpus
+// attribute (`tag` if attr is empty). The corpus is obtained from
+// the pool and it is released once the test finishes
+// (or once Close is called).
+func NewTagsetTest(pos Pos, corpusID, attr string, pool *corpus.CorpusPool) (*TagsetTest, error) {
+	if attr == "" {
+		attr = defaultTagAttr
+	}
+	corpHandle, err := pool.Acquire(corpusID)
+	if err != nil {
+		return nil, err
+	}
+	return &TagsetTest{
+		pos:        pos,
+		corpusID:   corpusID,
+		attr:       attr,
+		corpHandle: corpHandle,
+		createFn:   mango.CreateConcordance,
+		deleteFn:   mango.DeleteConcordance,
+	}, nil
+}
+
+// intersectSorted returns common items of two
+// ascending slices
+func intersectSorted(a, b []int) []int {
+	ans := make([]int, 0)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			ans = append(ans, a[i])
+			i++
+			j++
+		}
+	}
+	return ans
+}
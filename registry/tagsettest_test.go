@@ -0,0 +1,132 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"masm/v3/jobs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntersectSorted(t *testing.T) {
+	assert.Equal(t, []int{2, 5}, intersectSorted([]int{1, 2, 4, 5}, []int{2, 3, 5, 7}))
+	assert.Equal(t, []int{}, intersectSorted([]int{1, 3}, []int{2, 4}))
+	assert.Equal(t, []int{}, intersectSorted(nil, []int{1}))
+	assert.Equal(t, []int{1, 2, 3}, intersectSorted([]int{1, 2, 3}, []int{1, 2, 3}))
+}
+
+func newTestTagsetTester(counts map[string]int64) *tagsetTester {
+	return &tagsetTester{
+		pos: Pos{
+			ID: "test",
+			Values: []PosItem{
+				{Label: "noun", TagSrchPattern: "N.*"},
+				{Label: "proper", TagSrchPattern: "NP.*"},
+				{Label: "verb", TagSrchPattern: "V.*"},
+				{Label: "broken", TagSrchPattern: "("},
+			},
+		},
+		attr:   "tag",
+		values: []string{"NN", "NNS", "NP", "VB", "DT", "Z:"},
+		count: func(query string) (int64, error) {
+			if v, ok := counts[query]; ok {
+				return v, nil
+			}
+			return 0, fmt.Errorf("unexpected query %s", query)
+		},
+	}
+}
+
+func TestTagsetTesterMatchPatterns(t *testing.T) {
+	tt := newTestTagsetTester(nil)
+	matches, covered := tt.matchPatterns()
+	assert.Equal(t, [][]int{{0, 1, 2}, {2}, {3}, nil}, matches)
+	assert.Equal(t, []bool{true, true, true, true, false, false}, covered)
+}
+
+func TestTagsetTesterRun(t *testing.T) {
+	tt := newTestTagsetTester(map[string]int64{
+		`[tag="N.*"]`:              100,
+		`[tag="NP.*"]`:             10,
+		`[tag="V.*"]`:              50,
+		`[tag="N.*|NP.*|V.*|("]`:   150,
+		`[tag="DT"]`:               5,
+		`[tag="Z:"]`:               20,
+		`[tag="N.*" & tag="NP.*"]`: 10,
+	})
+	report, err := tt.run(context.Background(), &jobs.Job{})
+	assert.NoError(t, err)
+	assert.Equal(t, 6, report.NumTagValues)
+	assert.False(t, report.TagValuesTruncated)
+	assert.Equal(t, int64(150), report.CoveredTokens)
+
+	assert.Len(t, report.Patterns, 4)
+	assert.Equal(t, PatternTestResult{Label: "noun", Pattern: "N.*", NumTokens: 100, NumTagValues: 3}, report.Patterns[0])
+	assert.Equal(t, 1, report.Patterns[2].NumTagValues)
+	assert.Equal(t, 0, report.Patterns[3].NumTagValues)
+	assert.NotEmpty(t, report.Patterns[3].Error)
+
+	// sorted by frequency
+	assert.Equal(
+		t,
+		[]UncoveredTagValue{{Value: "Z:", NumTokens: 20}, {Value: "DT", NumTokens: 5}},
+		report.UncoveredValues,
+	)
+
+	assert.Len(t, report.Overlaps, 1)
+	assert.Equal(t, [2]string{"noun", "proper"}, report.Overlaps[0].Labels)
+	assert.Equal(t, 1, report.Overlaps[0].NumTagValues)
+	assert.Equal(t, []string{"NP"}, report.Overlaps[0].Examples)
+	assert.Equal(t, int64(10), report.Overlaps[0].NumTokens)
+}
+
+func TestTagsetTesterRunLimitsCounting(t *testing.T) {
+	values := make([]string, maxCountedUncoveredValues+5)
+	for i := range values {
+		values[i] = fmt.Sprintf("X%d", i)
+	}
+	var numQueries int
+	tt := &tagsetTester{
+		pos:    Pos{ID: "test", Values: []PosItem{{Label: "noun", TagSrchPattern: "N.*"}}},
+		attr:   "tag",
+		values: values,
+		count: func(query string) (int64, error) {
+			numQueries++
+			return 1, nil
+		},
+	}
+	report, err := tt.run(context.Background(), &jobs.Job{})
+	assert.NoError(t, err)
+	assert.Len(t, report.UncoveredValues, len(values))
+	assert.Equal(t, int64(-1), report.UncoveredValues[len(values)-1].NumTokens)
+	// pattern + all patterns + counted uncovered values
+	assert.Equal(t, 2+maxCountedUncoveredValues, numQueries)
+}
+
+func TestTagsetTesterRunCancelled(t *testing.T) {
+	tt := newTestTagsetTester(map[string]int64{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := tt.run(ctx, &jobs.Job{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,296 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"masm/v3/corpus"
+	"masm/v3/registry/parser"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+
+	dynlibInternal = "internal"
+)
+
+var (
+	attrIndexFileSuffixes    = []string{".lex", ".lex.idx", ".rev", ".text"}
+	dynAttrIndexFileSuffixes = []string{".lex", ".lex.idx"}
+	structIndexFileSuffixes  = []string{".rng"}
+)
+
+// ValidationIssue is a single problem found in a registry
+type ValidationIssue struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Item     string `json:"item"`
+	Message  string `json:"message"`
+}
+
+// ValidationReport is a result of checking a registry
+// against indexed corpus data.
+type ValidationReport struct {
+	CorpusID string            `json:"corpusId"`
+	OK       bool              `json:"ok"`
+	Errors   []ValidationIssue `json:"errors"`
+	Warnings []ValidationIssue `json:"warnings"`
+}
+
+func (r *ValidationReport) addError(code, item, msg string, args ...any) {
+	r.Errors = append(
+		r.Errors,
+		ValidationIssue{
+			Severity: SeverityError,
+			Code:     code,
+			Item:     item,
+			Message:  fmt.Sprintf(msg, args...),
+		},
+	)
+}
+
+func (r *ValidationReport) addWarning(code, item, msg string, args ...any) {
+	r.Warnings = append(
+		r.Warnings,
+		ValidationIssue{
+			Severity: SeverityWarning,
+			Code:     code,
+			Item:     item,
+			Message:  fmt.Sprintf(msg, args...),
+		},
+	)
+}
+
+type registryValidator struct {
+	conf   *corpus.CorporaSetup
+	dynFns *DynFnCatalogue
+	doc    *parser.Document
+	report *ValidationReport
+}
+
+func (v *registryValidator) checkIndexFiles(dataPath, item, baseName string, suffixes []string) {
+	for _, suff := range suffixes {
+		fullPath := filepath.Join(dataPath, baseName+suff)
+		isFile, err := fs.IsFile(fullPath)
+		if err != nil {
+			v.report.addError("ioError", item, "failed to test %s: %s", fullPath, err)
+
+		} else if !isFile {
+			v.report.addError("missingIndexFile", item, "index file %s not found", fullPath)
+		}
+	}
+}
+
+func (v *registryValidator) validateDataPath() (string, bool) {
+	dataPath, ok := v.doc.Prop("PATH")
+	if !ok || dataPath == "" {
+		v.report.addError("missingProperty", "PATH", "PATH not specified")
+		return "", false
+	}
+	isDir, err := fs.IsDir(dataPath)
+	if err != nil {
+		v.report.addError("ioError", "PATH", "failed to test data path %s: %s", dataPath, err)
+		return "", false
+	}
+	if !isDir {
+		v.report.addError("dataNotFound", "PATH", "data directory %s not found", dataPath)
+		return "", false
+	}
+	return dataPath, true
+}
+
+func (v *registryValidator) validateDynamicAttr(attr *parser.Attribute) {
+	item := "attribute " + attr.Name
+	fromAttr, ok := attr.Prop("FROMATTR")
+	if !ok || fromAttr == "" {
+		v.report.addError("missingProperty", item, "dynamic attribute without FROMATTR")
+
+	} else if v.doc.Attribute(fromAttr) == nil {
+		v.report.addError("unknownAttribute", item, "FROMATTR refers to unknown attribute %s", fromAttr)
+	}
+	fnName, _ := attr.Prop("DYNAMIC")
+	dynlib, ok := attr.Prop("DYNLIB")
+	if !ok || dynlib == "" {
+		dynlib = dynlibInternal
+	}
+	if dynlib != dynlibInternal && dynlib != v.conf.ManateeDynlibPath {
+		v.report.addWarning(
+			"unknownDynlib", item, "DYNLIB %s is neither internal nor the configured dynlib", dynlib)
+		return
+	}
+	fn, ok := v.dynFns.Find(fnName, dynlib)
+	if !ok {
+		v.report.addError(
+			"unknownDynamicFunction", item, "function %s not found in dynlib %s", fnName, dynlib)
+		return
+	}
+	if fn.Problem == DynFnProblemNotExported {
+		v.report.addError(
+			"unknownDynamicFunction", item, "function %s is not exported by dynlib %s", fnName, dynlib)
+		return
+
+	} else if fn.Problem != "" {
+		v.report.addWarning(
+			"unverifiedDynamicFunction", item, "function %s cannot be verified (%s)", fnName, fn.Problem)
+		return
+	}
+	funtype, _ := attr.Prop("FUNTYPE")
+	for _, callErr := range fn.ValidateCall(funtype, dynFnCallArgs(attr)) {
+		v.report.addError(callErr.Code, item+" "+callErr.Property, "%s", callErr.Message)
+	}
+}
+
+// dynFnCallArgs returns ARG1, ARG2, ... values of
+// a dynamic attribute (up to the first missing one)
+func dynFnCallArgs(attr *parser.Attribute) []string {
+	ans := make([]string, 0, maxDynFnArgs)
+	for i := 1; ; i++ {
+		value, ok := attr.Prop("ARG" + strconv.Itoa(i))
+		if !ok {
+			return ans
+		}
+		ans = append(ans, value)
+	}
+}
+
+func (v *registryValidator) validateStructAttrRefs(prop string, refs []string) {
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+		tmp := strings.SplitN(ref, ".", 2)
+		if len(tmp) != 2 {
+			v.report.addError(
+				"invalidStructAttr", prop, "%s is not a structural attribute (struct.attr)", ref)
+			continue
+		}
+		st := v.doc.Structure(tmp[0])
+		if st == nil {
+			v.report.addError("unknownStructure", prop, "structure %s not found", tmp[0])
+
+		} else if st.Attribute(tmp[1]) == nil {
+			v.report.addError("unknownStructAttr", prop, "structural attribute %s not found", ref)
+		}
+	}
+}
+
+func (v *registryValidator) validatePosList(prop string) {
+	value, ok := v.doc.Prop(prop)
+	if !ok || value == "" {
+		return
+	}
+	// the first character of the value defines a separator
+	sep := value[:1]
+	items := strings.Split(value[1:], sep)
+	if len(items)%2 != 0 {
+		v.report.addError(
+			"invalidPosList", prop, "%s must contain pairs of labels and patterns", prop)
+		return
+	}
+	for i := 0; i < len(items); i += 2 {
+		if _, err := regexp.Compile(items[i+1]); err != nil {
+			v.report.addError(
+				"invalidPattern", prop, "pattern '%s' of '%s' does not compile: %s",
+				items[i+1], items[i], err)
+		}
+	}
+}
+
+func (v *registryValidator) run() {
+	if err := v.doc.Validate(); err != nil {
+		var verrs parser.ValidationErrors
+		if errors.As(err, &verrs) {
+			for _, verr := range verrs {
+				v.report.addError("syntax", verr.Path, "%s", verr.Msg)
+			}
+		}
+		return
+	}
+	dataPath, dataOK := v.validateDataPath()
+	for _, attr := range v.doc.Attributes() {
+		_, isDynamic := attr.Prop("DYNAMIC")
+		if isDynamic {
+			v.validateDynamicAttr(attr)
+		}
+		if !dataOK {
+			continue
+		}
+		if isDynamic {
+			v.checkIndexFiles(dataPath, "attribute "+attr.Name, attr.Name, dynAttrIndexFileSuffixes)
+
+		} else {
+			v.checkIndexFiles(dataPath, "attribute "+attr.Name, attr.Name, attrIndexFileSuffixes)
+		}
+	}
+	for _, st := range v.doc.Structures() {
+		if !dataOK {
+			break
+		}
+		item := "structure " + st.Name
+		v.checkIndexFiles(dataPath, item, st.Name, structIndexFileSuffixes)
+		for _, attr := range st.Attributes() {
+			v.checkIndexFiles(
+				dataPath, item+"."+attr.Name, st.Name+"."+attr.Name, attrIndexFileSuffixes)
+		}
+	}
+	if len(v.doc.Attributes()) == 0 {
+		v.report.addWarning("noAttributes", "ATTRIBUTE", "no positional attributes defined")
+	}
+	if subcAttrs, ok := v.doc.Prop("SUBCORPATTRS"); ok {
+		v.validateStructAttrRefs(
+			"SUBCORPATTRS",
+			strings.FieldsFunc(subcAttrs, func(c rune) bool { return c == ',' || c == '|' }),
+		)
+	}
+	if fullRef, ok := v.doc.Prop("FULLREF"); ok {
+		v.validateStructAttrRefs("FULLREF", strings.Split(fullRef, ","))
+	}
+	v.validatePosList("WPOSLIST")
+	v.validatePosList("LPOSLIST")
+}
+
+// ValidateRegistry checks a registry document against data
+// indexed on disk and the available dynamic functions.
+// Attributes and structures are taken from the document
+// (i.e. they correspond to Manatee's ATTRLIST and STRUCTLIST).
+func ValidateRegistry(
+	corpusID string,
+	doc *parser.Document,
+	conf *corpus.CorporaSetup,
+	dynFns *DynFnCatalogue,
+) *ValidationReport {
+	report := &ValidationReport{
+		CorpusID: corpusID,
+		Errors:   []ValidationIssue{},
+		Warnings: []ValidationIssue{},
+	}
+	v := &registryValidator{conf: conf, dynFns: dynFns, doc: doc, report: report}
+	v.run()
+	report.OK = len(report.Errors) == 0
+	return report
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2026 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//   This file is part of CNC-MASM.
+//
+//  CNC-MASM is free software: you can redistribute it and/or modify
+//  it under the terms of the GNU General Public License as published by
+//  the Free Software Foundation, either version 3 of the License, or
+//  (at your option) any later version.
+//
+//  CNC-MASM is distributed in the hope that it will be useful,
+//  but WITHOUT ANY WARRANTY; without even the implied warranty of
+//  MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//  GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+//  along with CNC-MASM.  If not, see <https://www.gnu.org/licenses/>.
+
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"masm/v3/corpus"
+	"masm/v3/registry/parser"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createIndexFiles(t *testing.T, dir string, baseNames map[string][]string) {
+	for base, suffixes := range baseNames {
+		for _, suff := range suffixes {
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, base+suff), []byte{}, 0644))
+		}
+	}
+}
+
+func validateTestRegistry(t *testing.T, src string) *ValidationReport {
+	doc, err := parser.ParseRegistry("test", []byte(src))
+	assert.NoError(t, err)
+	dynFns, err := LoadDynFnCatalogue(&corpus.CorporaSetup{})
+	assert.NoError(t, err)
+	return ValidateRegistry("test", doc, &corpus.CorporaSetup{}, dynFns)
+}
+
+func issueCodes(issues []ValidationIssue) []string {
+	ans := make([]string, len(issues))
+	for i, v := range issues {
+		ans[i] = v.Code
+	}
+	return ans
+}
+
+func TestValidateRegistryOK(t *testing.T) {
+	dir := t.TempDir()
+	createIndexFiles(t, dir, map[string][]string{
+		"word":   attrIndexFileSuffixes,
+		"lc":     dynAttrIndexFileSuffixes,
+		"doc":    structIndexFileSuffixes,
+		"doc.id": attrIndexFileSuffixes,
+	})
+	report := validateTestRegistry(t, `PATH "`+dir+`"
+ATTRIBUTE word
+ATTRIBUTE lc {
+	DYNAMIC utf8lowercase
+	DYNLIB internal
+	FUNTYPE s
+	ARG1 "C"
+	FROMATTR word
+}
+STRUCTURE doc {
+	ATTRIBUTE id
+}
+SUBCORPATTRS "doc.id"
+WPOSLIST ",noun,N.*,verb,V.*"
+`)
+	assert.True(t, report.OK, report.Errors)
+	assert.Empty(t, report.Warnings)
+}
+
+func TestValidateRegistryProblems(t *testing.T) {
+	dir := t.TempDir()
+	createIndexFiles(t, dir, map[string][]string{"word": {".lex", ".rev"}})
+	report := validateTestRegistry(t, `PATH "`+dir+`"
+ATTRIBUTE word
+ATTRIBUTE lc {
+	DYNAMIC utf8lowercase
+	FUNTYPE s
+	ARG1 "C"
+	FROMATTR lemma
+}
+SUBCORPATTRS "doc.id,text"
+FULLREF "s.id"
+WPOSLIST ",noun,N(.*"
+`)
+	assert.False(t, report.OK)
+	assert.ElementsMatch(
+		t,
+		[]string{
+			"unknownAttribute",
+			"missingIndexFile", "missingIndexFile", // word.lex.idx, word.text
+			"missingIndexFile", "missingIndexFile", // lc.lex, lc.lex.idx
+			"unknownStructure", "invalidStructAttr", "unknownStructure",
+			"invalidPattern",
+		},
+		issueCodes(report.Errors),
+	)
+}
+
+func TestValidateRegistryMissingPath(t *testing.T) {
+	report := validateTestRegistry(t, "ATTRIBUTE word\n")
+	assert.Equal(t, []string{"missingProperty"}, issueCodes(report.Errors))
+
+	report = validateTestRegistry(t, "PATH /nonexistent/masm/data\n")
+	assert.Equal(t, []string{"dataNotFound"}, issueCodes(report.Errors))
+	assert.Equal(t, []string{"noAttributes"}, issueCodes(report.Warnings))
+}
+
+func TestValidateRegistryInvalidCall(t *testing.T) {
+	report := validateTestRegistry(t, `PATH /nonexistent
+ATTRIBUTE word
+ATTRIBUTE w1 {
+	DYNAMIC getnbysep
+	FUNTYPE ci
+	ARG1 "||"
+	ARG2 "1%d"
+	FROMATTR word
+}
+`)
+	var msgs []string
+	for _, e := range report.Errors {
+		if strings.HasPrefix(e.Item, "attribute w1 ARG") {
+			msgs = append(msgs, e.Message)
+		}
+	}
+	assert.Len(t, msgs, 2)
+	for _, msg := range msgs {
+		assert.NotContains(t, msg, "%!")
+	}
+}
+
+func TestValidateRegistrySyntaxWithPercent(t *testing.T) {
+	doc := &parser.Document{
+		Entries: []*parser.Entry{
+			{Attribute: &parser.Attribute{Name: "a%d b", Entries: []*parser.Entry{}}},
+		},
+	}
+	dynFns, err := LoadDynFnCatalogue(&corpus.CorporaSetup{})
+	assert.NoError(t, err)
+	report := ValidateRegistry("test", doc, &corpus.CorporaSetup{}, dynFns)
+	assert.Len(t, report.Errors, 1)
+	assert.Equal(t, "syntax", report.Errors[0].Code)
+	assert.Contains(t, report.Errors[0].Message, "'a%d b'")
+}